@@ -0,0 +1,279 @@
+// Package analysis reports the quality of the moves of played games:
+// evaluations, centipawn losses, accuracies, mistakes and missed mates.
+// Positions are evaluated by an Analyser, the built-in engine or a UCI engine
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Centipawns of a checkmate on the board
+const MateCentipawns = 100000
+
+// Evaluations are capped by it when the losses and the winning chances are computed
+const maxCentipawns = 1000
+
+// Score of a position, the positive one is better for the side it is given for
+type Score struct {
+	Centipawns int
+	// Moves to mate, negative if the side gets mated, zero if there is no mate
+	Mate int
+}
+
+// Returns the score for the other side
+func (s Score) Negate() Score {
+	return Score{Centipawns: -s.Centipawns, Mate: -s.Mate}
+}
+
+// Returns the score in centipawns capped by 1000, mates are worth the cap
+func (s Score) capped() int {
+	switch {
+	case s.Mate > 0:
+		return maxCentipawns
+	case s.Mate < 0:
+		return -maxCentipawns
+	}
+	return max(-maxCentipawns, min(s.Centipawns, maxCentipawns))
+}
+
+// Checks the side mates or has a forced mate
+func (s Score) mates() bool {
+	return s.Mate > 0 || s.Centipawns >= MateCentipawns
+}
+
+// Returns the score like "+0.35", "#3" or "#-2", a checkmate on the board is "#0"
+func (s Score) String() string {
+	switch {
+	case s.Mate != 0:
+		return fmt.Sprintf("#%d", s.Mate)
+	case s.Centipawns >= MateCentipawns || s.Centipawns <= -MateCentipawns:
+		return "#0"
+	}
+	return fmt.Sprintf("%+.2f", float64(s.Centipawns)/100)
+}
+
+// Evaluation of a position by an analyser
+type Evaluation struct {
+	// Score from the side to move
+	Score Score
+	// Best line, empty if the analyser doesn't know it
+	Line []core.Move
+}
+
+// Analyser evaluates positions of games. The game mustn't be changed,
+// its moves are given for the analysers which detect repetitions
+type Analyser interface {
+	Analyse(ctx context.Context, game *core.Game) (Evaluation, error)
+}
+
+// Judgement is a classification of a move by the winning chances it loses
+type Judgement int
+
+const (
+	Best Judgement = iota
+	Good
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+func (j Judgement) String() string {
+	switch j {
+	case Best:
+		return "best"
+	case Good:
+		return "good"
+	case Inaccuracy:
+		return "inaccuracy"
+	case Mistake:
+		return "mistake"
+	case Blunder:
+		return "blunder"
+	}
+	return fmt.Sprintf("Judgement(%d)", int(j))
+}
+
+// Winning chances in percents lost by the moves of the judgements
+var thresholds = []struct {
+	judgement Judgement
+	loss      float64
+}{
+	{Blunder, 15},
+	{Mistake, 10},
+	{Inaccuracy, 5},
+}
+
+// MoveReport is the analysis of a played move
+type MoveReport struct {
+	Move core.Move
+	SAN  string
+	Side core.Side
+	// Evaluation after the move from white
+	Score Score
+	// Centipawns the move lost compared to the best move
+	Loss int
+	// Accuracy of the move in percents
+	Accuracy  float64
+	Judgement Judgement
+	// Side had a forced mate before the move but not after it
+	MissedMate bool
+	// Best line of the position before the move if the move is not the best one
+	Alternative []core.Move
+}
+
+// SideReport sums up the moves of a side
+type SideReport struct {
+	// Average accuracy of the moves in percents
+	Accuracy float64
+	// Average centipawn loss of the moves
+	AverageLoss                      float64
+	Inaccuracies, Mistakes, Blunders int
+	MissedMates                      int
+}
+
+type Report struct {
+	// Evaluation of the start position from white
+	Start        Score
+	Moves        []MoveReport
+	White, Black SideReport
+}
+
+// Analyse evaluates every position of the game by the analyser and reports the played moves
+func Analyse(ctx context.Context, game *core.Game, a Analyser) (*Report, error) {
+	moves := game.Moves()
+	evals := make([]Evaluation, len(moves)+1)
+
+	g := game.Start().Game()
+	for i := range evals {
+		eval, err := evaluate(ctx, &g, a)
+		if err != nil {
+			return nil, fmt.Errorf("analysis: move %d: %w", i, err)
+		}
+		evals[i] = eval
+
+		if i < len(moves) {
+			if err := g.Play(moves[i]); err != nil {
+				return nil, fmt.Errorf("analysis: move %d: %w", i, err)
+			}
+		}
+	}
+
+	g = game.Start().Game()
+	res := &Report{Start: whiteScore(evals[0].Score, g.Turn())}
+	for i, move := range moves {
+		side := g.Turn()
+		// Scores of the side which moves
+		before, after := evals[i].Score, evals[i+1].Score.Negate()
+
+		report := MoveReport{
+			Move:  move,
+			SAN:   g.SAN(move),
+			Side:  side,
+			Score: whiteScore(after, side),
+			Loss:  max(0, before.capped()-after.capped()),
+		}
+
+		lost := winPercent(before) - winPercent(after)
+		report.Accuracy = accuracy(lost)
+
+		report.MissedMate = before.mates() && !after.mates()
+		if len(evals[i].Line) == 0 || g.CompleteMove(evals[i].Line[0]) != g.CompleteMove(move) {
+			report.Judgement = judge(before, after, lost)
+			report.Alternative = evals[i].Line
+		}
+
+		res.Moves = append(res.Moves, report)
+		g.Play(move)
+	}
+
+	res.White = summarize(res.Moves, core.White)
+	res.Black = summarize(res.Moves, core.Black)
+	return res, nil
+}
+
+// Evaluates the game by the analyser, ended games are evaluated by the rules
+func evaluate(ctx context.Context, game *core.Game, a Analyser) (Evaluation, error) {
+	switch game.Outcome() {
+	case core.Checkmate:
+		return Evaluation{Score: Score{Centipawns: -MateCentipawns}}, nil
+	case core.Stalemate:
+		return Evaluation{}, nil
+	}
+	return a.Analyse(ctx, game)
+}
+
+// Judges the move by the lost winning chances, the lost mate is judged by the score kept
+func judge(before, after Score, lost float64) Judgement {
+	if before.mates() && !after.mates() {
+		switch cp := after.capped(); {
+		case cp >= maxCentipawns:
+			return Inaccuracy
+		case cp > 700:
+			return Mistake
+		}
+		return Blunder
+	}
+
+	for _, t := range thresholds {
+		if lost >= t.loss {
+			return t.judgement
+		}
+	}
+	return Good
+}
+
+// Returns winning chances of the side in percents by the score
+func winPercent(s Score) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(s.capped())))-1)
+}
+
+// Returns accuracy of the move by the lost winning chances, a point is added
+// for the imperfect analysis
+func accuracy(lost float64) float64 {
+	if lost <= 0 {
+		return 100
+	}
+	return max(0, min(100, 103.1668100711649*math.Exp(-0.04354415386753951*lost)-3.166924740191411+1))
+}
+
+func whiteScore(s Score, side core.Side) Score {
+	if side == core.Black {
+		return s.Negate()
+	}
+	return s
+}
+
+func summarize(moves []MoveReport, side core.Side) SideReport {
+	var res SideReport
+	n := 0
+	for _, m := range moves {
+		if m.Side != side {
+			continue
+		}
+
+		n++
+		res.Accuracy += m.Accuracy
+		res.AverageLoss += float64(m.Loss)
+		switch m.Judgement {
+		case Inaccuracy:
+			res.Inaccuracies++
+		case Mistake:
+			res.Mistakes++
+		case Blunder:
+			res.Blunders++
+		}
+		if m.MissedMate {
+			res.MissedMates++
+		}
+	}
+
+	if n > 0 {
+		res.Accuracy /= float64(n)
+		res.AverageLoss /= float64(n)
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+func play(t *testing.T, sans ...string) core.Game {
+	t.Helper()
+
+	game := core.NewGame()
+	for _, san := range sans {
+		move, err := game.ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return game
+}
+
+// Black blunders into the mate, white misses it and black returns the favour
+var blunders = []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "d3", "Nxh5", "Nf3", "Nf6", "Ng5", "d5", "Bxd5", "Nxd5", "Nxf7", "Kxf7"}
+
+func checkReport(t *testing.T, report *Report) {
+	t.Helper()
+
+	if len(report.Moves) != len(blunders) {
+		t.Fatalf("Expected %d moves, got %d", len(blunders), len(report.Moves))
+	}
+
+	nf6, d3 := report.Moves[5], report.Moves[6]
+	if nf6.SAN != "Nf6" || nf6.Side != core.Black || nf6.Judgement != Blunder || nf6.Score.Mate != 1 {
+		t.Fatalf("Unexpected report of Nf6: %+v", nf6)
+	}
+	if !d3.MissedMate || d3.Judgement != Blunder || d3.Loss < 900 || d3.Accuracy > 10 || d3.Score.Centipawns > -500 {
+		t.Fatalf("Unexpected report of d3: %+v", d3)
+	}
+	game := play(t, blunders[:6]...)
+	if len(d3.Alternative) == 0 || game.SAN(d3.Alternative[0]) != "Qxf7#" {
+		t.Fatalf("Unexpected alternative of d3: %v", d3.Alternative)
+	}
+
+	if e4 := report.Moves[0]; e4.Judgement > Good || e4.Accuracy < 90 {
+		t.Fatalf("Unexpected report of e4: %+v", e4)
+	}
+	if report.White.Blunders == 0 || report.White.MissedMates != 1 || report.Black.Blunders == 0 || report.Black.MissedMates != 0 {
+		t.Fatalf("Unexpected sums %+v and %+v", report.White, report.Black)
+	}
+	if report.White.Accuracy <= 0 || report.White.Accuracy >= 100 || report.White.AverageLoss <= 0 {
+		t.Fatalf("Unexpected sums of white %+v", report.White)
+	}
+}
+
+func TestAnalyse(t *testing.T) {
+	game := play(t, blunders...)
+	report, err := Analyse(context.Background(), &game, NewBuiltin(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReport(t, report)
+}
+
+func TestAnalyse_Checkmate(t *testing.T) {
+	game := play(t, "e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7#")
+	report, err := Analyse(context.Background(), &game, NewBuiltin(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mate := report.Moves[6]
+	if mate.Judgement != Best || mate.Loss != 0 || mate.Accuracy != 100 || mate.Score.String() != "#0" {
+		t.Fatalf("Unexpected report of the mate: %+v", mate)
+	}
+	if report.Start.Mate != 0 || report.Start.Centipawns < 0 || report.Start.Centipawns > 100 {
+		t.Fatalf("Unexpected start score %v", report.Start)
+	}
+}
+
+func TestAnalyse_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	game := play(t, "e4")
+	if _, err := Analyse(ctx, &game, NewBuiltin(2)); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestScore_String(t *testing.T) {
+	tests := []struct {
+		score    Score
+		expected string
+	}{
+		{Score{Centipawns: 35}, "+0.35"},
+		{Score{Centipawns: -120}, "-1.20"},
+		{Score{}, "+0.00"},
+		{Score{Mate: 3}, "#3"},
+		{Score{Mate: -2}, "#-2"},
+		{Score{Centipawns: -MateCentipawns}, "#0"},
+	}
+
+	for _, test := range tests {
+		if s := test.score.String(); s != test.expected {
+			t.Fatalf("%v: expected %s, got %s", test.score, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package analysis
+
+import (
+	"context"
+
+	"github.com/zzvanq/shahio/core"
+	"github.com/zzvanq/shahio/engine"
+)
+
+// Builtin analyses positions by the built-in engine searching them to the depth
+type Builtin struct {
+	engine *engine.Engine
+	depth  int
+}
+
+func NewBuiltin(depth int) *Builtin {
+	return &Builtin{engine: engine.New(engine.Options{}), depth: depth}
+}
+
+func (b *Builtin) Analyse(ctx context.Context, game *core.Game) (Evaluation, error) {
+	res := b.engine.Search(ctx, game, b.depth)
+	if err := ctx.Err(); err != nil {
+		return Evaluation{}, err
+	}
+
+	score := Score{Centipawns: res.Score}
+	if mate := engine.MateIn(res.Score); mate != 0 {
+		score = Score{Mate: mate}
+	}
+	return Evaluation{Score: score, Line: res.Line}, nil
+}
@@ -0,0 +1,197 @@
+package analysis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Time the engine has to answer the commands which don't search
+const uciTimeout = 10 * time.Second
+
+var errEngineExited = errors.New("analysis: engine exited")
+
+// UCI analyses positions by a local engine binary speaking the UCI protocol
+type UCI struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	// Lines written by the engine, closed when it exits
+	lines chan string
+	depth int
+}
+
+// StartUCI runs the engine with the arguments, the positions are searched to the depth
+func StartUCI(depth int, path string, args ...string) (*UCI, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("analysis: %w", err)
+	}
+
+	u := &UCI{cmd: cmd, stdin: stdin, lines: make(chan string, 64), depth: depth}
+	go func() {
+		defer close(u.lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			u.lines <- scanner.Text()
+		}
+	}()
+
+	if err := u.command("uci", "uciok"); err != nil {
+		u.Close()
+		return nil, err
+	}
+	if err := u.command("isready", "readyok"); err != nil {
+		u.Close()
+		return nil, err
+	}
+	return u, nil
+}
+
+// SetOption sets the option of the engine like "Threads" or "Hash"
+func (u *UCI) SetOption(name, value string) error {
+	if err := u.send(fmt.Sprintf("setoption name %s value %s", name, value)); err != nil {
+		return err
+	}
+	return u.command("isready", "readyok")
+}
+
+// Close asks the engine to quit and waits for it
+func (u *UCI) Close() error {
+	u.send("quit")
+	u.stdin.Close()
+	for range u.lines {
+	}
+	return u.cmd.Wait()
+}
+
+func (u *UCI) Analyse(ctx context.Context, game *core.Game) (Evaluation, error) {
+	start := game.Start()
+	position := "position fen " + start.FEN()
+	if moves := game.Moves(); len(moves) > 0 {
+		replay := start.Game()
+		position += " moves"
+		for _, move := range moves {
+			position += " " + replay.UCI(move)
+			replay.Play(move)
+		}
+	}
+	if err := u.send(position, fmt.Sprintf("go depth %d", u.depth)); err != nil {
+		return Evaluation{}, err
+	}
+
+	var info []string
+	done := ctx.Done()
+	for {
+		select {
+		case <-done:
+			if err := u.send("stop"); err != nil {
+				return Evaluation{}, err
+			}
+			done = nil
+		case line, ok := <-u.lines:
+			if !ok {
+				return Evaluation{}, errEngineExited
+			}
+
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 0:
+			case fields[0] == "info" && slices.Contains(fields, "score") &&
+				!slices.Contains(fields, "lowerbound") && !slices.Contains(fields, "upperbound"):
+				info = fields
+			case fields[0] == "bestmove":
+				if err := ctx.Err(); err != nil {
+					return Evaluation{}, err
+				}
+				return parseInfo(game, info, fields[1:])
+			}
+		}
+	}
+}
+
+// Sends the command and waits for the line starting with the reply
+func (u *UCI) command(cmd, reply string) error {
+	if err := u.send(cmd); err != nil {
+		return err
+	}
+
+	timeout := time.After(uciTimeout)
+	for {
+		select {
+		case line, ok := <-u.lines:
+			if !ok {
+				return errEngineExited
+			}
+			if strings.HasPrefix(line, reply) {
+				return nil
+			}
+		case <-timeout:
+			return fmt.Errorf("analysis: engine didn't answer %q", cmd)
+		}
+	}
+}
+
+func (u *UCI) send(cmds ...string) error {
+	for _, cmd := range cmds {
+		if _, err := io.WriteString(u.stdin, cmd+"\n"); err != nil {
+			return fmt.Errorf("analysis: %w", err)
+		}
+	}
+	return nil
+}
+
+// Returns evaluation by the fields of the last info line and of the bestmove line
+func parseInfo(game *core.Game, info, bestmove []string) (Evaluation, error) {
+	var eval Evaluation
+	var line []string
+	for i := 1; i < len(info); i++ {
+		switch {
+		case info[i] == "score" && i+2 < len(info):
+			n, err := strconv.Atoi(info[i+2])
+			if err != nil {
+				return Evaluation{}, fmt.Errorf("analysis: invalid score: %q", info[i+2])
+			}
+			if info[i+1] == "mate" {
+				eval.Score = Score{Mate: n}
+			} else {
+				eval.Score = Score{Centipawns: n}
+			}
+			i += 2
+		case info[i] == "pv":
+			line = info[i+1:]
+			i = len(info)
+		}
+	}
+
+	if len(line) == 0 && len(bestmove) > 0 && bestmove[0] != "(none)" {
+		line = bestmove[:1]
+	}
+
+	g := game.Clone()
+	for _, s := range line {
+		move, err := g.ParseUCI(s)
+		if err != nil {
+			return Evaluation{}, fmt.Errorf("analysis: %w", err)
+		}
+		eval.Line = append(eval.Line, move)
+		g.Play(move)
+	}
+	return eval, nil
+}
@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+	"github.com/zzvanq/shahio/engine"
+)
+
+// Test binary runs as a UCI engine backed by the built-in engine when the variable is set
+const fakeEngineEnv = "SHAHIO_FAKE_UCI"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeEngineEnv) != "" {
+		fakeEngine()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func fakeEngine() {
+	e := engine.New(engine.Options{})
+	var game core.Game
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name fake")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "position":
+			s, err := core.ParseFEN(strings.Join(fields[2:8], " "))
+			if err != nil {
+				panic(err)
+			}
+			game = s.Game()
+			for _, m := range fields[min(9, len(fields)):] {
+				move, err := game.ParseUCI(m)
+				if err != nil {
+					panic(err)
+				}
+				game.Play(move)
+			}
+		case "go":
+			depth, _ := strconv.Atoi(fields[2])
+			res := e.Search(context.Background(), &game, depth)
+
+			score := fmt.Sprintf("cp %d", res.Score)
+			if mate := engine.MateIn(res.Score); mate != 0 {
+				score = fmt.Sprintf("mate %d", mate)
+			}
+			var pv []string
+			g := game.Clone()
+			for _, move := range res.Line {
+				pv = append(pv, g.UCI(move))
+				g.Play(move)
+			}
+
+			fmt.Printf("info depth %d score %s lowerbound pv %s\n", depth, "cp 9999", pv[0])
+			fmt.Printf("info depth %d score %s nodes %d pv %s\n", depth, score, res.Nodes, strings.Join(pv, " "))
+			fmt.Printf("bestmove %s\n", pv[0])
+		case "quit":
+			return
+		}
+	}
+}
+
+func startEngine(t *testing.T) *UCI {
+	t.Helper()
+
+	t.Setenv(fakeEngineEnv, "1")
+	u, err := StartUCI(2, os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { u.Close() })
+	return u
+}
+
+func TestUCI(t *testing.T) {
+	u := startEngine(t)
+	if err := u.SetOption("Threads", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	game := play(t, blunders...)
+	report, err := Analyse(context.Background(), &game, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReport(t, report)
+
+	// Engine evaluates the same as the built-in one
+	builtin, err := Analyse(context.Background(), &game, NewBuiltin(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report, builtin) {
+		t.Fatalf("Expected the report %+v, got %+v", builtin, report)
+	}
+}
+
+func TestUCI_Exited(t *testing.T) {
+	u := startEngine(t)
+	u.send("quit")
+
+	game := play(t, "e4")
+	if _, err := u.Analyse(context.Background(), &game); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
@@ -0,0 +1,249 @@
+// Package book reads, builds and writes opening books in the Polyglot format
+package book
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Size of an encoded entry in bytes
+const entrySize = 16
+
+// Entry is a book move in a position, see Key for the position key.
+// Move packs the squares and the promotion, castling is a king move onto its rook
+type Entry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// Book holds entries sorted by key
+type Book struct {
+	entries []Entry
+}
+
+// Move is a legal move found in a book
+type Move struct {
+	Move   core.Move
+	Weight uint16
+}
+
+// Polyglot piece kinds, black kinds are even, white ones follow them
+var pieceKinds = map[core.Figure]int{
+	core.Pawn: 0, core.Knight: 2, core.Bishop: 4, core.Rook: 6, core.Queen: 8, core.King: 10,
+}
+
+// Promotion codes of a move
+var promotionCodes = map[core.Figure]uint16{core.Knight: 1, core.Bishop: 2, core.Rook: 3, core.Queen: 4}
+
+func New(entries []Entry) *Book {
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b Entry) int { return cmp.Compare(a.Key, b.Key) })
+	return &Book{entries: entries}
+}
+
+func Open(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(bufio.NewReader(f))
+}
+
+// Read reads a Polyglot .bin book
+func Read(r io.Reader) (*Book, error) {
+	var entries []Entry
+	buf := make([]byte, entrySize)
+	for {
+		if _, err := io.ReadFull(r, buf); errors.Is(err, io.EOF) {
+			break
+		} else if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("book: truncated entry %d", len(entries))
+		} else if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, Entry{
+			Key:    binary.BigEndian.Uint64(buf),
+			Move:   binary.BigEndian.Uint16(buf[8:]),
+			Weight: binary.BigEndian.Uint16(buf[10:]),
+			Learn:  binary.BigEndian.Uint32(buf[12:]),
+		})
+	}
+
+	return New(entries), nil
+}
+
+// WriteTo writes the book in the Polyglot format
+func (b *Book) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, entrySize)
+
+	var n int64
+	for _, entry := range b.entries {
+		binary.BigEndian.PutUint64(buf, entry.Key)
+		binary.BigEndian.PutUint16(buf[8:], entry.Move)
+		binary.BigEndian.PutUint16(buf[10:], entry.Weight)
+		binary.BigEndian.PutUint32(buf[12:], entry.Learn)
+
+		written, err := bw.Write(buf)
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, bw.Flush()
+}
+
+func (b *Book) Entries() []Entry {
+	return slices.Clone(b.entries)
+}
+
+// Returns entries of the position key
+func (b *Book) Lookup(key uint64) []Entry {
+	i, _ := slices.BinarySearchFunc(b.entries, key, func(e Entry, key uint64) int { return cmp.Compare(e.Key, key) })
+	j := i
+	for j < len(b.entries) && b.entries[j].Key == key {
+		j++
+	}
+	return slices.Clone(b.entries[i:j])
+}
+
+// Returns legal book moves of the current position, heaviest first.
+// Entries which don't match a legal move are skipped
+func (b *Book) Moves(game *core.Game) []Move {
+	entries := b.Lookup(Key(game))
+	if len(entries) == 0 {
+		return nil
+	}
+
+	legal := map[uint16]core.Move{}
+	for _, move := range game.LegalMoves() {
+		legal[EncodeMove(move)] = move
+	}
+
+	var res []Move
+	for _, entry := range entries {
+		if move, ok := legal[entry.Move]; ok {
+			res = append(res, Move{move, entry.Weight})
+		}
+	}
+
+	slices.SortStableFunc(res, func(a, b Move) int { return cmp.Compare(b.Weight, a.Weight) })
+	return res
+}
+
+// Returns the heaviest book move
+func (b *Book) BestMove(game *core.Game) (core.Move, bool) {
+	moves := b.Moves(game)
+	if len(moves) == 0 {
+		return core.Move{}, false
+	}
+	return moves[0].Move, true
+}
+
+// Returns a random book move, the chance of a move is proportional to its weight.
+// If all the moves weigh nothing, they are equally likely
+func (b *Book) RandomMove(game *core.Game, rnd *rand.Rand) (core.Move, bool) {
+	moves := b.Moves(game)
+	if len(moves) == 0 {
+		return core.Move{}, false
+	}
+
+	total := 0
+	for _, move := range moves {
+		total += int(move.Weight)
+	}
+	if total == 0 {
+		return moves[rnd.IntN(len(moves))].Move, true
+	}
+
+	n := rnd.IntN(total)
+	for _, move := range moves {
+		if n < int(move.Weight) {
+			return move.Move, true
+		}
+		n -= int(move.Weight)
+	}
+	return moves[len(moves)-1].Move, true
+}
+
+// Key returns Polyglot Zobrist key of the current position of the game
+func Key(game *core.Game) uint64 {
+	return SnapshotKey(game.Snapshot())
+}
+
+func SnapshotKey(s core.Snapshot) uint64 {
+	var key uint64
+	for row := range 8 {
+		for col := range 8 {
+			pos, _ := core.NewPosition(row, col)
+			pic := s.At(pos)
+			if pic == core.Empty {
+				continue
+			}
+
+			kind := pieceKinds[pic.Figure()]
+			if pic.Side() == core.White {
+				kind++
+			}
+			key ^= random64[64*kind+8*row+col]
+		}
+	}
+
+	for i, right := range []core.CastlingRights{
+		core.WhiteKingCastling, core.WhiteQueenCastling, core.BlackKingCastling, core.BlackQueenCastling,
+	} {
+		if s.Castling()&right != 0 {
+			key ^= random64[768+i]
+		}
+	}
+
+	// En passant counts only if a pawn of the side to move stands next to the captured one
+	if pawn, ok := s.EnPassant(); ok {
+		for _, col := range []int{pawn.Col() - 1, pawn.Col() + 1} {
+			if pos, err := core.NewPosition(pawn.Row(), col); err == nil && s.At(pos).Figure() == core.Pawn && s.At(pos).Side() == s.Turn() {
+				key ^= random64[772+pawn.Col()]
+				break
+			}
+		}
+	}
+
+	if s.Turn() == core.White {
+		key ^= random64[780]
+	}
+
+	return key
+}
+
+// EncodeMove packs the move into Polyglot format, castling must hold the king cells
+func EncodeMove(move core.Move) uint16 {
+	source, target := move.Source.Position, move.Target.Position
+	if move.Action == core.KingCastling || move.Action == core.QueenCastling {
+		// King moves onto the rook
+		col := 7
+		if move.Action == core.QueenCastling {
+			col = 0
+		}
+		target, _ = core.NewPosition(source.Row(), col)
+	}
+
+	res := uint16(target.Col()) | uint16(target.Row())<<3 | uint16(source.Col())<<6 | uint16(source.Row())<<9
+	if move.Action == core.Promotion {
+		res |= promotionCodes[move.Target.Figure()] << 12
+	}
+	return res
+}
@@ -0,0 +1,175 @@
+package book
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+func play(t *testing.T, game *core.Game, sans ...string) {
+	t.Helper()
+
+	for _, san := range sans {
+		move, err := game.ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Keys from the Polyglot format description
+func TestKey(t *testing.T) {
+	tests := []struct {
+		moves []string
+		key   uint64
+	}{
+		{nil, 0x463b96181691fc9c},
+		{[]string{"e4"}, 0x823c9b50fd114196},
+		{[]string{"e4", "d5"}, 0x0756b94461c50fb0},
+		{[]string{"e4", "d5", "e5"}, 0x662fafb965db29d4},
+		{[]string{"e4", "d5", "e5", "f5"}, 0x22a48b5a8e47ff78},
+		{[]string{"e4", "d5", "e5", "f5", "Ke2"}, 0x652a607ca3f242c1},
+		{[]string{"e4", "d5", "e5", "f5", "Ke2", "Kf7"}, 0x00fdd303c946bdd9},
+		{[]string{"a4", "b5", "h4", "b4", "c4"}, 0x3c8123ea7b067637},
+		{[]string{"a4", "b5", "h4", "b4", "c4", "bxc3", "Ra3"}, 0x5c3f9b829b279560},
+	}
+
+	for _, tt := range tests {
+		game := core.NewGame()
+		play(t, &game, tt.moves...)
+
+		if key := Key(&game); key != tt.key {
+			t.Fatalf("%v: expected key %016x, got %016x", tt.moves, tt.key, key)
+		}
+	}
+}
+
+func TestEncodeMove(t *testing.T) {
+	game := core.NewGame()
+	play(t, &game, "e4", "e5", "Nf3", "Nc6", "Bc4", "Nf6")
+
+	castling, err := game.ParseSAN("O-O")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// e1h1
+	if encoded := EncodeMove(castling); encoded != 4<<6|7 {
+		t.Fatalf("Unexpected castling %d", encoded)
+	}
+}
+
+const games = `[Result "1-0"]
+1. e4 e5 2. Nf3 1-0
+
+[Result "1-0"]
+1. e4 c5 2. Nf3 1-0
+
+[Result "1/2-1/2"]
+1. d4 d5 1/2-1/2
+
+[Result "0-1"]
+1. c4 e5 0-1
+
+[Result "*"]
+1. b3 *
+`
+
+func TestBuild(t *testing.T) {
+	opts := DefaultBuildOptions
+	opts.MaxPly = 2
+
+	book, err := Build(strings.NewReader(games), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := core.NewGame()
+	moves := book.Moves(&start)
+
+	// c4 lost and b3 has no result
+	sans := []string{}
+	weights := []uint16{}
+	for _, move := range moves {
+		sans = append(sans, start.SAN(move.Move))
+		weights = append(weights, move.Weight)
+	}
+	if strings.Join(sans, " ") != "e4 d4" || weights[0] != 4 || weights[1] != 1 {
+		t.Fatalf("Unexpected moves %v %v", sans, weights)
+	}
+
+	// Second moves of white are beyond the max ply
+	play(t, &start, "e4", "e5")
+	if moves := book.Moves(&start); len(moves) != 0 {
+		t.Fatalf("Unexpected moves %v", moves)
+	}
+
+	opts.MinGames = 2
+	if book, err = Build(strings.NewReader(games), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	start = core.NewGame()
+	if best, ok := book.BestMove(&start); !ok || start.SAN(best) != "e4" || len(book.Moves(&start)) != 1 {
+		t.Fatalf("Unexpected best move %v", best)
+	}
+}
+
+func TestBook_ReadWrite(t *testing.T) {
+	book, err := Build(strings.NewReader(games), DefaultBuildOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := book.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() != len(book.Entries())*entrySize {
+		t.Fatalf("Unexpected size %d", buf.Len())
+	}
+
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(read.Entries(), book.Entries()) {
+		t.Fatalf("Read book doesn't match")
+	}
+
+	if _, err := Read(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+		t.Fatal("Expected error for a truncated book")
+	}
+}
+
+func TestBook_RandomMove(t *testing.T) {
+	game := core.NewGame()
+	e4, _ := game.ParseSAN("e4")
+	d4, _ := game.ParseSAN("d4")
+	key := Key(&game)
+
+	book := New([]Entry{{Key: key, Move: EncodeMove(e4), Weight: 3}, {Key: key, Move: EncodeMove(d4), Weight: 1}})
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	counts := map[core.Move]int{}
+	for range 4000 {
+		move, ok := book.RandomMove(&game, rnd)
+		if !ok {
+			t.Fatal("Expected a book move")
+		}
+		counts[move]++
+	}
+
+	if counts[e4] < 2700 || counts[e4] > 3300 || counts[e4]+counts[d4] != 4000 {
+		t.Fatalf("Unexpected distribution %v", counts)
+	}
+}
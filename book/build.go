@@ -0,0 +1,118 @@
+package book
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/zzvanq/shahio/core"
+	"github.com/zzvanq/shahio/pgn"
+)
+
+// BuildOptions tune which moves get into a built book
+type BuildOptions struct {
+	// Only the first plies of every game are taken
+	MaxPly int
+	// Moves played in fewer games are dropped
+	MinGames int
+	// Points the moving side scores for a game result,
+	// moves that score nothing are dropped
+	Win, Draw, Loss int
+}
+
+// Polyglot defaults, moves of lost games don't add weight
+var DefaultBuildOptions = BuildOptions{MaxPly: 24, MinGames: 1, Win: 2, Draw: 1, Loss: 0}
+
+type moveStats struct {
+	games  int
+	points int
+}
+
+// Build builds a book from a PGN collection.
+// Games without a result are skipped, weights are scaled down to fit the format
+func Build(r io.Reader, opts BuildOptions) (*Book, error) {
+	stats := map[uint64]map[uint16]*moveStats{}
+
+	games := pgn.NewReader(r)
+	for {
+		game, err := games.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addGame(stats, game, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []Entry
+	for key, moves := range stats {
+		var selected []Entry
+		maxPoints := 0
+		for move, s := range moves {
+			if s.games < opts.MinGames || s.points <= 0 {
+				continue
+			}
+			selected = append(selected, Entry{Key: key, Move: move})
+			maxPoints = max(maxPoints, s.points)
+		}
+
+		for i := range selected {
+			points := moves[selected[i].Move].points
+			if maxPoints > math.MaxUint16 {
+				points = max(1, points*math.MaxUint16/maxPoints)
+			}
+			selected[i].Weight = uint16(points)
+		}
+		entries = append(entries, selected...)
+	}
+
+	// Map order is random, heavier moves go first within a key
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(b.Weight, a.Weight), cmp.Compare(a.Move, b.Move))
+	})
+	return &Book{entries: entries}, nil
+}
+
+func addGame(stats map[uint64]map[uint16]*moveStats, game *pgn.Game, opts BuildOptions) error {
+	points := map[string]map[core.Side]int{
+		"1-0":     {core.White: opts.Win, core.Black: opts.Loss},
+		"0-1":     {core.White: opts.Loss, core.Black: opts.Win},
+		"1/2-1/2": {core.White: opts.Draw, core.Black: opts.Draw},
+	}[game.Result]
+	if points == nil {
+		return nil
+	}
+
+	g := game.Start.Game()
+	for ply, move := range game.Moves {
+		if ply >= opts.MaxPly {
+			break
+		}
+
+		key := Key(&g)
+		if stats[key] == nil {
+			stats[key] = map[uint16]*moveStats{}
+		}
+
+		encoded := EncodeMove(move)
+		s := stats[key][encoded]
+		if s == nil {
+			s = &moveStats{}
+			stats[key][encoded] = s
+		}
+		s.games++
+		s.points += points[g.Turn()]
+
+		if err := g.Play(move); err != nil {
+			return fmt.Errorf("book: %s game: %w", game.Tags["Event"], err)
+		}
+	}
+
+	return nil
+}
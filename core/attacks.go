@@ -0,0 +1,70 @@
+package core
+
+// Returns every piece of the side attacking the position, the pieces are in the board order.
+// Pieces behind other pieces don't attack, en passant captures are not attacks
+func (g *Game) Attackers(pos Position, side Side) []Position {
+	if !isValidPosition(pos.col, pos.row) {
+		return nil
+	}
+
+	var res []Position
+	for row := range g.Board {
+		for col, pic := range g.Board[row] {
+			if pic.side == side && g.attacks(Position{row: row, col: col}, pos) {
+				res = append(res, Position{row: row, col: col})
+			}
+		}
+	}
+	return res
+}
+
+// Checks the piece on the source attacks the target
+func (g *Game) attacks(source, target Position) bool {
+	pic := g.Board[source.row][source.col]
+	dc, dr := target.col-source.col, target.row-source.row
+	if dc == 0 && dr == 0 {
+		return false
+	}
+
+	switch pic.fig {
+	case Pawn:
+		return dr == AdvDirs[pic.side] && abs(dc) == 1
+	case Knight:
+		return abs(dc)*abs(dr) == 2
+	case King:
+		return abs(dc) <= 1 && abs(dr) <= 1
+	case Rook:
+		if dc != 0 && dr != 0 {
+			return false
+		}
+	case Bishop:
+		if abs(dc) != abs(dr) {
+			return false
+		}
+	case Queen:
+		if dc != 0 && dr != 0 && abs(dc) != abs(dr) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	// Cells between the slider and the target are empty
+	stepCol, stepRow := sign(dc), sign(dr)
+	for col, row := source.col+stepCol, source.row+stepRow; col != target.col || row != target.row; col, row = col+stepCol, row+stepRow {
+		if g.Board[row][col] != Empty {
+			return false
+		}
+	}
+	return true
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGame_Attackers(t *testing.T) {
+	s, err := ParseFEN("3r2k1/1b3ppp/8/3N4/2P1p3/1Q3B2/8/3R2K1 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := s.Game()
+
+	tests := []struct {
+		square   string
+		side     Side
+		expected string
+	}{
+		{"d5", Black, "[b7 d8]"},
+		// Queen and bishop behind the pawns don't attack
+		{"d5", White, "[d1 c4]"},
+		{"e4", White, "[f3]"},
+		{"d3", Black, "[e4]"},
+		{"f8", Black, "[d8 g8]"},
+		{"d3", White, "[d1 b3]"},
+		{"a8", White, "[]"},
+	}
+
+	for _, test := range tests {
+		pos, err := ParseSquare(test.square)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(game.Attackers(pos, test.side)); got != test.expected {
+			t.Fatalf("%s by %c: expected %s, got %s", test.square, test.side, test.expected, got)
+		}
+	}
+}
@@ -48,11 +48,11 @@ func TestProcessCastling_PiecesBetween(t *testing.T) {
 
 func TestProcessCastling_KingNotInPosition(t *testing.T) {
 	game := NewGame()
+	// King went forth and back
+	playMoves(t, &game, "e2e4", "e7e5", "e1e2", "b8c6", "e2e1", "c6b8")
 
 	move := Move{Action: KingCastling}
 
-	kingMove := Move{Source: Cell{Piece: Piece{'K', Black}}}
-	game.Moves = append(game.Moves, kingMove)
 	if err := game.processCastling(move); err.Error() != "king not in position" {
 		t.Fatal(err)
 	}
@@ -60,15 +60,11 @@ func TestProcessCastling_KingNotInPosition(t *testing.T) {
 
 func TestProcessCastling_RookNotInPositionWasMoved(t *testing.T) {
 	game := NewGame()
+	// Rook went forth and back
+	playMoves(t, &game, "h2h4", "h7h5", "g1f3", "h8h6", "f3g1", "h6h8", "b1c3")
 
 	move := Move{Action: KingCastling}
 
-	game.Moves = []Move{
-		{
-			Source: Cell{Piece: Piece{'R', Black}, Position: Position{row: 7, col: 7}},
-			Action: Movement,
-		},
-	}
 	if err := game.processCastling(move); err.Error() != "rook not in position" {
 		t.Fatal(err)
 	}
@@ -76,15 +72,11 @@ func TestProcessCastling_RookNotInPositionWasMoved(t *testing.T) {
 
 func TestProcessCastling_RookNotInPositionWasCaptured(t *testing.T) {
 	game := NewGame()
+	// Pawn captures the rook and promotes
+	playMoves(t, &game, "g2g4", "h7h5", "g4h5", "g8f6", "h5h6", "b8c6", "h6g7", "c6b8", "g7h8q")
 
 	move := Move{Action: KingCastling}
 
-	game.Moves = []Move{
-		{
-			Target: Cell{Piece: Piece{'R', Black}, Position: Position{row: 7, col: 7}},
-			Action: Capture,
-		},
-	}
 	if err := game.processCastling(move); err.Error() != "rook not in position" {
 		t.Fatal(err)
 	}
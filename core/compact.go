@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// MoveEncoder writes moves of a game as their indexes in the list of legal moves,
+// a byte per move, as there are never more than 256 legal moves
+type MoveEncoder struct {
+	w    io.Writer
+	game Game
+}
+
+// Returns an encoder of the moves played from the position
+func NewMoveEncoder(w io.Writer, start Snapshot) *MoveEncoder {
+	return &MoveEncoder{w: w, game: start.Game()}
+}
+
+// Encode writes the index of the move and plays it, the pieces and the action are taken from the board.
+// The move isn't played if it's illegal or its index isn't written
+func (e *MoveEncoder) Encode(move Move) error {
+	legal := e.game.LegalMoves()
+	index := slices.Index(legal, e.game.CompleteMove(move))
+	if index < 0 {
+		// Error of the rules tells the reason
+		g := e.game.Clone()
+		if err := g.processMove(move); err != nil {
+			return err
+		}
+		return newIllegalMoveError(ReasonInvalidMove, move)
+	}
+
+	if _, err := e.w.Write([]byte{byte(index)}); err != nil {
+		return err
+	}
+	return e.game.processMove(legal[index])
+}
+
+// MoveDecoder reads moves written by MoveEncoder replaying them from the start position
+type MoveDecoder struct {
+	r    io.ByteReader
+	game Game
+}
+
+// Returns a decoder of the moves played from the position.
+// Readers without ReadByte are buffered, so they may be read past the moves
+func NewMoveDecoder(r io.Reader, start Snapshot) *MoveDecoder {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &MoveDecoder{r: br, game: start.Game()}
+}
+
+// Decode returns the next move, io.EOF if there are no more moves
+func (d *MoveDecoder) Decode() (Move, error) {
+	index, err := d.r.ReadByte()
+	if err != nil {
+		return Move{}, err
+	}
+
+	legal := d.game.LegalMoves()
+	if int(index) >= len(legal) {
+		return Move{}, fmt.Errorf("invalid move index %d of %d legal moves", index, len(legal))
+	}
+
+	move := legal[index]
+	if err := d.game.processMove(move); err != nil {
+		return Move{}, err
+	}
+	return move, nil
+}
+
+// Returns the game replayed by the decoder so far
+func (d *MoveDecoder) Game() *Game {
+	return &d.game
+}
+
+// Returns the moves of the game in the compact encoding, see MoveEncoder
+func (g *Game) CompactMoves() []byte {
+	var buf bytes.Buffer
+	e := NewMoveEncoder(&buf, g.start)
+	for _, move := range g.moves {
+		// Played moves are legal
+		e.Encode(move)
+	}
+	return buf.Bytes()
+}
+
+// Returns a game replayed from the moves in the compact encoding
+func NewGameFromCompact(start Snapshot, data []byte) (Game, error) {
+	d := NewMoveDecoder(bytes.NewReader(data), start)
+	for {
+		if _, err := d.Decode(); err == io.EOF {
+			return d.game, nil
+		} else if err != nil {
+			return Game{}, err
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompactMoves(t *testing.T) {
+	game := newSerializedGame(t)
+
+	data := game.CompactMoves()
+	if len(data) != len(game.moves) {
+		t.Fatalf("Expected a byte per move, got %d bytes for %d moves", len(data), len(game.moves))
+	}
+
+	decoded, err := NewGameFromCompact(game.start, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Snapshot() != game.Snapshot() || len(decoded.moves) != len(game.moves) {
+		t.Fatalf("Decoded game doesn't match")
+	}
+
+	// Moves of a decoded game are the legal ones, so they are encoded the same way
+	if !bytes.Equal(decoded.CompactMoves(), data) {
+		t.Fatalf("Encoding isn't stable")
+	}
+
+	if _, err := NewGameFromCompact(game.start, append(data[:2:2], 200)); err == nil ||
+		!strings.HasPrefix(err.Error(), "invalid move index 200") {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestMoveEncoder_Stream(t *testing.T) {
+	start, err := ParseFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	e := NewMoveEncoder(&buf, start)
+	moves := []Move{{Action: QueenCastling}, {Action: KingCastling}}
+	for _, move := range moves {
+		if err := e.Encode(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The king has castled, it can't do it twice
+	if err := e.Encode(Move{Action: KingCastling}); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if buf.Len() != 2 {
+		t.Fatalf("Illegal move was written")
+	}
+
+	// Plain readers are buffered
+	d := NewMoveDecoder(io.MultiReader(&buf), start)
+	for _, move := range moves {
+		decoded, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Action != move.Action {
+			t.Fatalf("Expected %s, got %s", move.Action, decoded.Action)
+		}
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Fatalf("Expected EOF, got %v", err)
+	}
+	if d.Game().Snapshot().Castling() != 0 {
+		t.Fatalf("Unexpected castling rights %d", d.Game().Snapshot().Castling())
+	}
+}
+
+// Writer failing the first writes
+type failingWriter struct {
+	bytes.Buffer
+	failures int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.failures > 0 {
+		w.failures--
+		return 0, errors.New("write failed")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestMoveEncoder_WriteError(t *testing.T) {
+	w := &failingWriter{failures: 1}
+	e := NewMoveEncoder(w, InitialSnapshot())
+
+	e4 := Move{Source: Cell{Position: Position{row: 1, col: 4}}, Target: Cell{Position: Position{row: 3, col: 4}}, Action: Movement}
+	if err := e.Encode(e4); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	// The move which wasn't written isn't played, so it's encoded again
+	if err := e.Encode(e4); err != nil {
+		t.Fatal(err)
+	}
+
+	game, err := NewGameFromCompact(InitialSnapshot(), w.Bytes())
+	if err != nil || len(game.moves) != 1 || game.Snapshot() != e.game.Snapshot() {
+		t.Fatalf("Decoded game doesn't match: %v", err)
+	}
+}
@@ -37,15 +37,25 @@ type (
 	Board  [][]Piece
 	Game   struct {
 		Board      Board
-		Moves      []Move
+		moves      []Move
 		blackKing  Position
 		whiteKing  Position
 		blackCells int
 		whiteCells int
 		outcome    Outcome
+		// Position the game was started from
+		start Snapshot
 	}
 )
 
+const (
+	Pawn   = Figure('P')
+	Knight = Figure('N')
+	Bishop = Figure('B')
+	Rook   = Figure('R')
+	Queen  = Figure('Q')
+	King   = Figure('K')
+)
 const (
 	White         = Side('w')
 	Black         = Side('b')
@@ -87,60 +97,160 @@ var (
 		{-1, -1},
 	}
 	PicDirs = map[Figure][][2]int{
-		'B': DiagDirs, 'R': LineDirs,
-		'Q': append(DiagDirs, LineDirs...), 'N': KnightDirs,
-		'K': KingDirs,
+		Bishop: DiagDirs, Rook: LineDirs,
+		Queen: append(DiagDirs, LineDirs...), Knight: KnightDirs,
+		King: KingDirs,
 	}
 	PawnAtkDirs = map[Side][][2]int{Black: {{-1, -1}, {1, -1}}, White: {{-1, 1}, {1, 1}}}
 	AdvDirs     = map[Side]int{Black: -1, White: 1}
-	Empty       Piece
+	// Rows where pawns start and get promoted
+	PawnRows         = map[Side]int{Black: 6, White: 1}
+	PromotionRows    = map[Side]int{Black: 0, White: 7}
+	PromotionFigures = []Figure{Queen, Rook, Bishop, Knight}
+	Empty            Piece
 )
 
 func NewGame() Game {
-	return Game{
+	g := Game{
 		Board: [][]Piece{
-			{{'R', 'w'}, {'N', 'w'}, {'B', 'w'}, {'Q', 'w'}, {'K', 'w'}, {'B', 'w'}, {'N', 'w'}, {'R', 'w'}},
-			{{'P', 'w'}, {'P', 'w'}, {'P', 'w'}, {'P', 'w'}, {'P', 'w'}, {'P', 'w'}, {'P', 'w'}, {'P', 'w'}},
+			{{Rook, White}, {Knight, White}, {Bishop, White}, {Queen, White}, {King, White}, {Bishop, White}, {Knight, White}, {Rook, White}},
+			{{Pawn, White}, {Pawn, White}, {Pawn, White}, {Pawn, White}, {Pawn, White}, {Pawn, White}, {Pawn, White}, {Pawn, White}},
 			{Empty, Empty, Empty, Empty, Empty, Empty, Empty, Empty},
 			{Empty, Empty, Empty, Empty, Empty, Empty, Empty, Empty},
 			{Empty, Empty, Empty, Empty, Empty, Empty, Empty, Empty},
 			{Empty, Empty, Empty, Empty, Empty, Empty, Empty, Empty},
-			{{'P', 'b'}, {'P', 'b'}, {'P', 'b'}, {'P', 'b'}, {'P', 'b'}, {'P', 'b'}, {'P', 'b'}, {'P', 'b'}},
-			{{'R', 'b'}, {'N', 'b'}, {'B', 'b'}, {'Q', 'b'}, {'K', 'b'}, {'B', 'b'}, {'N', 'b'}, {'R', 'b'}},
+			{{Pawn, Black}, {Pawn, Black}, {Pawn, Black}, {Pawn, Black}, {Pawn, Black}, {Pawn, Black}, {Pawn, Black}, {Pawn, Black}},
+			{{Rook, Black}, {Knight, Black}, {Bishop, Black}, {Queen, Black}, {King, Black}, {Bishop, Black}, {Knight, Black}, {Rook, Black}},
 		},
-		Moves:      []Move{},
+		moves:      []Move{},
 		whiteKing:  Position{row: 0, col: 4},
 		blackKing:  Position{row: 7, col: 4},
 		blackCells: 16,
 		whiteCells: 16,
 		outcome:    NoOutcome,
 	}
+	g.start = Snapshot{board: g.Board.toArray(), turn: White, castling: AllCastling}
+
+	return g
+}
+
+// Returns the initial position of a game
+func InitialSnapshot() Snapshot {
+	g := NewGame()
+	return g.start
+}
+
+// NewGameFromMoves starts a game from the position and replays the moves by the rules.
+// Returns *ReplayError for the first illegal move
+func NewGameFromMoves(start Snapshot, moves []Move) (Game, error) {
+	game := start.Game()
+	for i, move := range moves {
+		if err := game.processMove(move); err != nil {
+			return Game{}, &ReplayError{Index: i, Err: err}
+		}
+	}
+	return game, nil
+}
+
+// Returns a copy of the played moves, history grows only by playing legal moves
+func (g *Game) Moves() []Move {
+	return slices.Clone(g.moves)
+}
+
+// Returns a copy of the game which shares nothing with the original
+func (g *Game) Clone() Game {
+	return g.clone()
+}
+
+// Play validates the move and plays it, an illegal move leaves the game untouched
+func (g *Game) Play(move Move) error {
+	return g.processMove(move)
+}
+
+// Returns all legal moves of the side to move
+func (g *Game) LegalMoves() []Move {
+	res := []Move{}
+	for _, move := range g.getCandidateMoves() {
+		if g.isLegal(move) {
+			res = append(res, move)
+		}
+	}
+	return res
+}
+
+func (g *Game) Outcome() Outcome {
+	return g.outcome
+}
+
+func (g *Game) Turn() Side {
+	return g.whoseTurn()
+}
+
+// Returns number of plies since the last capture or pawn move,
+// the clock of the start position included
+func (g *Game) HalfmoveClock() int {
+	clock := g.start.halfmove
+	for _, move := range g.moves {
+		clock++
+		if move.Source.fig == Pawn || move.Action == Capture || move.Action == Enpassant {
+			clock = 0
+		}
+	}
+	return clock
+}
+
+// Checks if the king of the side to move is attacked
+func (g *Game) InCheck() bool {
+	kingAttackers, _ := g.getAttackingCells(g.sideKing(g.whoseTurn()), getOpponent(g.whoseTurn()))
+	return len(kingAttackers) > 0
 }
 
 func (g *Game) processMove(move Move) error {
 	if g.outcome != NoOutcome {
-		return fmt.Errorf("game has ended")
+		return newIllegalMoveError(ReasonGameEnded, move)
 	}
 
+	// Apply the move to a copy, so an illegal move leaves the game untouched
+	next := g.clone()
+	if err := next.applyMove(move); err != nil {
+		return err
+	}
+
+	next.moves = append(next.moves, move)
+	next.outcome = next.checkGameStatus()
+
+	g.copyFrom(next)
+
+	return nil
+}
+
+// Applies the move to the board without recording it
+func (g *Game) applyMove(move Move) error {
 	actionProcessor := g.getProcessor(move)
 	if actionProcessor == nil {
-		return fmt.Errorf("invalid move action: %v", move.Action)
+		return newIllegalMoveError(ReasonInvalidAction, move)
 	}
 
-	err := actionProcessor(move)
-	if err != nil {
+	if !isCastling(move) && (!isValidPosition(move.Source.col, move.Source.row) ||
+		!isValidPosition(move.Target.col, move.Target.row)) {
+		return newIllegalMoveError(ReasonInvalidMove, move)
+	}
+
+	if err := g.checkClaims(move); err != nil {
+		return err
+	}
+
+	if err := actionProcessor(move); err != nil {
 		return err
 	}
 
 	kingAttackers, _ := g.getAttackingCells(g.sideKing(g.whoseTurn()), getOpponent(g.whoseTurn()))
 	if len(kingAttackers) > 0 {
-		return fmt.Errorf("king is in check")
+		err := newIllegalMoveError(ReasonKingInCheck, move)
+		err.Attackers = g.getCells(kingAttackers)
+		return err
 	}
 
-	g.outcome = g.checkGameStatus()
-
-	g.Moves = append(g.Moves, move)
-
 	return nil
 }
 
@@ -165,21 +275,37 @@ func (g *Game) processCastling(move Move) error {
 	king := Position{kingRows[g.whoseTurn()], 4}
 	rook := map[Action]Position{KingCastling: {col: 7, row: king.row}, QueenCastling: {col: 0, row: king.row}}[move.Action]
 
-	for _, prevm := range g.Moves {
+	if g.start.castling&castlingRight(g.whoseTurn(), move.Action) == 0 {
+		return newIllegalMoveError(ReasonNoCastlingRights, move)
+	}
+
+	for i, prevm := range g.moves {
 		// Check that king didn't move
-		if prevm.Source.fig == 'K' && prevm.Source.side == g.whoseTurn() {
-			return fmt.Errorf("king not in position")
+		if (prevm.Source.fig == King && prevm.Source.side == g.whoseTurn()) ||
+			(isCastling(prevm) && g.moveSide(i) == g.whoseTurn()) {
+			err := newIllegalMoveError(ReasonKingNotInPosition, move)
+			err.Forfeit, err.ForfeitIndex = &prevm, i
+			return err
 		}
 
 		// Check that rook didn't move
-		wasMoved := prevm.Source.Piece == Piece{'R', g.whoseTurn()} && prevm.Source.Position == rook
-		wasCaptured := prevm.Action == Capture &&
-			prevm.Target.Piece == Piece{'R', g.whoseTurn()} && prevm.Target.Position == rook
+		wasMoved := prevm.Source.Piece == Piece{Rook, g.whoseTurn()} && prevm.Source.Position == rook
+		wasCaptured := !isCastling(prevm) && g.moveSide(i) != g.whoseTurn() && prevm.Target.Position == rook
 		if wasMoved || wasCaptured {
-			return fmt.Errorf("rook not in position")
+			err := newIllegalMoveError(ReasonRookNotInPosition, move)
+			err.Forfeit, err.ForfeitIndex = &prevm, i
+			return err
 		}
 	}
 
+	if g.Board[king.row][king.col] != (Piece{King, g.whoseTurn()}) {
+		return newIllegalMoveError(ReasonKingNotInPosition, move)
+	}
+
+	if g.Board[rook.row][rook.col] != (Piece{Rook, g.whoseTurn()}) {
+		return newIllegalMoveError(ReasonRookNotInPosition, move)
+	}
+
 	// Check if there are pieces between king and rook
 	col := king.col
 	rookDir := map[Action]int{KingCastling: 1, QueenCastling: -1}[move.Action]
@@ -190,27 +316,33 @@ func (g *Game) processCastling(move Move) error {
 			break
 		}
 
-		if g.Board[king.row][col] != Empty {
-			return fmt.Errorf("pieces between king and rook")
+		if pic := g.Board[king.row][col]; pic != Empty {
+			err := newIllegalMoveError(ReasonPiecesBetween, move)
+			err.Blocker = &Cell{pic, Position{row: king.row, col: col}}
+			return err
 		}
 	}
 
 	// Check if crossover squares are attacked
 	crossoverAttackers, _ := g.getAttackingCells(Position{row: king.row, col: king.col + rookDir}, getOpponent(g.whoseTurn()))
 	if len(crossoverAttackers) > 0 {
-		return fmt.Errorf("crossover cell attacked")
+		err := newIllegalMoveError(ReasonCrossoverAttacked, move)
+		err.Attackers = g.getCells(crossoverAttackers)
+		return err
 	}
 
 	// Check if king is in check
 	kingAttackers, _ := g.getAttackingCells(Position{row: king.row, col: king.col}, getOpponent(g.whoseTurn()))
 
 	if len(kingAttackers) > 0 {
-		return fmt.Errorf("king is in check")
+		err := newIllegalMoveError(ReasonKingInCheck, move)
+		err.Attackers = g.getCells(kingAttackers)
+		return err
 	}
 
 	// Set king and rook new positions
-	g.Board[king.row][king.col+rookDir] = Piece{'R', g.whoseTurn()}
-	g.Board[king.row][king.col+2*rookDir] = Piece{'K', g.whoseTurn()}
+	g.Board[king.row][king.col+rookDir] = Piece{Rook, g.whoseTurn()}
+	g.Board[king.row][king.col+2*rookDir] = Piece{King, g.whoseTurn()}
 
 	// Clear old positions
 	g.Board[king.row][rook.col] = Empty
@@ -229,12 +361,29 @@ func (g *Game) processMovement(move Move) error {
 	target := g.Board[move.Target.row][move.Target.col]
 
 	if target.side == g.whoseTurn() {
-		return fmt.Errorf("cell is occupied")
+		err := newIllegalMoveError(ReasonCellOccupied, move)
+		err.Blocker = &Cell{target, move.Target.Position}
+		return err
+	}
+
+	if err := checkMoveDir(move); err != nil {
+		return err
+	}
+
+	// Pawns move 2 cells only from the start row and get promoted on the last row
+	if move.Source.fig == Pawn {
+		if move.Target.row-move.Source.row == 2*AdvDirs[move.Source.side] && move.Source.row != PawnRows[move.Source.side] {
+			return newIllegalMoveError(ReasonInvalidMove, move)
+		}
+
+		if move.Target.row == PromotionRows[move.Source.side] && move.Action != Promotion {
+			return newIllegalMoveError(ReasonInvalidMove, move)
+		}
 	}
 
 	// Check if line is blocked
 	switch move.Source.fig {
-	case 'Q', 'B', 'R', 'P':
+	case Queen, Bishop, Rook, Pawn:
 		stepRow, stepCol := 0, 0
 		if move.Target.row > move.Source.row {
 			stepRow = 1
@@ -248,24 +397,24 @@ func (g *Game) processMovement(move Move) error {
 			stepCol = -1
 		}
 
-		for col, row := move.Source.col, move.Source.row; ; {
-			col, row = col+stepCol, row+stepRow
-			if !isValidPosition(col, row) {
-				break
-			}
-
-			if g.Board[row][col] != Empty {
-				return fmt.Errorf("move is blocked")
-			}
-
+		for col, row := move.Source.col+stepCol, move.Source.row+stepRow; isValidPosition(col, row); col, row = col+stepCol, row+stepRow {
 			// Target position reached
 			if move.Target.row == row && move.Target.col == col {
 				break
 			}
+
+			if pic := g.Board[row][col]; pic != Empty {
+				err := newIllegalMoveError(ReasonMoveBlocked, move)
+				err.Blocker = &Cell{pic, Position{row: row, col: col}}
+				return err
+			}
 		}
 	}
 
-	if err := checkMoveDir(move); err != nil {
+	// Only captures may end on an occupied cell
+	if target != Empty && move.Action != Capture {
+		err := newIllegalMoveError(ReasonMoveBlocked, move)
+		err.Blocker = &Cell{target, move.Target.Position}
 		return err
 	}
 
@@ -277,14 +426,16 @@ func (g *Game) processMovement(move Move) error {
 func (g *Game) processCapture(move Move) error {
 	target := g.Board[move.Target.row][move.Target.col]
 	if target == Empty {
-		return fmt.Errorf("cell is empty")
+		return newIllegalMoveError(ReasonCellEmpty, move)
 	}
 
 	if target.side == g.whoseTurn() {
-		return fmt.Errorf("cell is occupied")
+		err := newIllegalMoveError(ReasonCellOccupied, move)
+		err.Blocker = &Cell{target, move.Target.Position}
+		return err
 	}
 
-	if move.Source.fig != 'P' {
+	if move.Source.fig != Pawn {
 		return g.processMovement(move)
 	}
 
@@ -292,41 +443,49 @@ func (g *Game) processCapture(move Move) error {
 		return err
 	}
 
+	if move.Target.row == PromotionRows[move.Source.side] && move.Action != Promotion {
+		return newIllegalMoveError(ReasonInvalidMove, move)
+	}
+
 	g.moveCell(move.Source, move.Target)
 
 	return nil
 }
 
 func (g *Game) processEnpassant(move Move) error {
-	if g.Board[move.Target.row][move.Target.col] != Empty {
-		return fmt.Errorf("cell is not empty")
+	if target := g.Board[move.Target.row][move.Target.col]; target != Empty {
+		err := newIllegalMoveError(ReasonCellNotEmpty, move)
+		err.Blocker = &Cell{target, move.Target.Position}
+		return err
 	}
 
-	if move.Source.fig != 'P' {
-		return fmt.Errorf("invalid move")
+	if move.Source.fig != Pawn {
+		return newIllegalMoveError(ReasonInvalidMove, move)
 	}
 
-	col, row := move.Source.col+(move.Target.col-move.Source.col), move.Source.row
-	epCapPic := g.Board[row][col]
-	if len(g.Moves) > 0 && epCapPic == (Piece{'P', getOpponent(g.whoseTurn())}) {
-		preEpMove := Move{
-			Source: Cell{Piece{'P', epCapPic.side}, Position{row - (AdvDirs[epCapPic.side] * 2), col}},
-			Target: Cell{Piece{'P', epCapPic.side}, Position{row, col}},
-			Action: Movement,
-		}
-		if prevMove := g.Moves[len(g.Moves)-1]; prevMove == preEpMove {
-			g.moveCell(move.Source, move.Target)
-			g.Board[row][col] = Empty
-			return nil
-		}
+	atkDir := [2]int{move.Target.col - move.Source.col, move.Target.row - move.Source.row}
+	if !slices.Contains(PawnAtkDirs[move.Source.side], atkDir) {
+		return newIllegalMoveError(ReasonInvalidMove, move)
 	}
 
-	return fmt.Errorf("invalid move")
+	// Captured pawn must have moved 2 cells by the previous move
+	epPawn, found := g.lastDoubleStep()
+	if !found || epPawn != (Position{row: move.Source.row, col: move.Target.col}) ||
+		g.Board[epPawn.row][epPawn.col] != (Piece{Pawn, getOpponent(move.Source.side)}) {
+		return newIllegalMoveError(ReasonInvalidMove, move)
+	}
+
+	g.moveCell(move.Source, move.Target)
+	g.removeCell(epPawn)
+
+	return nil
 }
 
 func (g *Game) processPromotion(move Move) error {
-	if move.Source.fig != 'P' || move.Target.side != move.Source.side {
-		return fmt.Errorf("invalid move")
+	if move.Source.fig != Pawn || move.Target.side != move.Source.side ||
+		!slices.Contains(PromotionFigures, move.Target.fig) ||
+		move.Target.row != PromotionRows[move.Source.side] {
+		return newIllegalMoveError(ReasonInvalidMove, move)
 	}
 
 	var err error
@@ -345,204 +504,128 @@ func (g *Game) processPromotion(move Move) error {
 }
 
 func (g *Game) checkGameStatus() Outcome {
-	if g.blackCells == 1 || g.whiteCells == 1 {
+	// Only kings are left
+	if g.blackCells == 1 && g.whiteCells == 1 {
 		return Stalemate
 	}
 
-	side := getOpponent(g.whoseTurn())
-	king := g.sideKing(side)
-
-	// If king is not attacked, check for stalemate
-	atkCells, isBlockable := g.getAttackingCells(king, side)
-	if len(atkCells) == 0 && g.checkStalemate() {
-		return Stalemate
-	}
-
-	// Check if checked king can run away
-	if g.canKingMove(side) {
-		return Checkmate
-	}
-
-	// Mate if attacked by 2 cells and can't run away
-	if len(atkCells) == 2 {
-		return Checkmate
-	}
-
-	// Check if the attacking cell can be captured
-	atkCell := atkCells[0]
-	captCells, _ := g.getAttackingCells(atkCell, side)
-	if len(captCells) > 0 {
+	if g.hasLegalMove() {
 		return NoOutcome
 	}
 
-	// Check if a blockable line of attack can be blocked
-	if isBlockable {
-		atkDir := func(a, b int) int {
-			if a > b {
-				return -1
-			} else if a < b {
-				return 1
-			}
-			return 0
-		}
-
-		dirCol := atkDir(king.col, atkCell.col)
-		dirRow := atkDir(king.row, atkCell.row)
-		for col, row := king.col+dirCol, king.row+dirRow; isValidPosition(col, row); {
-			blockerCell, found := g.getSourceCell(Position{row: row, col: col}, side)
-			if found && blockerCell != king {
-				return NoOutcome
-			}
-			col, row = col+dirCol, row+dirRow
-		}
+	if g.InCheck() {
+		return Checkmate
 	}
 
-	return Checkmate
+	return Stalemate
 }
 
-func (g *Game) checkStalemate() bool {
-	opponent := getOpponent(g.whoseTurn())
-	if g.canKingMove(opponent) {
-		return false
-	}
-
-	starts := map[Side]int{Black: 7, White: 0}
-	dir := map[Side]int{Black: -1, White: 1}[opponent]
-	for row := starts[opponent]; row != starts[g.whoseTurn()]; row += dir {
-		for col := range g.Board[row] {
-			if pic := g.Board[row][col]; pic != Empty &&
-				pic.side == opponent && g.canMove(Position{row: row, col: col}) {
-				return false
-			}
-		}
-	}
-
-	return true
+func (g *Game) hasLegalMove() bool {
+	return slices.ContainsFunc(g.getCandidateMoves(), g.isLegal)
 }
 
-func (g *Game) canMove(cell Position) bool {
-	pic := g.Board[cell.row][cell.col]
-	king := g.sideKing(pic.side)
+// Checks the move on a copy of the game
+func (g *Game) isLegal(move Move) bool {
+	next := g.clone()
+	return next.applyMove(move) == nil
+}
 
-	switch pic.fig {
-	case 'P':
-		// single move
-		if col, row := cell.col, cell.row+AdvDirs[g.whoseTurn()]; isValidPosition(col, row) && g.Board[row][col] == Empty &&
-			!g.moveAndCheck(Move{Source: Cell{pic, cell}, Target: Cell{Empty, Position{row, col}}, Action: Movement}, king) {
-			return true
-		}
+// Returns moves of the side to move which follow the movement rules,
+// they still may leave the king in check
+func (g *Game) getCandidateMoves() []Move {
+	side := g.whoseTurn()
+	res := make([]Move, 0, 64)
 
-		// attack move
-		for _, dir := range PawnAtkDirs[g.whoseTurn()] {
-			if col, row := cell.col+dir[0], cell.row+dir[1]; isValidPosition(col, row) &&
-				(g.Board[row][col] != Empty && g.Board[row][col].side == getOpponent(pic.side)) &&
-				!g.moveAndCheck(Move{
-					Source: Cell{pic, cell},
-					Target: Cell{g.Board[row][col], Position{row: row, col: col}},
-					Action: Capture,
-				}, king) {
-				return true
+	for row := range g.Board {
+		for col, pic := range g.Board[row] {
+			if pic == Empty || pic.side != side {
+				continue
 			}
-		}
 
-		// en passant move
-		if len(g.Moves) > 0 {
-			prevMove := g.Moves[len(g.Moves)-1]
-			for _, dir := range PawnAtkDirs[g.whoseTurn()] {
-				preEpMove := Move{
-					Source: Cell{Piece{'P', getOpponent(pic.side)}, Position{row: cell.row, col: cell.col + dir[0]}},
-					Target: Cell{Piece{'P', getOpponent(pic.side)}, Position{row: cell.row + (AdvDirs[g.whoseTurn()] * 2), col: cell.col + dir[0]}},
-					Action: Movement,
+			source := Cell{pic, Position{row: row, col: col}}
+			switch pic.fig {
+			case Pawn:
+				res = g.appendPawnMoves(res, source)
+			case Knight, King:
+				for _, dir := range PicDirs[pic.fig] {
+					if col, row := col+dir[0], row+dir[1]; isValidPosition(col, row) {
+						res = g.appendStep(res, source, Position{row: row, col: col})
+					}
 				}
-				if prevMove == preEpMove &&
-					!g.moveAndCheck(Move{
-						Source: Cell{pic, cell},
-						Target: Cell{Empty, Position{row: cell.row + AdvDirs[g.whoseTurn()], col: cell.col + dir[0]}},
-						Action: Enpassant,
-					}, king) {
-					return true
+			case Queen, Bishop, Rook:
+				for _, dir := range PicDirs[pic.fig] {
+					for col, row := col+dir[0], row+dir[1]; isValidPosition(col, row); col, row = col+dir[0], row+dir[1] {
+						res = g.appendStep(res, source, Position{row: row, col: col})
+						if g.Board[row][col] != Empty {
+							break
+						}
+					}
 				}
 			}
 		}
-	case 'Q', 'N':
-		dirs := PicDirs['N']
-		if pic.fig == 'Q' {
-			dirs = PicDirs['Q']
-		}
+	}
 
-		for _, dir := range dirs {
-			col, row := cell.col+dir[0], cell.row+dir[1]
-			if isValidPosition(col, row) &&
-				(g.Board[row][col] == Empty || g.Board[row][col].side != pic.side) &&
-				!g.moveAndCheck(Move{
-					Source: Cell{pic, cell},
-					Target: Cell{g.Board[row][col], Position{row: row, col: col}},
-					Action: Movement,
-				}, king) {
-				return true
-			}
-		}
-	case 'B', 'R':
-		for _, dir := range PicDirs[pic.fig] {
-			col, row := cell.col+dir[0], cell.row+dir[1]
-			if isValidPosition(col, row) && g.Board[row][col].side != pic.side &&
-				!g.moveAndCheck(Move{
-					Source: Cell{pic, cell},
-					Target: Cell{g.Board[row][col], Position{row: row, col: col}},
-					Action: Movement,
-				}, king) {
-				return true
-			}
-		}
+	// Castling, the rest is checked by the processor
+	kingCastling, queenCastling := castlingMove(side, KingCastling), castlingMove(side, QueenCastling)
+	if g.sideKing(side) == kingCastling.Source.Position {
+		res = append(res, kingCastling, queenCastling)
 	}
 
-	return false
+	return res
 }
 
-func (g *Game) canKingMove(side Side) bool {
-	king := g.sideKing(side)
-
-	for _, dir := range PicDirs['K'] {
-		if col, row := king.col+dir[0], king.row+dir[1]; isValidPosition(col, row) {
-			if pic := g.Board[row][col]; pic != Empty && pic.side == side {
-				continue
-			}
+// Appends a movement or a capture to the target
+func (g *Game) appendStep(moves []Move, source Cell, target Position) []Move {
+	switch pic := g.Board[target.row][target.col]; {
+	case pic == Empty:
+		return append(moves, Move{Source: source, Target: Cell{Empty, target}, Action: Movement})
+	case pic.side != source.side:
+		return append(moves, Move{Source: source, Target: Cell{pic, target}, Action: Capture})
+	}
+	return moves
+}
 
-			if !g.moveAndCheck(Move{
-				Source: Cell{g.Board[king.row][king.col], king},
-				Target: Cell{g.Board[row][col], Position{row: row, col: col}},
-				Action: Movement,
-			}, king) {
-				return true
-			}
+func (g *Game) appendPawnMoves(moves []Move, source Cell) []Move {
+	side := source.side
+	appendPromotions := func(target Position) {
+		for _, fig := range PromotionFigures {
+			moves = append(moves, Move{Source: source, Target: Cell{Piece{fig, side}, target}, Action: Promotion})
 		}
 	}
-	return false
-}
 
-func (g *Game) moveAndCheck(move Move, check Position) bool {
-	advDir := AdvDirs[g.whoseTurn()]
-	var epPawn Piece
+	// Moves forward
+	if row := source.row + AdvDirs[side]; isValidPosition(source.col, row) && g.Board[row][source.col] == Empty {
+		if row == PromotionRows[side] {
+			appendPromotions(Position{row: row, col: source.col})
+		} else {
+			moves = append(moves, Move{Source: source, Target: Cell{Empty, Position{row: row, col: source.col}}, Action: Movement})
+		}
 
-	// Make move
-	g.moveCell(move.Source, move.Target)
-	if move.Action == Enpassant {
-		epPawn = g.Board[move.Source.row+advDir][move.Source.col]
-		g.Board[move.Source.row+advDir][move.Source.col] = Empty
+		if row := row + AdvDirs[side]; source.row == PawnRows[side] && g.Board[row][source.col] == Empty {
+			moves = append(moves, Move{Source: source, Target: Cell{Empty, Position{row: row, col: source.col}}, Action: Movement})
+		}
 	}
 
-	// Defer restoring of state
-	defer func() {
-		g.cancelMoveCell(move.Source, move.Target)
-		if move.Action == Enpassant {
-			g.Board[move.Source.row+advDir][move.Source.col] = epPawn
+	// Attacks
+	epPawn, isEp := g.lastDoubleStep()
+	for _, dir := range PawnAtkDirs[side] {
+		col, row := source.col+dir[0], source.row+dir[1]
+		if !isValidPosition(col, row) {
+			continue
 		}
-	}()
 
-	// Check for check
-	atkCells, _ := g.getAttackingCells(check, getOpponent(move.Source.side))
+		pic := g.Board[row][col]
+		switch {
+		case pic != Empty && pic.side != side && row == PromotionRows[side]:
+			appendPromotions(Position{row: row, col: col})
+		case pic != Empty && pic.side != side:
+			moves = append(moves, Move{Source: source, Target: Cell{pic, Position{row: row, col: col}}, Action: Capture})
+		case pic == Empty && isEp && epPawn == (Position{row: source.row, col: col}):
+			moves = append(moves, Move{Source: source, Target: Cell{Empty, Position{row: row, col: col}}, Action: Enpassant})
+		}
+	}
 
-	return len(atkCells) > 0
+	return moves
 }
 
 // Returns at most 2 cells that can attack the given cell
@@ -557,7 +640,7 @@ func (g *Game) getAttackingCells(cell Position, side Side) ([]Position, bool) {
 	isBlockable := len(res) == 1
 
 	// Pawn attacks
-	pawn := Piece{'P', side}
+	pawn := Piece{Pawn, side}
 	for _, dir := range PawnAtkDirs[side] {
 		col, row := cell.col-dir[0], cell.row-dir[1]
 
@@ -575,25 +658,14 @@ func (g *Game) getAttackingCells(cell Position, side Side) ([]Position, bool) {
 	}
 
 	// En passant
-	epPawn := Piece{'P', getOpponent(side)}
-	if len(g.Moves) > 0 && g.Board[cell.row][cell.col] == epPawn {
-		prevMove := g.Moves[len(g.Moves)-1]
-		advDir := AdvDirs[getOpponent(side)]
-		if isValidPosition(cell.col, cell.row-(advDir*2)) {
-			preEpMove := Move{
-				Source: Cell{g.Board[cell.row-(advDir*2)][cell.col], Position{row: cell.row - (advDir * 2), col: cell.col}},
-				Target: Cell{g.Board[cell.row][cell.col], Position{row: cell.row, col: cell.col}},
-				Action: Movement,
-			}
-			if prevMove == preEpMove {
-				for _, offset := range []int{-1, 1} {
-					if adjCol := cell.col + offset; isValidPosition(adjCol, cell.row) &&
-						g.Board[cell.row][adjCol] == pawn {
-						res = append(res, Position{row: cell.row, col: adjCol})
-						if len(res) == 2 {
-							return res, isBlockable
-						}
-					}
+	if epPawn, found := g.lastDoubleStep(); found && epPawn == cell &&
+		g.Board[cell.row][cell.col] == (Piece{Pawn, getOpponent(side)}) {
+		for _, offset := range []int{-1, 1} {
+			if adjCol := cell.col + offset; isValidPosition(adjCol, cell.row) &&
+				g.Board[cell.row][adjCol] == pawn {
+				res = append(res, Position{row: cell.row, col: adjCol})
+				if len(res) == 2 {
+					return res, isBlockable
 				}
 			}
 		}
@@ -607,71 +679,6 @@ func (g *Game) getAttackingCells(cell Position, side Side) ([]Position, bool) {
 	return res, isBlockable
 }
 
-// Returns first cell that can move to this position
-// Technically castling is a move, but it's not used to block a check
-// bool return parameter indicates if cell was found
-func (g *Game) getSourceCell(cell Position, side Side) (Position, bool) {
-	lines := g.getAttackingLines(cell, side)
-	if len(lines) > 0 {
-		return lines[0], true
-	}
-
-	knights := g.getAttackingKnights(cell, side)
-	if len(knights) > 0 {
-		return knights[0], true
-	}
-
-	if king, found := g.getAttackingKing(cell, side); found {
-		return king, true
-	}
-
-	// Pawn moves by attack
-	pic := g.Board[cell.row][cell.col]
-	pawn := Piece{'P', side}
-	if pic != Empty && pic.side == getOpponent(side) {
-		for _, dir := range PawnAtkDirs[getOpponent(side)] {
-			col, row := cell.col+dir[0], cell.row+dir[1]
-
-			if isValidPosition(col, row) && g.Board[row][col] == pawn {
-				return Position{row: row, col: col}, true
-			}
-		}
-	}
-
-	// Pawn moves 1 cell
-	if row := cell.row - AdvDirs[side]; isValidPosition(cell.col, row) &&
-		g.Board[row][cell.col] == pawn {
-		return Position{row: row, col: cell.col}, true
-	}
-
-	// Pawn moves 2 cell
-	if row := cell.row - 2*AdvDirs[side]; isValidPosition(cell.col, row) &&
-		g.Board[row][cell.col] == pawn && g.Board[cell.row][cell.col] == Empty {
-		return Position{row: row, col: cell.col}, true
-	}
-
-	// Pawn moves by en passant
-	if row := cell.row - AdvDirs[side]; len(g.Moves) > 0 && isValidPosition(cell.col, row) &&
-		g.Board[row][cell.col] == (Piece{'P', getOpponent(side)}) {
-		prevMove := g.Moves[len(g.Moves)-1]
-		preEpMove := Move{
-			Source: Cell{g.Board[row][cell.col], Position{row: row + (AdvDirs[side] * 2), col: cell.col}},
-			Target: Cell{Empty, Position{row: row, col: cell.col}},
-			Action: Movement,
-		}
-		if prevMove == preEpMove {
-			for _, offset := range PawnAtkDirs[side] {
-				if adjCol := cell.col + offset[0]; isValidPosition(adjCol, row) &&
-					g.Board[row][adjCol] == pawn {
-					return Position{row: row, col: adjCol}, true
-				}
-			}
-		}
-	}
-
-	return Position{}, false
-}
-
 func (g *Game) getAttackingLines(cell Position, side Side) []Position {
 	res := make([]Position, 0, 2)
 
@@ -680,9 +687,8 @@ func (g *Game) getAttackingLines(cell Position, side Side) []Position {
 			col, row := cell.col+dir[0], cell.row+dir[1]
 			for ; isValidPosition(col, row); col, row = col+dir[0], row+dir[1] {
 				p := g.Board[row][col]
-				// Dir is blocked
-				if p != Empty && p.side == getOpponent(side) {
-					break
+				if p == Empty {
+					continue
 				}
 				if isAttacker(p) {
 					res = append(res, Position{row: row, col: col})
@@ -690,26 +696,28 @@ func (g *Game) getAttackingLines(cell Position, side Side) []Position {
 						return
 					}
 				}
+				// Dir is blocked
+				break
 			}
 		}
 	}
 
 	// Lines
 	isFullLineAttacker := func(p Piece) bool {
-		return p == Piece{'R', side} ||
-			p == Piece{'Q', side}
+		return p == Piece{Rook, side} ||
+			p == Piece{Queen, side}
 	}
-	checkDirections(PicDirs['R'], isFullLineAttacker)
+	checkDirections(PicDirs[Rook], isFullLineAttacker)
 	if len(res) == 2 {
 		return res
 	}
 
 	// Diagonals
 	isFullDiagonalAttacker := func(p Piece) bool {
-		return p == Piece{'B', side} ||
-			p == Piece{'Q', side}
+		return p == Piece{Bishop, side} ||
+			p == Piece{Queen, side}
 	}
-	checkDirections(PicDirs['B'], isFullDiagonalAttacker)
+	checkDirections(PicDirs[Bishop], isFullDiagonalAttacker)
 
 	return res
 }
@@ -717,9 +725,9 @@ func (g *Game) getAttackingLines(cell Position, side Side) []Position {
 func (g *Game) getAttackingKnights(cell Position, side Side) []Position {
 	res := make([]Position, 0, 2)
 
-	for _, move := range PicDirs['N'] {
+	for _, move := range PicDirs[Knight] {
 		col, row := cell.col+move[0], cell.row+move[1]
-		if isValidPosition(col, row) && g.Board[row][col] == (Piece{'N', side}) {
+		if isValidPosition(col, row) && g.Board[row][col] == (Piece{Knight, side}) {
 			res = append(res, Position{row: row, col: col})
 			if len(res) == 2 {
 				return res
@@ -730,10 +738,18 @@ func (g *Game) getAttackingKnights(cell Position, side Side) []Position {
 	return res
 }
 
+func (g *Game) getCells(positions []Position) []Cell {
+	cells := make([]Cell, 0, len(positions))
+	for _, pos := range positions {
+		cells = append(cells, Cell{g.Board[pos.row][pos.col], pos})
+	}
+	return cells
+}
+
 func (g *Game) getAttackingKing(cell Position, side Side) (Position, bool) {
-	for _, move := range PicDirs['K'] {
+	for _, move := range PicDirs[King] {
 		col, row := cell.col+move[0], cell.row+move[1]
-		if isValidPosition(col, row) && g.Board[row][col] == (Piece{'K', side}) {
+		if isValidPosition(col, row) && g.Board[row][col] == (Piece{King, side}) {
 			return Position{row: row, col: col}, true
 		}
 	}
@@ -742,20 +758,14 @@ func (g *Game) getAttackingKing(cell Position, side Side) (Position, bool) {
 }
 
 func (g *Game) moveCell(source, target Cell) {
-	g.Board[target.row][target.col] = g.Board[source.row][source.col]
-	g.Board[source.row][source.col] = Empty
+	pic := g.Board[source.row][source.col]
 
-	if target.Piece != Empty {
-		switch getOpponent(source.side) {
-		case Black:
-			g.blackCells--
-		case White:
-			g.whiteCells--
-		}
-	}
+	g.removeCell(target.Position)
+	g.Board[target.row][target.col] = pic
+	g.Board[source.row][source.col] = Empty
 
-	if source.fig == 'K' {
-		if source.side == White {
+	if pic.fig == King {
+		if pic.side == White {
 			g.whiteKing = Position{row: target.row, col: target.col}
 		} else {
 			g.blackKing = Position{row: target.row, col: target.col}
@@ -763,24 +773,59 @@ func (g *Game) moveCell(source, target Cell) {
 	}
 }
 
-func (g *Game) cancelMoveCell(source, target Cell) {
-	g.Board[target.row][target.col] = target.Piece
-	g.Board[source.row][source.col] = source.Piece
-
-	switch getOpponent(source.side) {
+func (g *Game) removeCell(cell Position) {
+	switch g.Board[cell.row][cell.col].side {
 	case Black:
-		g.blackCells++
+		g.blackCells--
 	case White:
-		g.whiteCells++
+		g.whiteCells--
 	}
 
-	if source.fig == 'K' {
-		if g.whoseTurn() == White {
-			g.whiteKing = Position{row: source.row, col: source.col}
-		} else {
-			g.blackKing = Position{row: source.row, col: source.col}
+	g.Board[cell.row][cell.col] = Empty
+}
+
+// Returns position of the pawn which has moved 2 cells by the last move
+func (g *Game) lastDoubleStep() (Position, bool) {
+	if len(g.moves) == 0 {
+		// The game could be started right after the pawn has moved
+		epRows := map[Side]int{Black: 3, White: 4}
+		if pos := g.start.enpassant; pos.row == epRows[g.whoseTurn()] && isValidPosition(pos.col, pos.row) &&
+			g.Board[pos.row][pos.col] == (Piece{Pawn, getOpponent(g.whoseTurn())}) {
+			return pos, true
 		}
+		return Position{}, false
+	}
+
+	prevMove := g.moves[len(g.moves)-1]
+	if prevMove.Action != Movement || prevMove.Source.fig != Pawn || prevMove.Source.col != prevMove.Target.col ||
+		prevMove.Target.row-prevMove.Source.row != 2*AdvDirs[prevMove.Source.side] {
+		return Position{}, false
+	}
+
+	return prevMove.Target.Position, true
+}
+
+func (g *Game) clone() Game {
+	c := *g
+	c.Board = make(Board, len(g.Board))
+	for row := range g.Board {
+		c.Board[row] = slices.Clone(g.Board[row])
 	}
+	// Appending to the copy must not write into the moves of the original
+	c.moves = slices.Clip(g.moves)
+	return c
+}
+
+// Copies the state of other game in place,
+// so the existing references to the board stay valid
+func (g *Game) copyFrom(other Game) {
+	board := g.Board
+	for row := range board {
+		copy(board[row], other.Board[row])
+	}
+
+	*g = other
+	g.Board = board
 }
 
 func (g *Game) sideKing(side Side) Position {
@@ -791,24 +836,28 @@ func (g *Game) sideKing(side Side) Position {
 }
 
 func (g *Game) whoseTurn() Side {
-	if len(g.Moves)%2 == 0 {
+	return g.moveSide(len(g.moves))
+}
+
+// Returns side which makes the i-th move
+func (g *Game) moveSide(i int) Side {
+	if (i%2 == 0) == (g.start.turn != Black) {
 		return White
 	}
 	return Black
 }
 
 func checkAtkDir(move Move) error {
-	if move.Source.fig != 'P' {
-		err := checkMoveDir(move)
-		if err != nil {
-			return fmt.Errorf("invalid attack")
+	if move.Source.fig != Pawn {
+		if err := checkMoveDir(move); err != nil {
+			return newIllegalMoveError(ReasonInvalidAttack, move)
 		}
-		return err
+		return nil
 	}
 
 	atkDir := [2]int{move.Target.col - move.Source.col, move.Target.row - move.Source.row}
 	if !slices.Contains(PawnAtkDirs[move.Source.side], atkDir) {
-		return fmt.Errorf("invalid attack")
+		return newIllegalMoveError(ReasonInvalidAttack, move)
 	}
 
 	return nil
@@ -817,7 +866,13 @@ func checkAtkDir(move Move) error {
 func checkMoveDir(move Move) error {
 	var dir [2]int
 	switch move.Source.fig {
-	case 'Q', 'B', 'R':
+	case Queen, Bishop, Rook:
+		// Sliding pieces move only along a straight line
+		if drow, dcol := move.Target.row-move.Source.row, move.Target.col-move.Source.col; drow != 0 && dcol != 0 &&
+			drow != dcol && drow != -dcol {
+			return newIllegalMoveError(ReasonInvalidMove, move)
+		}
+
 		drow, dcol := 0, 0
 
 		if move.Target.row > move.Source.row {
@@ -836,14 +891,21 @@ func checkMoveDir(move Move) error {
 		dir = [2]int{move.Target.col - move.Source.col, move.Target.row - move.Source.row}
 	}
 
-	dirs := append(PicDirs[move.Source.fig], PawnDirs[move.Source.side]...)
+	dirs := PicDirs[move.Source.fig]
+	if move.Source.fig == Pawn {
+		dirs = PawnDirs[move.Source.side]
+	}
 	if !slices.Contains(dirs, dir) {
-		return fmt.Errorf("invalid move")
+		return newIllegalMoveError(ReasonInvalidMove, move)
 	}
 
 	return nil
 }
 
+func isCastling(move Move) bool {
+	return move.Action == KingCastling || move.Action == QueenCastling
+}
+
 func isValidPosition(col, row int) bool {
 	return col > -1 && col < 8 && row > -1 && row < 8
 }
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Editor sets up an arbitrary position. The position may break the rules
+// while it's edited, it's validated once the game is built
+type Editor struct {
+	s Snapshot
+}
+
+// Returns an editor of an empty board with white to move
+func NewEditor() *Editor {
+	return &Editor{s: Snapshot{turn: White}}
+}
+
+// Returns an editor starting from the snapshot, the snapshot itself is never changed
+func (s Snapshot) Edit() *Editor {
+	return &Editor{s: s}
+}
+
+// Put places the piece replacing the one standing on the cell
+func (e *Editor) Put(pic Piece, pos Position) error {
+	if !pic.fig.IsValid() || !pic.side.IsValid() {
+		return fmt.Errorf("invalid piece: %q", pic.String())
+	}
+	if !isValidPosition(pos.col, pos.row) {
+		return fmt.Errorf("invalid position: row %d, col %d", pos.row, pos.col)
+	}
+
+	e.s.board[pos.row][pos.col] = pic
+	return nil
+}
+
+// Remove empties the cell and returns the piece standing on it
+func (e *Editor) Remove(pos Position) Piece {
+	if !isValidPosition(pos.col, pos.row) {
+		return Empty
+	}
+
+	pic := e.s.board[pos.row][pos.col]
+	e.s.board[pos.row][pos.col] = Empty
+	return pic
+}
+
+// Clear removes every piece, castling rights and en passant
+func (e *Editor) Clear() {
+	e.s = Snapshot{turn: e.s.turn}
+}
+
+func (e *Editor) SetTurn(side Side) error {
+	if !side.IsValid() {
+		return fmt.Errorf("invalid side: %q", rune(side))
+	}
+
+	e.s.turn = side
+	return nil
+}
+
+func (e *Editor) SetCastling(rights CastlingRights) {
+	e.s.castling = rights & AllCastling
+}
+
+// SetEnPassant sets the pawn which can be captured en passant,
+// it stands on the 4th or the 5th rank after its double step, see ClearEnPassant
+func (e *Editor) SetEnPassant(pos Position) error {
+	if !isValidPosition(pos.col, pos.row) || (pos.row != 3 && pos.row != 4) {
+		return fmt.Errorf("invalid en passant position: row %d, col %d", pos.row, pos.col)
+	}
+
+	e.s.enpassant = pos
+	return nil
+}
+
+// ClearEnPassant forbids capturing en passant
+func (e *Editor) ClearEnPassant() {
+	e.s.enpassant = Position{}
+}
+
+// Mirror swaps the files of the pieces, "a" with "h" and so on.
+// Castling rights are cleared, as kings and rooks leave their cells
+func (e *Editor) Mirror() {
+	for row := range e.s.board {
+		for col := range 4 {
+			e.s.board[row][col], e.s.board[row][7-col] = e.s.board[row][7-col], e.s.board[row][col]
+		}
+	}
+
+	e.s.castling = 0
+	if pos, ok := e.s.EnPassant(); ok {
+		e.s.enpassant = Position{row: pos.row, col: 7 - pos.col}
+	}
+}
+
+// FlipColors swaps the sides of the pieces and the ranks of the board,
+// the turn and castling rights pass to the other side, so the position stays the same
+func (e *Editor) FlipColors() {
+	var board [8][8]Piece
+	for row := range e.s.board {
+		for col, pic := range e.s.board[row] {
+			if pic != Empty {
+				pic.side = getOpponent(pic.side)
+			}
+			board[7-row][col] = pic
+		}
+	}
+	e.s.board = board
+	e.s.turn = getOpponent(e.s.turn)
+
+	var castling CastlingRights
+	for _, side := range []Side{White, Black} {
+		for _, action := range []Action{KingCastling, QueenCastling} {
+			if e.s.castling&castlingRight(side, action) != 0 {
+				castling |= castlingRight(getOpponent(side), action)
+			}
+		}
+	}
+	e.s.castling = castling
+
+	if pos, ok := e.s.EnPassant(); ok {
+		e.s.enpassant = Position{row: 7 - pos.row, col: pos.col}
+	}
+}
+
+// Returns the edited position as it is
+func (e *Editor) Snapshot() Snapshot {
+	return e.s
+}
+
+// Build validates the position and starts a game from it.
+// Violations are joined into the error, each of them is *PositionError
+func (e *Editor) Build() (Game, error) {
+	if errs := ValidatePosition(e.s); errs != nil {
+		joined := make([]error, 0, len(errs))
+		for _, err := range errs {
+			joined = append(joined, err)
+		}
+		return Game{}, errors.Join(joined...)
+	}
+
+	return e.s.Game(), nil
+}
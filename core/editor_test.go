@@ -0,0 +1,124 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEditor_Build(t *testing.T) {
+	e := NewEditor()
+	e.Put(Piece{King, White}, Position{row: 0, col: 4})
+	e.Put(Piece{Queen, White}, Position{row: 6, col: 3})
+	e.Put(Piece{Knight, Black}, Position{row: 7, col: 0})
+	e.Put(Piece{King, Black}, Position{row: 7, col: 4})
+
+	if pic := e.Remove(Position{row: 7, col: 0}); pic != (Piece{Knight, Black}) {
+		t.Fatalf("Unexpected removed piece %v", pic)
+	}
+	if err := e.SetTurn(Black); err != nil {
+		t.Fatal(err)
+	}
+
+	game, err := e.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.whiteKing != (Position{row: 0, col: 4}) || game.blackKing != (Position{row: 7, col: 4}) ||
+		game.whiteCells != 2 || game.blackCells != 1 || game.Turn() != Black {
+		t.Fatalf("Derived fields don't match the board: %+v", game)
+	}
+
+	// The queen is protected by nothing
+	move, err := game.ParseSAN("Kxd7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := game.Play(move); err != nil || game.Outcome() != Stalemate {
+		t.Fatalf("Unexpected outcome %v: %v", game.Outcome(), err)
+	}
+}
+
+func TestEditor_BuildErrors(t *testing.T) {
+	e := NewEditor()
+	e.Put(Piece{King, White}, Position{row: 0, col: 4})
+	e.Put(Piece{Pawn, White}, Position{row: 7, col: 0})
+
+	_, err := e.Build()
+
+	var posErr *PositionError
+	if !errors.As(err, &posErr) || posErr.Violation != ViolationPawnRank || posErr.Side != White {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// Every violation is reported
+	if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 2 ||
+		errs[1].(*PositionError).Violation != ViolationKingCount {
+		t.Fatalf("Unexpected errors %v", errs)
+	}
+
+	if err := e.Put(Empty, Position{}); err == nil {
+		t.Fatal("Expected error for an empty piece")
+	}
+	if err := e.Put(Piece{King, Black}, Position{row: 8}); err == nil {
+		t.Fatal("Expected error for a position outside the board")
+	}
+	if err := e.SetTurn('x'); err == nil {
+		t.Fatal("Expected error for an invalid side")
+	}
+	// a1 can't be told apart from no en passant
+	if err := e.SetEnPassant(Position{}); err == nil {
+		t.Fatal("Expected error for a pawn off the en passant ranks")
+	}
+
+	e.SetEnPassant(Position{row: 4, col: 3})
+	if _, ok := e.Snapshot().EnPassant(); !ok {
+		t.Fatal("En passant isn't set")
+	}
+	e.ClearEnPassant()
+	if _, ok := e.Snapshot().EnPassant(); ok {
+		t.Fatal("En passant isn't cleared")
+	}
+
+	e.Clear()
+	if e.Snapshot().FEN() != "8/8/8/8/8/8/8/8 w - - 0 1" {
+		t.Fatalf("Board isn't cleared: %s", e.Snapshot().FEN())
+	}
+}
+
+func TestEditor_MirrorFlip(t *testing.T) {
+	tests := []struct {
+		fen      string
+		mirror   bool
+		expected string
+	}{
+		{"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", true, "3k4/8/8/8/8/8/8/3K3R w - - 0 1"},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", true, "rnbkqbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBKQBNR b - d3 0 1"},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", false, "rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1"},
+		{"4k2r/8/8/8/8/8/8/4K3 w k - 0 1", false, "4k3/8/8/8/8/8/8/4K2R b K - 0 1"},
+	}
+
+	for _, test := range tests {
+		s, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		e := s.Edit()
+		if test.mirror {
+			e.Mirror()
+		} else {
+			e.FlipColors()
+		}
+
+		if fen := e.Snapshot().FEN(); fen != test.expected {
+			t.Fatalf("%s: expected %s, got %s", test.fen, test.expected, fen)
+		}
+		if s.FEN() != test.fen {
+			t.Fatalf("Snapshot was changed: %s", s.FEN())
+		}
+		if _, err := e.Build(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Largest number of pieces, kings included, of a generated table
+const maxEndgamePieces = 4
+
+// Order of the pieces in a material
+const materialOrder = "KQRBNP"
+
+// Magic bytes, version and extension of a table file,
+// the tables don't change with the schema of the games
+const (
+	endgameMagic     = "SHEG"
+	endgameVersion   = 1
+	EndgameExtension = ".egt"
+)
+
+// EndgameTable holds distance to mate of every position of a material
+// with either side to move, see Endgames.Probe
+type EndgameTable struct {
+	material string
+	pieces   []Piece
+	values   []int8
+}
+
+// Endgames is a set of tables generated by retrograde analysis.
+// Positions of the tables have no castling rights and no en passant
+type Endgames struct {
+	tables map[string]*EndgameTable
+}
+
+// MateDistance is the result of the side to move with the best play of both sides
+type MateDistance struct {
+	// 1 if the side to move mates, -1 if it gets mated, 0 for a draw
+	Result int
+	// Plies till the mate, zero for a draw and for a checkmated side
+	Plies int
+}
+
+func NewEndgames() *Endgames {
+	return &Endgames{tables: map[string]*EndgameTable{}}
+}
+
+// Generate generates tables of the materials like "KQvK" or "KRvKP"
+// and the tables of the materials reached by captures and promotions.
+// Materials with up to 4 pieces are supported, pawns may belong to one side only
+func (e *Endgames) Generate(materials ...string) error {
+	for _, material := range materials {
+		if err := e.ensure(material); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Endgames) ensure(material string) error {
+	// Tables are keyed by the material in the order of the pieces
+	material, pieces, err := parseMaterial(material)
+	if err != nil {
+		return err
+	}
+
+	if material == "KvK" || e.table(material) != nil {
+		return nil
+	}
+
+	for _, next := range conversions(pieces) {
+		if err := e.ensure(next); err != nil {
+			return err
+		}
+	}
+
+	e.tables[material] = e.generate(material, pieces)
+	return nil
+}
+
+// Returns materials reached from the pieces by a capture, a promotion or both
+func conversions(pieces []Piece) []string {
+	var res []string
+	add := func(pieces []egPiece) {
+		if material := materialOf(pieces); !slices.Contains(res, material) {
+			res = append(res, material)
+		}
+	}
+
+	placed := make([]egPiece, len(pieces))
+	for i, pic := range pieces {
+		placed[i] = egPiece{Piece: pic}
+	}
+
+	for i, p := range placed {
+		if p.fig != King {
+			add(slices.Delete(slices.Clone(placed), i, i+1))
+		}
+		if p.fig != Pawn {
+			continue
+		}
+
+		for _, fig := range PromotionFigures {
+			promoted := slices.Clone(placed)
+			promoted[i].fig = fig
+			add(promoted)
+
+			for j, captured := range promoted {
+				if captured.side != p.side && captured.fig != King {
+					add(slices.Delete(slices.Clone(promoted), j, j+1))
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Returns the table of the material, nil if it isn't generated
+func (e *Endgames) table(material string) *EndgameTable {
+	if t := e.tables[material]; t != nil {
+		return t
+	}
+	white, black, _ := strings.Cut(material, "v")
+	return e.tables[black+"v"+white]
+}
+
+// Returns stored value of the position, tables with switched colors are used as well
+func (e *Endgames) value(pieces []egPiece, stm Side) (int8, bool) {
+	material := materialOf(pieces)
+	t := e.tables[material]
+
+	var buf [maxEndgamePieces]egPiece
+	position := buf[:0]
+	if t == nil {
+		white, black, _ := strings.Cut(material, "v")
+		if t = e.tables[black+"v"+white]; t == nil {
+			return egDraw, false
+		}
+
+		// Colors are switched and the board is flipped
+		for _, p := range pieces {
+			position = append(position, egPiece{Piece{p.fig, getOpponent(p.side)}, p.sq ^ 56})
+		}
+		stm = getOpponent(stm)
+	} else {
+		position = append(position, pieces...)
+	}
+
+	// Pieces are ordered as the table ones
+	var ordered [maxEndgamePieces]egPiece
+	for i, pic := range t.pieces {
+		j := slices.IndexFunc(position, func(p egPiece) bool { return p.Piece == pic })
+		ordered[i] = position[j]
+		position = slices.Delete(position, j, j+1)
+	}
+
+	return t.values[t.index(ordered[:len(t.pieces)], stm)], true
+}
+
+// Probe returns distance to mate of the current position,
+// false if the position isn't covered by the tables
+func (e *Endgames) Probe(game *Game) (MateDistance, bool) {
+	s := game.Snapshot()
+	pieces, ok := snapshotPieces(s)
+	if !ok {
+		return MateDistance{}, false
+	}
+
+	if len(pieces) == 2 {
+		return MateDistance{}, true
+	}
+
+	v, ok := e.value(pieces, s.turn)
+	if !ok || v == egIllegal {
+		return MateDistance{}, false
+	}
+	return mateDistance(v), true
+}
+
+// BestMove returns the move which wins the fastest, keeps the draw
+// or delays the mate the most
+func (e *Endgames) BestMove(game *Game) (Move, bool) {
+	if _, ok := e.Probe(game); !ok {
+		return Move{}, false
+	}
+
+	var best Move
+	bestRank, found := 0, false
+	for _, move := range game.LegalMoves() {
+		next := game.clone()
+		if err := next.processMove(move); err != nil {
+			continue
+		}
+
+		dist, ok := e.Probe(&next)
+		if !ok {
+			return Move{}, false
+		}
+
+		// Opponent's loss is ranked by the shortest mate, its win by the longest one
+		rank := -dist.Result * 1000
+		if dist.Result != 0 {
+			rank += dist.Result * dist.Plies
+		}
+
+		if !found || rank > bestRank {
+			best, bestRank, found = move, rank, true
+		}
+	}
+	return best, found
+}
+
+// Returns pieces of the snapshot, false if it can't be in a table
+func snapshotPieces(s Snapshot) ([]egPiece, bool) {
+	if s.castling != 0 {
+		return nil, false
+	}
+
+	var pieces []egPiece
+	for row := range s.board {
+		for col, pic := range s.board[row] {
+			if pic == Empty {
+				continue
+			}
+			if len(pieces) == maxEndgamePieces {
+				return nil, false
+			}
+			pieces = append(pieces, egPiece{pic, row*8 + col})
+		}
+	}
+	return pieces, true
+}
+
+func mateDistance(v int8) MateDistance {
+	switch {
+	case v > 0:
+		return MateDistance{Result: 1, Plies: int(v)}
+	case v < 0:
+		return MateDistance{Result: -1, Plies: -int(v) - 1}
+	}
+	return MateDistance{}
+}
+
+// Returns material of the table like "KRvKP"
+func (t *EndgameTable) Material() string {
+	return t.material
+}
+
+// Returns the largest distance to mate in plies
+func (t *EndgameTable) MaxPlies() int {
+	res := 0
+	for _, v := range t.values {
+		if v != egIllegal {
+			res = max(res, mateDistance(v).Plies)
+		}
+	}
+	return res
+}
+
+// WriteTo writes the table compressed
+func (t *EndgameTable) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	buf.WriteString(endgameMagic)
+	buf.WriteByte(endgameVersion)
+	buf.WriteByte(byte(len(t.material)))
+	buf.WriteString(t.material)
+
+	zw, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range t.values {
+		zw.Write([]byte{byte(v)})
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ReadEndgameTable reads a table written by EndgameTable.WriteTo
+func ReadEndgameTable(r io.Reader) (*EndgameTable, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(endgameMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil || string(header[:len(endgameMagic)]) != endgameMagic {
+		return nil, fmt.Errorf("not an endgame table")
+	}
+
+	if version := header[len(endgameMagic)]; version != endgameVersion {
+		return nil, fmt.Errorf("unsupported schema version: %d", version)
+	}
+
+	material := make([]byte, header[len(endgameMagic)+1])
+	if _, err := io.ReadFull(br, material); err != nil {
+		return nil, fmt.Errorf("truncated endgame table")
+	}
+
+	name, pieces, err := parseMaterial(string(material))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &EndgameTable{material: name, pieces: pieces, values: make([]int8, 2<<(6*len(pieces)))}
+	data := make([]byte, len(t.values))
+	if _, err := io.ReadFull(flate.NewReader(br), data); err != nil {
+		return nil, fmt.Errorf("truncated endgame table")
+	}
+	for i, v := range data {
+		t.values[i] = int8(v)
+	}
+
+	return t, nil
+}
+
+// Save writes every table into the directory, a file per material
+func (e *Endgames) Save(dir string) error {
+	for material, t := range e.tables {
+		f, err := os.Create(filepath.Join(dir, material+EndgameExtension))
+		if err != nil {
+			return err
+		}
+
+		_, err = t.WriteTo(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadEndgames reads the tables saved into the directory
+func LoadEndgames(dir string) (*Endgames, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+EndgameExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	e := NewEndgames()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := ReadEndgameTable(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		e.tables[t.material] = t
+	}
+	return e, nil
+}
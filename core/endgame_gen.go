@@ -0,0 +1,487 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Stored values of the endgame tables.
+// Positive value is a win in that many plies, negative one is a loss in -value-1 plies
+const (
+	egDraw    = 0
+	egIllegal = math.MinInt8
+	egUnknown = math.MaxInt8
+	// Buckets of the generator keep this bit for the wins found by conversions
+	egPending = 1 << 31
+)
+
+// Piece of an enumerated position, squares are numbered row*8+col
+type egPiece struct {
+	Piece
+	sq int
+}
+
+var (
+	egKnightSteps [64][]int
+	egKingSteps   [64][]int
+)
+
+func init() {
+	for sq := range 64 {
+		for _, dir := range KnightDirs {
+			if col, row := sq%8+dir[0], sq/8+dir[1]; isValidPosition(col, row) {
+				egKnightSteps[sq] = append(egKnightSteps[sq], row*8+col)
+			}
+		}
+		for _, dir := range KingDirs {
+			if col, row := sq%8+dir[0], sq/8+dir[1]; isValidPosition(col, row) {
+				egKingSteps[sq] = append(egKingSteps[sq], row*8+col)
+			}
+		}
+	}
+}
+
+// Generates the table, the tables of the materials reached
+// by captures and promotions must already be in the set
+func (e *Endgames) generate(material string, pieces []Piece) *EndgameTable {
+	t := &EndgameTable{material: material, pieces: pieces}
+	size := 2
+	for range pieces {
+		size *= 64
+	}
+
+	t.values = make([]int8, size)
+	counts := make([]uint8, size)
+	// Plies of the longest conversion lost to the opponent plus one, -1 if the position can't be lost
+	conversions := make([]int8, size)
+	var buckets [][]uint32
+	push := func(depth int, idx uint32) {
+		for len(buckets) <= depth {
+			buckets = append(buckets, nil)
+		}
+		buckets[depth] = append(buckets[depth], idx)
+	}
+
+	board := make([]egPiece, len(pieces))
+	for idx := range t.values {
+		t.decode(idx, board)
+		stm := egSide(idx)
+		if !egIsLegal(board, stm) {
+			t.values[idx] = egIllegal
+			continue
+		}
+
+		minWin, maxLoss, hasDraw, legal := -1, -1, false, false
+		egMoves(board, stm, func(child []egPiece, conversion bool) {
+			legal = true
+			if !conversion {
+				counts[idx]++
+				return
+			}
+
+			switch v := e.lookup(child, getOpponent(stm)); {
+			case v < 0:
+				if win := -int(v); minWin == -1 || win < minWin {
+					minWin = win
+				}
+			case v > 0:
+				maxLoss = max(maxLoss, int(v))
+			default:
+				hasDraw = true
+			}
+		})
+
+		switch {
+		case !legal && egInCheck(board, stm):
+			t.values[idx] = -1
+			push(0, uint32(idx))
+		case !legal:
+			t.values[idx] = egDraw
+		case minWin != -1 && counts[idx] == 0:
+			t.values[idx] = int8(minWin)
+			push(minWin, uint32(idx))
+		case minWin != -1:
+			t.values[idx] = egUnknown
+			conversions[idx] = -1
+			push(minWin, uint32(idx)|egPending)
+		case counts[idx] == 0 && hasDraw:
+			t.values[idx] = egDraw
+		case counts[idx] == 0:
+			t.values[idx] = int8(-maxLoss - 2)
+			push(maxLoss+1, uint32(idx))
+		default:
+			t.values[idx] = egUnknown
+			conversions[idx] = int8(maxLoss + 1)
+			if hasDraw {
+				conversions[idx] = -1
+			}
+		}
+	}
+
+	// Positions are resolved by the plies to mate, a lost position makes
+	// its predecessors won and a won one is lost when all its moves are won by the opponent
+	predecessor := make([]egPiece, len(pieces))
+	for depth := 0; depth < len(buckets); depth++ {
+		for _, entry := range buckets[depth] {
+			idx := int(entry &^ egPending)
+			if entry&egPending != 0 {
+				if t.values[idx] != egUnknown {
+					continue
+				}
+				t.values[idx] = int8(depth)
+			}
+
+			won := t.values[idx] > 0
+			t.decode(idx, board)
+			egUnmoves(board, getOpponent(egSide(idx)), predecessor, func() {
+				prev := t.index(predecessor, getOpponent(egSide(idx)))
+				if t.values[prev] != egUnknown {
+					return
+				}
+
+				if !won {
+					t.values[prev] = int8(depth + 1)
+					push(depth+1, uint32(prev))
+					return
+				}
+
+				counts[prev]--
+				if counts[prev] == 0 && conversions[prev] != -1 {
+					loss := max(depth, int(conversions[prev])-1) + 1
+					t.values[prev] = int8(-loss - 1)
+					push(loss, uint32(prev))
+				}
+			})
+		}
+	}
+
+	for idx, v := range t.values {
+		if v == egUnknown {
+			t.values[idx] = egDraw
+		}
+	}
+
+	return t
+}
+
+// Returns value of a position reached by a conversion, kings alone are a draw
+func (e *Endgames) lookup(pieces []egPiece, stm Side) int8 {
+	if len(pieces) == 2 {
+		return egDraw
+	}
+
+	v, _ := e.value(pieces, stm)
+	return v
+}
+
+func egSide(idx int) Side {
+	if idx&1 == 0 {
+		return White
+	}
+	return Black
+}
+
+// Index of the position, the pieces are ordered as the table ones
+func (t *EndgameTable) index(pieces []egPiece, stm Side) int {
+	idx := 0
+	for _, p := range pieces {
+		idx = idx*64 + p.sq
+	}
+
+	idx *= 2
+	if stm == Black {
+		idx++
+	}
+	return idx
+}
+
+func (t *EndgameTable) decode(idx int, pieces []egPiece) {
+	idx /= 2
+	for i := len(t.pieces) - 1; i >= 0; i-- {
+		pieces[i] = egPiece{t.pieces[i], idx % 64}
+		idx /= 64
+	}
+}
+
+// Checks pieces don't share squares, pawns are off the last rows
+// and the side which has just moved isn't in check
+func egIsLegal(pieces []egPiece, stm Side) bool {
+	for i, p := range pieces {
+		if p.fig == Pawn && (p.sq < 8 || p.sq >= 56) {
+			return false
+		}
+		for _, other := range pieces[:i] {
+			if other.sq == p.sq {
+				return false
+			}
+		}
+	}
+	return !egInCheck(pieces, getOpponent(stm))
+}
+
+func egInCheck(pieces []egPiece, side Side) bool {
+	for _, p := range pieces {
+		if p.Piece == (Piece{King, side}) {
+			return egIsAttacked(pieces, p.sq, getOpponent(side))
+		}
+	}
+	return false
+}
+
+// Checks the square is attacked by the side
+func egIsAttacked(pieces []egPiece, sq int, side Side) bool {
+	for _, p := range pieces {
+		if p.side != side || p.sq == sq {
+			continue
+		}
+
+		switch p.fig {
+		case Pawn:
+			if sq/8-p.sq/8 == AdvDirs[side] && abs(sq%8-p.sq%8) == 1 {
+				return true
+			}
+		case Knight:
+			if egContains(egKnightSteps[p.sq], sq) {
+				return true
+			}
+		case King:
+			if egContains(egKingSteps[p.sq], sq) {
+				return true
+			}
+		default:
+			for _, dir := range PicDirs[p.fig] {
+				if egSlides(pieces, p.sq, sq, dir) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Checks the slider reaches the target along the direction
+func egSlides(pieces []egPiece, from, to int, dir [2]int) bool {
+	for col, row := from%8+dir[0], from/8+dir[1]; isValidPosition(col, row); col, row = col+dir[0], row+dir[1] {
+		sq := row*8 + col
+		if sq == to {
+			return true
+		}
+		if egAt(pieces, sq) >= 0 {
+			return false
+		}
+	}
+	return false
+}
+
+// Returns number of the piece on the square, -1 if it's empty
+func egAt(pieces []egPiece, sq int) int {
+	for i, p := range pieces {
+		if p.sq == sq {
+			return i
+		}
+	}
+	return -1
+}
+
+func egContains(squares []int, sq int) bool {
+	for _, s := range squares {
+		if s == sq {
+			return true
+		}
+	}
+	return false
+}
+
+// Calls yield with the position after every legal move of the side.
+// Conversions are captures and promotions, they change the material
+func egMoves(pieces []egPiece, side Side, yield func(child []egPiece, conversion bool)) {
+	child := make([]egPiece, 0, len(pieces))
+	try := func(i, target int, fig Figure) {
+		child = child[:0]
+		captured := false
+		for j, p := range pieces {
+			switch {
+			case j == i:
+				child = append(child, egPiece{Piece{fig, side}, target})
+			case p.sq == target:
+				captured = true
+			default:
+				child = append(child, p)
+			}
+		}
+
+		if !egInCheck(child, side) {
+			yield(child, captured || fig != pieces[i].fig)
+		}
+	}
+
+	for i, p := range pieces {
+		if p.side != side {
+			continue
+		}
+
+		steps := func(targets []int) {
+			for _, target := range targets {
+				if j := egAt(pieces, target); j == -1 || pieces[j].side != side {
+					try(i, target, p.fig)
+				}
+			}
+		}
+
+		switch p.fig {
+		case Pawn:
+			pawnTargets(pieces, p, func(target int) {
+				if target/8 == PromotionRows[side] {
+					for _, fig := range PromotionFigures {
+						try(i, target, fig)
+					}
+				} else {
+					try(i, target, Pawn)
+				}
+			})
+		case Knight:
+			steps(egKnightSteps[p.sq])
+		case King:
+			steps(egKingSteps[p.sq])
+		default:
+			for _, dir := range PicDirs[p.fig] {
+				for col, row := p.sq%8+dir[0], p.sq/8+dir[1]; isValidPosition(col, row); col, row = col+dir[0], row+dir[1] {
+					target := row*8 + col
+					j := egAt(pieces, target)
+					if j == -1 || pieces[j].side != side {
+						try(i, target, p.fig)
+					}
+					if j != -1 {
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// Calls yield with targets of the pawn, the positions have no en passant
+func pawnTargets(pieces []egPiece, p egPiece, yield func(int)) {
+	adv := AdvDirs[p.side] * 8
+	if egAt(pieces, p.sq+adv) == -1 {
+		yield(p.sq + adv)
+		if p.sq/8 == PawnRows[p.side] && egAt(pieces, p.sq+2*adv) == -1 {
+			yield(p.sq + 2*adv)
+		}
+	}
+
+	for _, dcol := range []int{-1, 1} {
+		if col := p.sq%8 + dcol; col >= 0 && col < 8 {
+			if j := egAt(pieces, p.sq+adv+dcol); j != -1 && pieces[j].side != p.side {
+				yield(p.sq + adv + dcol)
+			}
+		}
+	}
+}
+
+// Fills predecessor with every position the side could have moved
+// to the current one from without a capture or a promotion and calls yield.
+// Legality of the predecessors is checked by the caller
+func egUnmoves(pieces []egPiece, side Side, predecessor []egPiece, yield func()) {
+	copy(predecessor, pieces)
+	for i, p := range pieces {
+		if p.side != side {
+			continue
+		}
+
+		from := func(source int) {
+			predecessor[i].sq = source
+			yield()
+			predecessor[i].sq = p.sq
+		}
+
+		switch p.fig {
+		case Pawn:
+			back := -AdvDirs[side] * 8
+			source := p.sq + back
+			if source/8 == PromotionRows[getOpponent(side)] || egAt(pieces, source) != -1 {
+				continue
+			}
+			from(source)
+			if source/8 == PawnRows[side]+AdvDirs[side] && egAt(pieces, source+back) == -1 {
+				from(source + back)
+			}
+		case Knight:
+			for _, source := range egKnightSteps[p.sq] {
+				if egAt(pieces, source) == -1 {
+					from(source)
+				}
+			}
+		case King:
+			for _, source := range egKingSteps[p.sq] {
+				if egAt(pieces, source) == -1 {
+					from(source)
+				}
+			}
+		default:
+			for _, dir := range PicDirs[p.fig] {
+				for col, row := p.sq%8+dir[0], p.sq/8+dir[1]; isValidPosition(col, row); col, row = col+dir[0], row+dir[1] {
+					source := row*8 + col
+					if egAt(pieces, source) != -1 {
+						break
+					}
+					from(source)
+				}
+			}
+		}
+	}
+}
+
+// Parses material like "KRvKP" into the material as ParseMaterial returns it
+// and the white and the black pieces in its order
+func parseMaterial(material string) (string, []Piece, error) {
+	name, err := ParseMaterial(material)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var pieces []Piece
+	white, black, _ := strings.Cut(name, "v")
+	for _, letter := range white {
+		pieces = append(pieces, Piece{Figure(letter), White})
+	}
+	for _, letter := range black {
+		pieces = append(pieces, Piece{Figure(letter), Black})
+	}
+
+	if len(pieces) > maxEndgamePieces {
+		return "", nil, fmt.Errorf("material %q has more than %d pieces", material, maxEndgamePieces)
+	}
+
+	if strings.Contains(white, "P") && strings.Contains(black, "P") {
+		return "", nil, fmt.Errorf("material %q has pawns of both sides, en passant isn't supported", material)
+	}
+
+	return name, pieces, nil
+}
+
+// Returns material of the pieces like "KRvKP", the pieces go in the order of "KQRBNP"
+func materialOf(pieces []egPiece) string {
+	var white, black []byte
+	for _, letter := range []byte(materialOrder) {
+		for _, p := range pieces {
+			if byte(p.fig) != letter {
+				continue
+			}
+			if p.side == White {
+				white = append(white, letter)
+			} else {
+				black = append(black, letter)
+			}
+		}
+	}
+	return string(white) + "v" + string(black)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
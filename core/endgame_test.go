@@ -0,0 +1,213 @@
+package core
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+var (
+	endgamesOnce sync.Once
+	endgames     *Endgames
+)
+
+func generatedEndgames(t *testing.T) *Endgames {
+	t.Helper()
+
+	var err error
+	endgamesOnce.Do(func() {
+		endgames = NewEndgames()
+		err = endgames.Generate("KQvK", "KRvK", "KPvK")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return endgames
+}
+
+func TestEndgames_MaxPlies(t *testing.T) {
+	e := generatedEndgames(t)
+
+	// Known longest mates: 10 moves with a queen, 16 with a rook and 28 with a pawn
+	for material, plies := range map[string]int{"KQvK": 20, "KRvK": 32, "KPvK": 56, "KBvK": 0, "KNvK": 0} {
+		if max := e.tables[material].MaxPlies(); max != plies {
+			t.Fatalf("%s: expected %d plies, got %d", material, plies, max)
+		}
+	}
+}
+
+// Every enumerated position is checked against the move generator of the game,
+// its value must follow from the values of the positions after its moves
+func TestEndgames_MatchGame(t *testing.T) {
+	e := generatedEndgames(t)
+
+	for _, material := range []string{"KRvK", "KPvK"} {
+		table := e.tables[material]
+		pieces := make([]egPiece, len(table.pieces))
+
+		for idx := 0; idx < len(table.values); idx += 53 {
+			if table.values[idx] == egIllegal {
+				continue
+			}
+
+			table.decode(idx, pieces)
+			s := Snapshot{turn: egSide(idx)}
+			for _, p := range pieces {
+				s.board[p.sq/8][p.sq%8] = p.Piece
+			}
+			game := s.Game()
+
+			count := 0
+			egMoves(pieces, s.turn, func([]egPiece, bool) { count++ })
+
+			moves := game.LegalMoves()
+			if len(moves) != count {
+				t.Fatalf("%s: %d moves, the game has %d", s.FEN(), count, len(moves))
+			}
+
+			dist, ok := e.Probe(&game)
+			if !ok {
+				t.Fatalf("%s: position isn't found", s.FEN())
+			}
+
+			switch {
+			case game.Outcome() == Checkmate && dist != (MateDistance{Result: -1}):
+				t.Fatalf("%s: checkmate has %v", s.FEN(), dist)
+			case game.Outcome() == Stalemate && dist != (MateDistance{}):
+				t.Fatalf("%s: stalemate has %v", s.FEN(), dist)
+			case game.Outcome() != NoOutcome:
+				continue
+			}
+
+			expected := MateDistance{Result: -1}
+			for _, move := range moves {
+				next := game.clone()
+				if err := next.processMove(move); err != nil {
+					t.Fatal(err)
+				}
+
+				child, ok := e.Probe(&next)
+				if !ok {
+					t.Fatalf("%s: position after %v isn't found", s.FEN(), move)
+				}
+
+				switch {
+				case child.Result == -1 && (expected.Result != 1 || child.Plies+1 < expected.Plies):
+					expected = MateDistance{Result: 1, Plies: child.Plies + 1}
+				case child.Result == 0 && expected.Result == -1:
+					expected = MateDistance{}
+				case child.Result == 1 && expected.Result == -1:
+					expected.Plies = max(expected.Plies, child.Plies+1)
+				}
+			}
+
+			if dist != expected {
+				t.Fatalf("%s: expected %v, got %v", s.FEN(), expected, dist)
+			}
+		}
+	}
+}
+
+func TestEndgames_BestMove(t *testing.T) {
+	e := generatedEndgames(t)
+
+	s, err := ParseFEN("8/8/8/8/8/2k5/8/K6R w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := s.Game()
+
+	dist, ok := e.Probe(&game)
+	if !ok || dist.Result != 1 {
+		t.Fatalf("Unexpected distance %v", dist)
+	}
+
+	for range dist.Plies {
+		move, ok := e.BestMove(&game)
+		if !ok {
+			t.Fatal("Best move isn't found")
+		}
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if game.Outcome() != Checkmate {
+		t.Fatalf("Game isn't mated after %d plies: %s", dist.Plies, game.Snapshot().FEN())
+	}
+
+	// Not in the tables
+	game = NewGame()
+	if _, ok := e.Probe(&game); ok {
+		t.Fatal("Start position was found")
+	}
+}
+
+func TestEndgameTable_ReadWrite(t *testing.T) {
+	table := generatedEndgames(t).tables["KQvK"]
+
+	var buf bytes.Buffer
+	if _, err := table.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Runs of illegal positions and draws compress well
+	if buf.Len() > len(table.values)/4 {
+		t.Fatalf("Table takes %d bytes", buf.Len())
+	}
+
+	read, err := ReadEndgameTable(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.Material() != "KQvK" || !bytes.Equal(int8Bytes(read.values), int8Bytes(table.values)) {
+		t.Fatalf("Read table doesn't match")
+	}
+
+	if _, err := ReadEndgameTable(bytes.NewReader(buf.Bytes()[:buf.Len()/2])); err == nil {
+		t.Fatal("Expected error for a truncated table")
+	}
+
+	dir := t.TempDir()
+	e := NewEndgames()
+	e.tables["KQvK"] = table
+	if err := e.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadEndgames(dir)
+	if err != nil || loaded.table("KvKQ") == nil {
+		t.Fatalf("Table isn't loaded: %v", err)
+	}
+}
+
+func TestEndgames_NonCanonicalMaterial(t *testing.T) {
+	material, pieces, err := parseMaterial("KNBvK")
+	if err != nil || material != "KBNvK" || pieces[1].fig != Bishop || pieces[2].fig != Knight {
+		t.Fatalf("Unexpected material %s with %v: %v", material, pieces, err)
+	}
+
+	// Table is looked up by the material in the order of the pieces, as the positions are probed
+	e := NewEndgames()
+	e.tables["KBNvK"] = &EndgameTable{material: "KBNvK"}
+	if err := e.Generate("KNBvK"); err != nil || len(e.tables) != 1 {
+		t.Fatalf("Unexpected tables %v: %v", e.tables, err)
+	}
+}
+
+func TestEndgames_GenerateErrors(t *testing.T) {
+	for _, material := range []string{"KPvKP", "KQRvKR", "QvK", "KXvK", "KQK"} {
+		if err := NewEndgames().Generate(material); err == nil {
+			t.Fatalf("Expected error for %s", material)
+		}
+	}
+}
+
+func int8Bytes(values []int8) []byte {
+	res := make([]byte, len(values))
+	for i, v := range values {
+		res[i] = byte(v)
+	}
+	return res
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Reason is a machine-readable code of why a move was rejected
+type Reason string
+
+const (
+	ReasonGameEnded         = Reason("game_ended")
+	ReasonInvalidAction     = Reason("invalid_action")
+	ReasonInvalidMove       = Reason("invalid_move")
+	ReasonInvalidAttack     = Reason("invalid_attack")
+	ReasonCellOccupied      = Reason("cell_occupied")
+	ReasonCellEmpty         = Reason("cell_empty")
+	ReasonCellNotEmpty      = Reason("cell_not_empty")
+	ReasonMoveBlocked       = Reason("move_blocked")
+	ReasonKingInCheck       = Reason("king_in_check")
+	ReasonKingNotInPosition = Reason("king_not_in_position")
+	ReasonRookNotInPosition = Reason("rook_not_in_position")
+	ReasonPiecesBetween     = Reason("pieces_between")
+	ReasonCrossoverAttacked = Reason("crossover_attacked")
+	ReasonNoCastlingRights  = Reason("no_castling_rights")
+	ReasonPieceMismatch     = Reason("piece_mismatch")
+	ReasonWrongTurn         = Reason("wrong_turn")
+)
+
+// Messages is the default (english) catalog used by IllegalMoveError.Error.
+// Messages may contain placeholders, see IllegalMoveError.Localize
+var Messages = map[Reason]string{
+	ReasonGameEnded:         "game has ended",
+	ReasonInvalidAction:     "invalid move action: {action}",
+	ReasonInvalidMove:       "invalid move",
+	ReasonInvalidAttack:     "invalid attack",
+	ReasonCellOccupied:      "cell is occupied",
+	ReasonCellEmpty:         "cell is empty",
+	ReasonCellNotEmpty:      "cell is not empty",
+	ReasonMoveBlocked:       "move is blocked",
+	ReasonKingInCheck:       "king is in check",
+	ReasonKingNotInPosition: "king not in position",
+	ReasonRookNotInPosition: "rook not in position",
+	ReasonPiecesBetween:     "pieces between king and rook",
+	ReasonCrossoverAttacked: "crossover cell attacked",
+	ReasonNoCastlingRights:  "castling is not allowed",
+	ReasonPieceMismatch:     "piece doesn't match the board",
+	ReasonWrongTurn:         "not your turn",
+}
+
+// IllegalMoveError describes why a move was rejected
+type IllegalMoveError struct {
+	Reason Reason
+	Move   Move
+	// Piece standing in the way of the move
+	Blocker *Cell
+	// Enemy pieces attacking the king (or the crossover cell for castling)
+	Attackers []Cell
+	// Actual content of the board which doesn't match the move
+	Actual *Cell
+	// Earlier move which forfeited castling rights and its index in Game.Moves()
+	Forfeit      *Move
+	ForfeitIndex int
+}
+
+// ReplayError reports the first illegal move of a replayed game
+type ReplayError struct {
+	// Index of the move in the replayed moves
+	Index int
+	Err   error
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("move %d: %v", e.Index+1, e.Err)
+}
+
+func (e *ReplayError) Unwrap() error {
+	return e.Err
+}
+
+func newIllegalMoveError(reason Reason, move Move) *IllegalMoveError {
+	return &IllegalMoveError{Reason: reason, Move: move, ForfeitIndex: -1}
+}
+
+func (e *IllegalMoveError) Error() string {
+	return e.Localize(Messages)
+}
+
+// Localize renders the message for the error reason from the given catalog.
+// Falls back to the default catalog if the reason is missing.
+// Supported placeholders:
+//
+//	{action}              move action
+//	{piece}, {from}, {to} moved piece (FEN letter) and its squares
+//	{blocker}, {blocker_square}
+//	{attacker}, {attacker_square} first attacking piece
+//	{attackers}           all attacking pieces, e.g. "Qd8 Nf6"
+//	{actual}, {actual_square} board content which doesn't match the move
+//	{forfeit}             1-based index of the move that forfeited castling
+func (e *IllegalMoveError) Localize(messages map[Reason]string) string {
+	msg, ok := messages[e.Reason]
+	if !ok {
+		msg = Messages[e.Reason]
+	}
+	if !strings.Contains(msg, "{") {
+		return msg
+	}
+
+	args := []string{
+		"{action}", string(e.Move.Action),
+		"{piece}", e.Move.Source.Piece.String(),
+		"{from}", e.Move.Source.Position.String(),
+		"{to}", e.Move.Target.Position.String(),
+	}
+
+	if e.Blocker != nil {
+		args = append(args, "{blocker}", e.Blocker.Piece.String(), "{blocker_square}", e.Blocker.Position.String())
+	}
+
+	if len(e.Attackers) > 0 {
+		attackers := make([]string, 0, len(e.Attackers))
+		for _, atk := range e.Attackers {
+			attackers = append(attackers, atk.Piece.String()+atk.Position.String())
+		}
+		args = append(args,
+			"{attacker}", e.Attackers[0].Piece.String(),
+			"{attacker_square}", e.Attackers[0].Position.String(),
+			"{attackers}", strings.Join(attackers, " "),
+		)
+	}
+
+	if e.Actual != nil {
+		args = append(args, "{actual}", e.Actual.Piece.String(), "{actual_square}", e.Actual.Position.String())
+	}
+
+	if e.Forfeit != nil {
+		args = append(args, "{forfeit}", strconv.Itoa(e.ForfeitIndex+1))
+	}
+
+	return strings.NewReplacer(args...).Replace(msg)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIllegalMoveError_Blocker(t *testing.T) {
+	game := NewGame()
+
+	move := Move{
+		Source: Cell{Piece{'Q', White}, Position{0, 3}},
+		Target: Cell{Position: Position{2, 3}},
+		Action: Movement,
+	}
+
+	var illegal *IllegalMoveError
+	if err := game.processMove(move); !errors.As(err, &illegal) {
+		t.Fatalf("expected IllegalMoveError, got %v", err)
+	}
+
+	if illegal.Reason != ReasonMoveBlocked {
+		t.Fatalf("unexpected reason %s", illegal.Reason)
+	}
+
+	if illegal.Blocker == nil || *illegal.Blocker != (Cell{Piece{'P', White}, Position{1, 3}}) {
+		t.Fatalf("unexpected blocker %v", illegal.Blocker)
+	}
+}
+
+func TestIllegalMoveError_Attackers(t *testing.T) {
+	game := NewGame()
+	// Knight shields the king from the rook
+	game.Board[1][4] = Piece{'N', White}
+	game.Board[4][4] = Piece{'R', Black}
+
+	move := Move{
+		Source: Cell{Piece{'N', White}, Position{1, 4}},
+		Target: Cell{Position: Position{2, 2}},
+		Action: Movement,
+	}
+
+	var illegal *IllegalMoveError
+	if err := game.processMove(move); !errors.As(err, &illegal) {
+		t.Fatalf("expected IllegalMoveError, got %v", err)
+	}
+
+	if illegal.Reason != ReasonKingInCheck {
+		t.Fatalf("unexpected reason %s", illegal.Reason)
+	}
+
+	if len(illegal.Attackers) != 1 || illegal.Attackers[0] != (Cell{Piece{'R', Black}, Position{4, 4}}) {
+		t.Fatalf("unexpected attackers %v", illegal.Attackers)
+	}
+}
+
+func TestIllegalMoveError_Forfeit(t *testing.T) {
+	game := NewGame()
+	playMoves(t, &game, "g1f3", "g8f6", "h1g1", "f6g8", "g1h1", "g8f6")
+
+	rookMove := Move{
+		Source: Cell{Piece{'R', White}, Position{0, 7}},
+		Target: Cell{Position: Position{0, 6}},
+		Action: Movement,
+	}
+
+	var illegal *IllegalMoveError
+	if err := game.processCastling(Move{Action: KingCastling}); !errors.As(err, &illegal) {
+		t.Fatalf("expected IllegalMoveError, got %v", err)
+	}
+
+	if illegal.Reason != ReasonRookNotInPosition {
+		t.Fatalf("unexpected reason %s", illegal.Reason)
+	}
+
+	if illegal.Forfeit == nil || *illegal.Forfeit != rookMove || illegal.ForfeitIndex != 2 {
+		t.Fatalf("unexpected forfeit %v at %d", illegal.Forfeit, illegal.ForfeitIndex)
+	}
+}
+
+func TestIllegalMoveError_Localize(t *testing.T) {
+	err := newIllegalMoveError(ReasonMoveBlocked, Move{
+		Source: Cell{Piece{'Q', White}, Position{0, 3}},
+		Target: Cell{Position: Position{2, 3}},
+		Action: Movement,
+	})
+	err.Blocker = &Cell{Piece{'P', Black}, Position{1, 3}}
+
+	catalog := map[Reason]string{
+		ReasonMoveBlocked: "{piece} {from}-{to}: {blocker} на {blocker_square}",
+	}
+	if msg := err.Localize(catalog); msg != "Q d1-d3: p на d2" {
+		t.Fatalf("unexpected message '%s'", msg)
+	}
+
+	// Missing reasons fall back to the default catalog
+	if msg := err.Localize(nil); msg != "move is blocked" {
+		t.Fatalf("unexpected message '%s'", msg)
+	}
+}
@@ -0,0 +1,28 @@
+package core_test
+
+import (
+	"fmt"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+func ExampleGame_Play() {
+	game := core.NewGame()
+
+	from, _ := core.ParseSquare("e2")
+	to, _ := core.ParseSquare("e4")
+	pawn, _ := core.NewPiece(core.Pawn, core.White)
+
+	move := core.Move{
+		Source: core.Cell{Piece: pawn, Position: from},
+		Target: core.Cell{Position: to},
+		Action: core.Movement,
+	}
+	if err := game.Play(move); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(game.Board[to.Row()][to.Col()].Unicode(), to)
+	// Output: ♙ e4
+}
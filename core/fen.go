@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseFEN parses a position in Forsyth-Edwards notation.
+// Move clocks are optional, zero and 1 by default
+func ParseFEN(fen string) (Snapshot, error) {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 || len(fields) > 6 {
+		return Snapshot{}, fmt.Errorf("invalid FEN: %q", fen)
+	}
+
+	var s Snapshot
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return Snapshot{}, fmt.Errorf("invalid FEN board: %q", fields[0])
+	}
+
+	for i, rank := range ranks {
+		row, col := 7-i, 0
+		for _, c := range rank {
+			if c >= '1' && c <= '8' {
+				col += int(c - '0')
+				continue
+			}
+
+			pic, err := ParsePiece(c)
+			if err != nil || col > 7 {
+				return Snapshot{}, fmt.Errorf("invalid FEN board: %q", fields[0])
+			}
+			s.board[row][col] = pic
+			col++
+		}
+
+		if col != 8 {
+			return Snapshot{}, fmt.Errorf("invalid FEN board: %q", fields[0])
+		}
+	}
+
+	if len(fields[1]) != 1 || !Side(fields[1][0]).IsValid() {
+		return Snapshot{}, fmt.Errorf("invalid FEN turn: %q", fields[1])
+	}
+	s.turn = Side(fields[1][0])
+
+	castling, err := parseCastling(fields[2])
+	if err != nil {
+		return Snapshot{}, err
+	}
+	s.castling = castling
+
+	if fields[3] != "-" {
+		square, err := ParseSquare(fields[3])
+		epRows := map[Side]int{White: 5, Black: 2}
+		if err != nil || square.row != epRows[s.turn] {
+			return Snapshot{}, fmt.Errorf("invalid FEN en passant: %q", fields[3])
+		}
+		// FEN gives the square behind the pawn
+		s.enpassant = Position{row: square.row - AdvDirs[s.turn], col: square.col}
+	}
+
+	if len(fields) > 4 {
+		halfmove, err := strconv.Atoi(fields[4])
+		if err != nil || halfmove < 0 {
+			return Snapshot{}, fmt.Errorf("invalid FEN halfmove clock: %q", fields[4])
+		}
+		s.halfmove = halfmove
+	}
+
+	if len(fields) > 5 {
+		fullmove, err := strconv.Atoi(fields[5])
+		if err != nil || fullmove < 0 {
+			return Snapshot{}, fmt.Errorf("invalid FEN fullmove number: %q", fields[5])
+		}
+		// Some writers start the moves from 0
+		s.fullmove = max(fullmove-1, 0)
+	}
+
+	return s, nil
+}
+
+// Returns the position in Forsyth-Edwards notation
+func (s Snapshot) FEN() string {
+	var sb strings.Builder
+	for row := 7; row >= 0; row-- {
+		empty := 0
+		for _, pic := range s.board[row] {
+			if pic == Empty {
+				empty++
+				continue
+			}
+
+			if empty > 0 {
+				fmt.Fprint(&sb, empty)
+				empty = 0
+			}
+			sb.WriteString(pic.String())
+		}
+
+		if empty > 0 {
+			fmt.Fprint(&sb, empty)
+		}
+		if row > 0 {
+			sb.WriteByte('/')
+		}
+	}
+
+	enpassant := "-"
+	if pos, ok := s.EnPassant(); ok {
+		enpassant = Position{row: pos.row + AdvDirs[s.turn], col: pos.col}.String()
+	}
+
+	fmt.Fprintf(&sb, " %c %s %s %d %d", s.turn, s.castlingString(), enpassant, s.HalfmoveClock(), s.FullmoveNumber())
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package core
+
+import "testing"
+
+func TestParseFEN(t *testing.T) {
+	start := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	s, err := ParseFEN(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s != InitialSnapshot() {
+		t.Fatalf("Unexpected snapshot %s", s.FEN())
+	}
+
+	game := NewGame()
+	playMoves(t, &game, "e2e4", "c7c5", "e4e5", "d7d5")
+
+	fen := game.Snapshot().FEN()
+	if fen != "rnbqkbnr/pp2pppp/8/2ppP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3" {
+		t.Fatalf("Unexpected FEN %s", fen)
+	}
+
+	if s, err := ParseFEN(fen); err != nil || s != game.Snapshot() {
+		t.Fatalf("FEN %s parsed as %s: %v", fen, s.FEN(), err)
+	}
+}
+
+func TestParseFEN_Clocks(t *testing.T) {
+	fen := "4k3/8/8/8/8/8/4P3/4K1N1 b - - 12 40"
+	s, err := ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.HalfmoveClock() != 12 || s.FullmoveNumber() != 40 || s.FEN() != fen {
+		t.Fatalf("Unexpected clocks %d %d", s.HalfmoveClock(), s.FullmoveNumber())
+	}
+
+	// Clocks of the start position are continued by the game
+	game := s.Game()
+	playMoves(t, &game, "e8d8", "g1f3")
+	if game.HalfmoveClock() != 14 || game.Snapshot().FEN() != "3k4/8/8/8/8/5N2/4P3/4K3 b - - 14 41" {
+		t.Fatalf("Unexpected clock %d and FEN %s", game.HalfmoveClock(), game.Snapshot().FEN())
+	}
+
+	playMoves(t, &game, "d8c8", "e2e4")
+	if game.HalfmoveClock() != 0 || game.Snapshot().FullmoveNumber() != 42 {
+		t.Fatalf("Unexpected clock %d and FEN %s", game.HalfmoveClock(), game.Snapshot().FEN())
+	}
+
+	// Clocks are optional
+	if s, err := ParseFEN("4k3/8/8/8/8/8/4P3/4K1N1 b - -"); err != nil || s.HalfmoveClock() != 0 || s.FullmoveNumber() != 1 {
+		t.Fatalf("Unexpected snapshot %s: %v", s.FEN(), err)
+	}
+}
+
+func TestParseFEN_Errors(t *testing.T) {
+	for _, fen := range []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq -",
+		"rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQxq -",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e3",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 x",
+	} {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Fatalf("Expected error for FEN %q", fen)
+		}
+	}
+}
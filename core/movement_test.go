@@ -49,6 +49,33 @@ func TestProcessMovement_MoveIsBlocked(t *testing.T) {
 	}
 }
 
+func TestProcessMovement_InvalidMove(t *testing.T) {
+	game := NewGame()
+
+	// Free the cells in front of the pieces
+	game.Board[1] = make([]Piece, 8)
+
+	// Only pawns move straight forward by the pawn directions
+	moves := []Move{
+		{
+			Source: Cell{Piece{'N', White}, Position{0, 1}},
+			Target: Cell{Position: Position{1, 1}},
+			Action: Movement,
+		},
+		{
+			Source: Cell{Piece{'B', White}, Position{0, 2}},
+			Target: Cell{Position: Position{1, 2}},
+			Action: Movement,
+		},
+	}
+
+	for _, move := range moves {
+		if err := game.processMovement(move); err == nil || err.Error() != "invalid move" {
+			t.Fatalf("Error '%v' for move %v", err, move)
+		}
+	}
+}
+
 func TestProcessCapture_CellIsOccupied(t *testing.T) {
 	game := NewGame()
 
@@ -182,7 +209,7 @@ func TestProcessEnpassant(t *testing.T) {
 	// Add Pre-e.p. move
 	preEpMove := Move{
 		Source: Cell{Piece{'P', White}, Position{1, 0}},
-		Target: Cell{Piece{'P', White}, enemyPawn},
+		Target: Cell{Position: enemyPawn},
 		Action: Movement,
 	}
 	if err := game.processMove(preEpMove); err != nil {
@@ -265,3 +292,67 @@ func TestProcessPromotion(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessCapture_Slider(t *testing.T) {
+	game := NewGame()
+	game.Board[1][0] = Empty
+
+	move := Move{
+		Source: Cell{Piece{'R', White}, Position{0, 0}},
+		Target: Cell{Piece{'P', Black}, Position{6, 0}},
+		Action: Capture,
+	}
+
+	if err := game.processCapture(move); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessMovement_OffLine(t *testing.T) {
+	game := NewGame()
+	game.Board[1] = make([]Piece, 8)
+
+	// Sliding pieces don't jump like knights
+	move := Move{
+		Source: Cell{Piece{'Q', White}, Position{0, 3}},
+		Target: Cell{Position: Position{2, 4}},
+		Action: Movement,
+	}
+
+	if err := game.processMovement(move); err == nil || err.Error() != "invalid move" {
+		t.Fatalf("Error '%v' for move %v", err, move)
+	}
+}
+
+func TestProcessMovement_OccupiedTarget(t *testing.T) {
+	game := NewGame()
+	game.Board[2][2] = Piece{'P', Black}
+
+	// Only captures take pieces
+	move := Move{
+		Source: Cell{Piece{'N', White}, Position{0, 1}},
+		Target: Cell{Position: Position{2, 2}},
+		Action: Movement,
+	}
+
+	if err := game.processMovement(move); err == nil || err.Error() != "move is blocked" {
+		t.Fatalf("Error '%v' for move %v", err, move)
+	}
+}
+
+func TestProcessMove_ShieldedKing(t *testing.T) {
+	game := NewGame()
+	game.Board[1][4], game.Board[6][4] = Empty, Empty
+	// Black pawn shields the white king from the black rook
+	game.Board[3][4], game.Board[5][4] = Piece{'P', Black}, Piece{'R', Black}
+
+	move := Move{
+		Source: Cell{Piece{'P', White}, Position{1, 0}},
+		Target: Cell{Position: Position{2, 0}},
+		Action: Movement,
+	}
+
+	if err := game.processMove(move); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var unicodePieces = map[Piece]string{
+	{King, White}: "♔", {Queen, White}: "♕", {Rook, White}: "♖",
+	{Bishop, White}: "♗", {Knight, White}: "♘", {Pawn, White}: "♙",
+	{King, Black}: "♚", {Queen, Black}: "♛", {Rook, Black}: "♜",
+	{Bishop, Black}: "♝", {Knight, Black}: "♞", {Pawn, Black}: "♟",
+}
+
+func (f Figure) IsValid() bool {
+	switch f {
+	case Pawn, Knight, Bishop, Rook, Queen, King:
+		return true
+	}
+	return false
+}
+
+func (s Side) IsValid() bool {
+	return s == White || s == Black
+}
+
+func NewPiece(fig Figure, side Side) (Piece, error) {
+	if !fig.IsValid() {
+		return Empty, fmt.Errorf("invalid figure: %q", rune(fig))
+	}
+
+	if !side.IsValid() {
+		return Empty, fmt.Errorf("invalid side: %q", rune(side))
+	}
+
+	return Piece{fig, side}, nil
+}
+
+// ParsePiece parses FEN letter of a piece, uppercase for white and lowercase for black
+func ParsePiece(letter rune) (Piece, error) {
+	// Wider runes would wrap around to the letters of the figures
+	if letter > unicode.MaxASCII {
+		return Empty, fmt.Errorf("invalid figure: %q", letter)
+	}
+
+	side := White
+	if letter >= 'a' && letter <= 'z' {
+		side = Black
+		letter -= 'a' - 'A'
+	}
+
+	return NewPiece(Figure(letter), side)
+}
+
+func (p Piece) Figure() Figure {
+	return p.fig
+}
+
+func (p Piece) Side() Side {
+	return p.side
+}
+
+// Returns FEN letter of the piece, empty string for an empty cell
+func (p Piece) String() string {
+	if p == Empty {
+		return ""
+	}
+	if p.side == Black {
+		return strings.ToLower(string(p.fig))
+	}
+	return string(p.fig)
+}
+
+// Returns chess symbol of the piece, empty string for an empty cell
+func (p Piece) Unicode() string {
+	return unicodePieces[p]
+}
+
+// Row 0 is the first rank, col 0 is the "a" file
+func NewPosition(row, col int) (Position, error) {
+	if !isValidPosition(col, row) {
+		return Position{}, fmt.Errorf("invalid position: row %d, col %d", row, col)
+	}
+	return Position{row: row, col: col}, nil
+}
+
+// ParseSquare parses square in algebraic notation, e.g. "e4"
+func ParseSquare(square string) (Position, error) {
+	if len(square) != 2 {
+		return Position{}, fmt.Errorf("invalid square: %q", square)
+	}
+
+	col, row := int(square[0])-'a', int(square[1])-'1'
+	if !isValidPosition(col, row) {
+		return Position{}, fmt.Errorf("invalid square: %q", square)
+	}
+
+	return Position{row: row, col: col}, nil
+}
+
+func (p Position) Row() int {
+	return p.row
+}
+
+func (p Position) Col() int {
+	return p.col
+}
+
+// Returns square in algebraic notation, "-" for a position outside the board
+func (p Position) String() string {
+	if !isValidPosition(p.col, p.row) {
+		return "-"
+	}
+	return fmt.Sprintf("%c%d", 'a'+p.col, p.row+1)
+}
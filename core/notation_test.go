@@ -0,0 +1,61 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestParseSquare(t *testing.T) {
+	for row := range 8 {
+		for col := range 8 {
+			pos := Position{row: row, col: col}
+			parsed, err := ParseSquare(pos.String())
+			if err != nil || parsed != pos {
+				t.Fatalf("Square '%s' parsed as %v: %v", pos, parsed, err)
+			}
+		}
+	}
+
+	if pos, _ := ParseSquare("e4"); pos != (Position{3, 4}) {
+		t.Fatalf("Unexpected position %v", pos)
+	}
+
+	for _, square := range []string{"", "e", "e9", "i1", "E4", "e10"} {
+		if _, err := ParseSquare(square); err == nil {
+			t.Fatalf("Expected error for square '%s'", square)
+		}
+	}
+}
+
+func TestParsePiece(t *testing.T) {
+	for _, letter := range "PNBRQKpnbrqk" {
+		pic, err := ParsePiece(letter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if pic.String() != string(letter) {
+			t.Fatalf("Piece '%c' formatted as '%s'", letter, pic)
+		}
+	}
+
+	for _, letter := range "xX1 ŐŎ♔" {
+		if _, err := ParsePiece(letter); err == nil {
+			t.Fatalf("Expected error for '%c'", letter)
+		}
+	}
+}
+
+func TestNewPiece(t *testing.T) {
+	if _, err := NewPiece(Figure('X'), White); err == nil {
+		t.Fatalf("Expected error for invalid figure")
+	}
+
+	if _, err := NewPiece(Queen, Side('x')); err == nil {
+		t.Fatalf("Expected error for invalid side")
+	}
+
+	pic, err := NewPiece(Queen, Black)
+	if err != nil || pic.Figure() != Queen || pic.Side() != Black || pic.Unicode() != "♛" {
+		t.Fatalf("Unexpected piece %v: %v", pic, err)
+	}
+}
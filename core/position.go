@@ -0,0 +1,430 @@
+package core
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Violation is a machine-readable code of why a position can't occur in a game
+type Violation string
+
+const (
+	ViolationKingCount       = Violation("king_count")
+	ViolationKingsAdjacent   = Violation("kings_adjacent")
+	ViolationPawnRank        = Violation("pawn_rank")
+	ViolationOpponentInCheck = Violation("opponent_in_check")
+	ViolationPieceCount      = Violation("piece_count")
+	ViolationCastling        = Violation("castling")
+	ViolationEnPassant       = Violation("en_passant")
+	// Found by the retro analysis only, see ValidateReachable
+	ViolationImpossibleCheck = Violation("impossible_check")
+	ViolationPawnCaptures    = Violation("pawn_captures")
+	ViolationNoLastMove      = Violation("no_last_move")
+)
+
+// ViolationMessages is the default (english) catalog used by PositionError.Error
+var ViolationMessages = map[Violation]string{
+	ViolationKingCount:       "side must have exactly one king",
+	ViolationKingsAdjacent:   "kings are adjacent",
+	ViolationPawnRank:        "pawn on the first or last rank",
+	ViolationOpponentInCheck: "side not to move is in check",
+	ViolationPieceCount:      "too many pieces for the promotions",
+	ViolationCastling:        "castling rights without king or rook in position",
+	ViolationEnPassant:       "en passant without a pawn which has moved 2 cells",
+	ViolationImpossibleCheck: "check can't be given by one move",
+	ViolationPawnCaptures:    "pawns need more captures than pieces are missing",
+	ViolationNoLastMove:      "side not to move has no last move",
+}
+
+var sideNames = map[Side]string{White: "white", Black: "black"}
+
+// PositionError describes why a position can't occur in a game
+type PositionError struct {
+	Violation Violation
+	// Side the violation belongs to, zero if it belongs to the whole position
+	Side Side
+	// Pieces causing the violation
+	Cells []Cell
+}
+
+func (e *PositionError) Error() string {
+	msg := ViolationMessages[e.Violation]
+	if e.Side != 0 {
+		msg = sideNames[e.Side] + ": " + msg
+	}
+
+	if len(e.Cells) > 0 {
+		cells := make([]string, 0, len(e.Cells))
+		for _, cell := range e.Cells {
+			cells = append(cells, cell.Piece.String()+cell.Position.String())
+		}
+		msg += fmt.Sprintf(" (%s)", strings.Join(cells, " "))
+	}
+
+	return msg
+}
+
+// ValidatePosition returns every violation of the rules found in the position,
+// nil if there are none
+func ValidatePosition(s Snapshot) []*PositionError {
+	var res []*PositionError
+	add := func(violation Violation, side Side, cells []Cell) {
+		res = append(res, &PositionError{Violation: violation, Side: side, Cells: cells})
+	}
+
+	kings := map[Side][]Cell{}
+	for _, side := range []Side{White, Black} {
+		var pawns []Cell
+		counts := map[Figure]int{}
+		// Bishops on the dark and the light cells
+		var bishops [2]int
+
+		for row := range s.board {
+			for col, pic := range s.board[row] {
+				if pic.side != side {
+					continue
+				}
+
+				counts[pic.fig]++
+				cell := Cell{pic, Position{row: row, col: col}}
+				switch pic.fig {
+				case King:
+					kings[side] = append(kings[side], cell)
+				case Pawn:
+					if row == 0 || row == 7 {
+						pawns = append(pawns, cell)
+					}
+				case Bishop:
+					bishops[(row+col)%2]++
+				}
+			}
+		}
+
+		if len(kings[side]) != 1 {
+			add(ViolationKingCount, side, kings[side])
+		}
+
+		if len(pawns) > 0 {
+			add(ViolationPawnRank, side, pawns)
+		}
+
+		// Every piece above the initial ones is promoted from a missing pawn
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+		promoted := max(0, counts[Queen]-1) + max(0, counts[Rook]-2) + max(0, counts[Knight]-2) +
+			max(0, bishops[0]-1) + max(0, bishops[1]-1)
+		if total > 16 || counts[Pawn] > 8 || promoted > 8-counts[Pawn] {
+			add(ViolationPieceCount, side, nil)
+		}
+
+		if !s.validCastling(side) {
+			add(ViolationCastling, side, nil)
+		}
+	}
+
+	if len(kings[White]) == 1 && len(kings[Black]) == 1 {
+		white, black := kings[White][0], kings[Black][0]
+		if abs(white.row-black.row) <= 1 && abs(white.col-black.col) <= 1 {
+			add(ViolationKingsAdjacent, 0, []Cell{white, black})
+		}
+
+		opponent := getOpponent(s.turn)
+		if attackers := boardAttackers(s.board, kings[opponent][0].Position, s.turn); len(attackers) > 0 {
+			add(ViolationOpponentInCheck, opponent, attackers)
+		}
+	}
+
+	if pos, ok := s.EnPassant(); ok && !s.validEnPassant(pos) {
+		add(ViolationEnPassant, 0, nil)
+	}
+
+	return res
+}
+
+// ValidateReachable validates the position and if it follows the rules,
+// looks for the moves which could have led to it.
+// A position passing the check may still be unreachable from the initial position
+func ValidateReachable(s Snapshot) []*PositionError {
+	if res := ValidatePosition(s); res != nil {
+		return res
+	}
+
+	var res []*PositionError
+	add := func(violation Violation, side Side, cells []Cell) {
+		res = append(res, &PositionError{Violation: violation, Side: side, Cells: cells})
+	}
+
+	// One move checks by a piece, or by two pieces if one is discovered
+	king := s.king(s.turn)
+	checkers := boardAttackers(s.board, king, getOpponent(s.turn))
+	if len(checkers) > 2 || len(checkers) == 2 && !isSlider(checkers[0].fig) && !isSlider(checkers[1].fig) {
+		add(ViolationImpossibleCheck, s.turn, checkers)
+	}
+
+	for _, side := range []Side{White, Black} {
+		if !s.enoughCaptures(side) {
+			add(ViolationPawnCaptures, side, nil)
+		}
+	}
+
+	if pos, ok := s.EnPassant(); ok {
+		// The last move is the double step of the pawn
+		prev := s.board
+		prev[pos.row][pos.col] = Empty
+		prev[pos.row+2*AdvDirs[s.turn]][pos.col] = Piece{Pawn, getOpponent(s.turn)}
+		if len(boardAttackers(prev, king, getOpponent(s.turn))) > 0 {
+			add(ViolationEnPassant, 0, nil)
+		}
+	} else if !s.hasLastMove() {
+		add(ViolationNoLastMove, getOpponent(s.turn), nil)
+	}
+
+	return res
+}
+
+func (s Snapshot) validCastling(side Side) bool {
+	row := map[Side]int{White: 0, Black: 7}[side]
+	rooks := map[Action]int{KingCastling: 7, QueenCastling: 0}
+
+	for action, col := range rooks {
+		if s.castling&castlingRight(side, action) == 0 {
+			continue
+		}
+		if s.board[row][4] != (Piece{King, side}) || s.board[row][col] != (Piece{Rook, side}) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks that the pawn which can be captured en passant has just moved 2 cells
+func (s Snapshot) validEnPassant(pos Position) bool {
+	dir := AdvDirs[s.turn]
+	epRows := map[Side]int{White: 4, Black: 3}
+
+	return pos.row == epRows[s.turn] && s.board[pos.row][pos.col] == (Piece{Pawn, getOpponent(s.turn)}) &&
+		s.board[pos.row+dir][pos.col] == Empty && s.board[pos.row+2*dir][pos.col] == Empty
+}
+
+func (s Snapshot) king(side Side) Position {
+	for row := range s.board {
+		for col, pic := range s.board[row] {
+			if pic == (Piece{King, side}) {
+				return Position{row: row, col: col}
+			}
+		}
+	}
+	return Position{}
+}
+
+// Checks that the opponent lost enough pieces for the pawns of the side
+// to reach their files, every file change is a capture
+func (s Snapshot) enoughCaptures(side Side) bool {
+	var pawns []Position
+	missing := 16
+	for row := range s.board {
+		for col, pic := range s.board[row] {
+			switch {
+			case pic == (Piece{Pawn, side}):
+				pawns = append(pawns, Position{row: row, col: col})
+			case pic.side == getOpponent(side):
+				missing--
+			}
+		}
+	}
+
+	// Fewest captures by the pawns taken from the initial files of the mask
+	const unreachable = 1 << 10
+	captures := make([]int, 1<<8)
+	for mask := 1; mask < len(captures); mask++ {
+		captures[mask] = unreachable
+		i := bits.OnesCount(uint(mask)) - 1
+		if i >= len(pawns) {
+			continue
+		}
+
+		// Pawn makes a capture at most every move
+		p := pawns[i]
+		moves := abs(p.row - PawnRows[side])
+		for file := range 8 {
+			if mask&(1<<file) != 0 && abs(p.col-file) <= moves {
+				captures[mask] = min(captures[mask], captures[mask&^(1<<file)]+abs(p.col-file))
+			}
+		}
+	}
+
+	least := unreachable
+	for mask, count := range captures {
+		if bits.OnesCount(uint(mask)) == len(pawns) {
+			least = min(least, count)
+		}
+	}
+	return least <= missing
+}
+
+// Checks if the side not to move has a move leading to the position,
+// which doesn't leave the side to move in check before it
+func (s Snapshot) hasLastMove() bool {
+	side := getOpponent(s.turn)
+	king := s.king(s.turn)
+	dir := AdvDirs[side]
+
+	// Captured piece is restored only if the side to move has lost any,
+	// its figure doesn't matter as any piece blocks the lines
+	count := 0
+	for row := range s.board {
+		for _, pic := range s.board[row] {
+			if pic.side == s.turn {
+				count++
+			}
+		}
+	}
+	captured := Piece{Knight, s.turn}
+
+	// Checks the position with the piece moved back from the cell to the empty cell,
+	// the captured piece is restored on its cell if given
+	unmove := func(from, to Position, pic Piece, restored Position, captured Piece) bool {
+		if !isValidPosition(to.col, to.row) || s.board[to.row][to.col] != Empty {
+			return false
+		}
+
+		prev := s.board
+		prev[from.row][from.col] = Empty
+		prev[to.row][to.col] = pic
+		if captured != Empty {
+			if count == 16 || prev[restored.row][restored.col] != Empty {
+				return false
+			}
+			prev[restored.row][restored.col] = captured
+		}
+
+		return len(boardAttackers(prev, king, side)) == 0
+	}
+
+	epRows := map[Side]int{White: 5, Black: 2}
+	for row := range s.board {
+		for col, pic := range s.board[row] {
+			if pic.side != side {
+				continue
+			}
+			from := Position{row: row, col: col}
+
+			if pic.fig == Pawn {
+				back := Position{row: row - dir, col: col}
+				if back.row != PromotionRows[s.turn] && unmove(from, back, pic, from, Empty) {
+					return true
+				}
+
+				// Double step from the initial row
+				if row-2*dir == PawnRows[side] && s.board[row-dir][col] == Empty &&
+					unmove(from, Position{row: row - 2*dir, col: col}, pic, from, Empty) {
+					return true
+				}
+
+				for _, dcol := range []int{-1, 1} {
+					back := Position{row: row - dir, col: col + dcol}
+					if back.row == PromotionRows[s.turn] {
+						continue
+					}
+					if unmove(from, back, pic, from, captured) {
+						return true
+					}
+					// Pawn captured en passant stood behind the cell
+					if row == epRows[side] && unmove(from, back, pic, Position{row: row - dir, col: col}, Piece{Pawn, s.turn}) {
+						return true
+					}
+				}
+				continue
+			}
+
+			for _, to := range boardReach(s.board, from, pic.fig) {
+				if unmove(from, to, pic, from, Empty) || unmove(from, to, pic, from, captured) {
+					return true
+				}
+			}
+
+			// Promoted piece moves back as a pawn
+			if pic.fig != King && row == PromotionRows[side] {
+				if unmove(from, Position{row: row - dir, col: col}, Piece{Pawn, side}, from, Empty) {
+					return true
+				}
+				for _, dcol := range []int{-1, 1} {
+					if unmove(from, Position{row: row - dir, col: col + dcol}, Piece{Pawn, side}, from, captured) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	// Castling moves the rook back as well
+	row := map[Side]int{White: 0, Black: 7}[side]
+	for _, cols := range [][4]int{{6, 5, 4, 7}, {2, 3, 4, 0}} {
+		kingTo, rookTo, kingFrom, rookFrom := cols[0], cols[1], cols[2], cols[3]
+		if s.board[row][kingTo] != (Piece{King, side}) || s.board[row][rookTo] != (Piece{Rook, side}) ||
+			s.board[row][kingFrom] != Empty || s.board[row][rookFrom] != Empty || rookFrom == 0 && s.board[row][1] != Empty {
+			continue
+		}
+
+		prev := s.board
+		prev[row][kingTo], prev[row][rookTo] = Empty, Empty
+		prev[row][kingFrom], prev[row][rookFrom] = Piece{King, side}, Piece{Rook, side}
+		if len(boardAttackers(prev, king, side)) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns empty cells the piece reaches from the cell
+func boardReach(board [8][8]Piece, from Position, fig Figure) []Position {
+	var res []Position
+	for _, dir := range PicDirs[fig] {
+		col, row := from.col+dir[0], from.row+dir[1]
+		for ; isValidPosition(col, row) && board[row][col] == Empty; col, row = col+dir[0], row+dir[1] {
+			res = append(res, Position{row: row, col: col})
+			if !isSlider(fig) {
+				break
+			}
+		}
+	}
+	return res
+}
+
+// Returns every piece of the side attacking the cell
+func boardAttackers(board [8][8]Piece, cell Position, side Side) []Cell {
+	var res []Cell
+	for _, fig := range []Figure{Queen, Rook, Bishop, Knight, King} {
+		for _, dir := range PicDirs[fig] {
+			col, row := cell.col+dir[0], cell.row+dir[1]
+			for ; isValidPosition(col, row); col, row = col+dir[0], row+dir[1] {
+				if pic := board[row][col]; pic != Empty {
+					if pic == (Piece{fig, side}) {
+						res = append(res, Cell{pic, Position{row: row, col: col}})
+					}
+					break
+				}
+				if !isSlider(fig) {
+					break
+				}
+			}
+		}
+	}
+
+	for _, dir := range PawnAtkDirs[side] {
+		col, row := cell.col-dir[0], cell.row-dir[1]
+		if isValidPosition(col, row) && board[row][col] == (Piece{Pawn, side}) {
+			res = append(res, Cell{board[row][col], Position{row: row, col: col}})
+		}
+	}
+
+	return res
+}
+
+func isSlider(fig Figure) bool {
+	return fig == Queen || fig == Rook || fig == Bishop
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func violations(errs []*PositionError) []Violation {
+	var res []Violation
+	for _, err := range errs {
+		res = append(res, err.Violation)
+	}
+	return res
+}
+
+func TestValidatePosition(t *testing.T) {
+	tests := []struct {
+		fen        string
+		violations []Violation
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", nil},
+		{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", nil},
+		{"4k3/8/8/8/8/8/8/4K3 b - - 0 1", nil},
+		{"8/8/8/8/8/8/8/4K3 w - - 0 1", []Violation{ViolationKingCount}},
+		{"4k3/8/8/8/8/8/8/3KK3 w - - 0 1", []Violation{ViolationKingCount}},
+		{"8/8/8/8/8/8/8/3Kk3 w - - 0 1", []Violation{ViolationKingsAdjacent, ViolationOpponentInCheck}},
+		{"P3k3/8/8/8/8/8/8/4K2p w - - 0 1", []Violation{ViolationPawnRank, ViolationPawnRank}},
+		{"4k3/8/8/8/8/8/8/4R1K1 w - - 0 1", []Violation{ViolationOpponentInCheck}},
+		{"4k3/8/8/8/8/8/PPPPPPPP/QQ2K3 w - - 0 1", []Violation{ViolationPieceCount}},
+		// Both bishops on dark cells
+		{"4k3/8/8/8/8/8/PPPPPPPP/B1B1K3 w - - 0 1", []Violation{ViolationPieceCount}},
+		{"4k3/8/8/8/8/8/PPPPPPP1/B1B1K3 w - - 0 1", nil},
+		{"4k3/8/8/8/8/8/8/4K3 w K - 0 1", []Violation{ViolationCastling}},
+		{"r3k3/8/8/8/8/8/8/4K3 w q - 0 1", nil},
+		{"4k3/8/8/8/8/8/8/4K3 w - e6 0 1", []Violation{ViolationEnPassant}},
+		{"4k3/4p3/8/4p3/8/8/8/4K3 w - e6 0 1", []Violation{ViolationEnPassant}},
+		{"K7/8/8/8/8/8/8/7P w Kk - 0 1", []Violation{ViolationPawnRank, ViolationCastling, ViolationKingCount, ViolationCastling}},
+	}
+
+	for _, test := range tests {
+		s, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := violations(ValidatePosition(s)); !slices.Equal(got, test.violations) {
+			t.Fatalf("%s: expected %v, got %v", test.fen, test.violations, got)
+		}
+	}
+}
+
+func TestValidateReachable(t *testing.T) {
+	tests := []struct {
+		fen        string
+		violations []Violation
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", nil},
+		{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", nil},
+		// Discovered double check
+		{"4k3/8/3N4/8/8/8/4R3/4K3 b - - 0 1", nil},
+		// No move of white gives all three checks
+		{"4k3/8/3N4/1B6/8/8/4R3/4K3 b - - 0 1", []Violation{ViolationImpossibleCheck, ViolationNoLastMove}},
+		{"4k3/8/3N1N2/8/8/8/8/4K3 b - - 0 1", []Violation{ViolationImpossibleCheck, ViolationNoLastMove}},
+		// Doubled pawns while every black piece is on the board
+		{"rnbqkbnr/pppppppp/8/8/8/P7/P7/RNBQKBNR w Kkq - 0 1", []Violation{ViolationPawnCaptures}},
+		{"rnbqkbn1/pppppppp/8/8/8/P7/P7/RNBQKBNR w Qq - 0 1", nil},
+		// White has no move to make
+		{"7k/8/8/8/8/8/PPP5/KB6 b - - 0 1", []Violation{ViolationNoLastMove}},
+		{"7k/8/8/8/8/8/PP6/KB6 b - - 0 1", nil},
+		// Last move was castling
+		{"7k/8/8/8/8/8/8/5RK1 b - - 0 1", nil},
+		// The pawn on e7 gave check before the double step
+		{"4k3/8/3K4/4p3/8/8/8/8 w - e6 0 1", []Violation{ViolationEnPassant}},
+		// Illegal positions aren't analyzed
+		{"8/8/8/8/8/8/8/4K3 w - - 0 1", []Violation{ViolationKingCount}},
+	}
+
+	for _, test := range tests {
+		s, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := violations(ValidateReachable(s)); !slices.Equal(got, test.violations) {
+			t.Fatalf("%s: expected %v, got %v", test.fen, test.violations, got)
+		}
+	}
+}
+
+func TestPositionError_Error(t *testing.T) {
+	s, err := ParseFEN("4k3/8/8/8/8/8/8/4R1K1 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := ValidatePosition(s)
+	if len(errs) != 1 || errs[0].Error() != "black: side not to move is in check (Re1)" {
+		t.Fatalf("Unexpected errors %v", errs)
+	}
+}
+
+// Positions of played games are always reachable
+func TestValidateReachable_RandomGames(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+
+	for range 20 {
+		game := NewGame()
+		for game.Outcome() == NoOutcome && len(game.moves) < 200 {
+			moves := game.LegalMoves()
+			if err := game.Play(moves[r.IntN(len(moves))]); err != nil {
+				t.Fatal(err)
+			}
+
+			if errs := ValidateReachable(game.Snapshot()); errs != nil {
+				t.Fatalf("%s: %v", game.Snapshot().FEN(), errs)
+			}
+		}
+	}
+}
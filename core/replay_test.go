@@ -0,0 +1,54 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewGameFromMoves(t *testing.T) {
+	played := NewGame()
+	playMoves(t, &played, "e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6", "e1g1")
+
+	game, err := NewGameFromMoves(InitialSnapshot(), played.Moves())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(game, played) {
+		t.Fatalf("Replayed game doesn't match the played one")
+	}
+}
+
+func TestNewGameFromMoves_IllegalMove(t *testing.T) {
+	moves := []Move{
+		{Source: Cell{Piece{'P', White}, Position{1, 4}}, Target: Cell{Position: Position{3, 4}}, Action: Movement},
+		{Source: Cell{Piece{'P', Black}, Position{6, 4}}, Target: Cell{Position: Position{4, 4}}, Action: Movement},
+		// Pawn can't move onto the blocked cell
+		{Source: Cell{Piece{'P', White}, Position{3, 4}}, Target: Cell{Position: Position{4, 4}}, Action: Movement},
+	}
+
+	_, err := NewGameFromMoves(InitialSnapshot(), moves)
+
+	var replay *ReplayError
+	if !errors.As(err, &replay) || replay.Index != 2 {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	var illegal *IllegalMoveError
+	if !errors.As(err, &illegal) || illegal.Move != moves[2] {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestGame_MovesIsCopy(t *testing.T) {
+	game := NewGame()
+	playMoves(t, &game, "e2e4")
+
+	moves := game.Moves()
+	moves[0] = Move{}
+
+	if game.Moves()[0] == (Move{}) {
+		t.Fatalf("History was changed through the returned moves")
+	}
+}
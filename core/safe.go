@@ -0,0 +1,137 @@
+package core
+
+import "sync"
+
+// Size of the subscription channel buffer
+const changesBuffer = 16
+
+// Change is sent to subscribers after every played move
+type Change struct {
+	Move     Move
+	Snapshot Snapshot
+	Outcome  Outcome
+}
+
+// SafeGame is a game handle which can be shared between goroutines.
+// Moves are played one at a time, while queries run concurrently
+// and never change the game
+type SafeGame struct {
+	mu          sync.RWMutex
+	game        Game
+	subscribers map[chan Change]struct{}
+}
+
+// Takes a copy of the game, so the handle never shares the board with the caller
+func NewSafeGame(game Game) *SafeGame {
+	return &SafeGame{game: game.clone(), subscribers: map[chan Change]struct{}{}}
+}
+
+func (sg *SafeGame) Play(move Move) error {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.play(move)
+}
+
+// PlayLenient plays a move with the pieces taken from the board, see Game.CompleteMove
+func (sg *SafeGame) PlayLenient(move Move) error {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.play(sg.game.CompleteMove(move))
+}
+
+// Must be called with the write lock held
+func (sg *SafeGame) play(move Move) error {
+	if err := sg.game.processMove(move); err != nil {
+		return err
+	}
+
+	change := Change{Move: move, Snapshot: sg.game.Snapshot(), Outcome: sg.game.outcome}
+	for ch := range sg.subscribers {
+		notify(ch, change)
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel receiving changes of the game and a function to cancel the subscription.
+// A slow subscriber never blocks moves: if its buffer is full the oldest change is dropped,
+// every change holds a full snapshot, so the latest one is always enough to catch up
+func (sg *SafeGame) Subscribe() (<-chan Change, func()) {
+	ch := make(chan Change, changesBuffer)
+
+	sg.mu.Lock()
+	sg.subscribers[ch] = struct{}{}
+	sg.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			sg.mu.Lock()
+			delete(sg.subscribers, ch)
+			sg.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+func (sg *SafeGame) Snapshot() Snapshot {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.game.Snapshot()
+}
+
+func (sg *SafeGame) Moves() []Move {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.game.Moves()
+}
+
+func (sg *SafeGame) LegalMoves() []Move {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.game.LegalMoves()
+}
+
+func (sg *SafeGame) Outcome() Outcome {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.game.Outcome()
+}
+
+func (sg *SafeGame) Turn() Side {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.game.Turn()
+}
+
+func (sg *SafeGame) InCheck() bool {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.game.InCheck()
+}
+
+// Returns a copy of the game
+func (sg *SafeGame) Game() Game {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+	return sg.game.clone()
+}
+
+// Sends the change without blocking, dropping the oldest one if the buffer is full.
+// Must be called with the write lock held, so there is no concurrent sender
+func notify(ch chan Change, change Change) {
+	for {
+		select {
+		case ch <- change:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// Run with -race to detect unsynchronized access
+func TestSafeGame_ConcurrentReadersAndWriter(t *testing.T) {
+	sg := NewSafeGame(NewGame())
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				s := sg.Snapshot()
+				for _, move := range s.LegalMoves() {
+					if _, err := s.Play(move); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+				sg.Moves()
+				sg.Outcome()
+				sg.InCheck()
+				sg.Turn()
+			}
+		}()
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for range 30 {
+		moves := sg.LegalMoves()
+		if len(moves) == 0 {
+			break
+		}
+		if err := sg.Play(moves[rnd.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+}
+
+func TestSafeGame_QueriesDontChangeGame(t *testing.T) {
+	game := NewGame()
+	// Give the black king a check, so the queries have to look for defences
+	game.Board[6][3] = Empty
+	game.Board[3][0] = Piece{'Q', White}
+	snapshot := game.Snapshot()
+	snapshot.turn = Black
+
+	sg := NewSafeGame(snapshot.Game())
+	before := sg.Game()
+
+	sg.LegalMoves()
+	sg.InCheck()
+	sg.Snapshot()
+	sg.Outcome()
+
+	if !reflect.DeepEqual(before, sg.game) {
+		t.Fatalf("Game was changed by queries")
+	}
+}
+
+func TestSafeGame_Subscribe(t *testing.T) {
+	sg := NewSafeGame(NewGame())
+	changes, cancel := sg.Subscribe()
+
+	moves := []Move{
+		{Source: Cell{Piece{'P', White}, Position{1, 4}}, Target: Cell{Position: Position{3, 4}}, Action: Movement},
+		{Source: Cell{Piece{'P', Black}, Position{6, 4}}, Target: Cell{Position: Position{4, 4}}, Action: Movement},
+	}
+	for _, move := range moves {
+		if err := sg.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Illegal moves aren't sent
+	if err := sg.Play(moves[0]); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	for _, move := range moves {
+		change := <-changes
+		if change.Move != move || change.Outcome != NoOutcome {
+			t.Fatalf("Unexpected change %v", change)
+		}
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-changes; ok {
+		t.Fatalf("Channel wasn't closed")
+	}
+}
+
+func TestSafeGame_SlowSubscriber(t *testing.T) {
+	sg := NewSafeGame(NewGame())
+	changes, cancel := sg.Subscribe()
+	defer cancel()
+
+	// Knights go back and forth without anyone reading the changes
+	moves := []Move{
+		{Source: Cell{Piece{'N', White}, Position{0, 6}}, Target: Cell{Position: Position{2, 5}}, Action: Movement},
+		{Source: Cell{Piece{'N', Black}, Position{7, 6}}, Target: Cell{Position: Position{5, 5}}, Action: Movement},
+		{Source: Cell{Piece{'N', White}, Position{2, 5}}, Target: Cell{Position: Position{0, 6}}, Action: Movement},
+		{Source: Cell{Piece{'N', Black}, Position{5, 5}}, Target: Cell{Position: Position{7, 6}}, Action: Movement},
+	}
+	total := changesBuffer + 6
+	for i := range total {
+		if err := sg.Play(moves[i%len(moves)]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(changes) != changesBuffer {
+		t.Fatalf("Unexpected number of buffered changes %d", len(changes))
+	}
+
+	var last Change
+	for range changesBuffer {
+		last = <-changes
+	}
+
+	if last.Move != moves[(total-1)%len(moves)] || last.Snapshot != sg.Snapshot() {
+		t.Fatalf("Latest change wasn't kept")
+	}
+}
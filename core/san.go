@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns the move in standard algebraic notation, e.g. "Nbd7", "exd6", "e8=Q+" or "O-O#".
+// The move must be legal in the game
+func (g *Game) SAN(move Move) string {
+	var sb strings.Builder
+
+	switch {
+	case isCastling(move):
+		sb.WriteString(string(move.Action))
+	case move.Source.fig == Pawn:
+		if move.Source.col != move.Target.col {
+			sb.WriteByte(byte('a' + move.Source.col))
+			sb.WriteByte('x')
+		}
+		sb.WriteString(move.Target.Position.String())
+		if move.Action == Promotion {
+			sb.WriteByte('=')
+			sb.WriteByte(byte(move.Target.fig))
+		}
+	default:
+		sb.WriteByte(byte(move.Source.fig))
+		sb.WriteString(g.disambiguation(move))
+		if move.Action == Capture {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(move.Target.Position.String())
+	}
+
+	next := g.clone()
+	if next.processMove(move) == nil {
+		if next.outcome == Checkmate {
+			sb.WriteByte('#')
+		} else if next.InCheck() {
+			sb.WriteByte('+')
+		}
+	}
+
+	return sb.String()
+}
+
+// ParseSAN finds the legal move given in standard algebraic notation.
+// Check, mate and annotation suffixes are ignored, castling may be written with zeros
+func (g *Game) ParseSAN(san string) (Move, error) {
+	str := strings.TrimRight(san, "+#!?")
+
+	switch str {
+	case "O-O", "0-0":
+		return g.findLegal(san, func(move Move) bool { return move.Action == KingCastling })
+	case "O-O-O", "0-0-0":
+		return g.findLegal(san, func(move Move) bool { return move.Action == QueenCastling })
+	}
+
+	fig := Pawn
+	if len(str) > 0 && strings.IndexByte("NBRQK", str[0]) != -1 {
+		fig = Figure(str[0])
+		str = str[1:]
+	}
+
+	var promoted Figure
+	if i := len(str) - 1; i > 0 && strings.IndexByte("NBRQ", str[i]) != -1 {
+		promoted = Figure(str[i])
+		str = strings.TrimSuffix(str[:i], "=")
+	}
+
+	if len(str) < 2 {
+		return Move{}, fmt.Errorf("invalid move: %q", san)
+	}
+
+	target, err := ParseSquare(str[len(str)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move: %q", san)
+	}
+
+	// File and rank of the source which disambiguate the move
+	col, row := -1, -1
+	for _, c := range strings.TrimSuffix(str[:len(str)-2], "x") {
+		switch {
+		case c >= 'a' && c <= 'h' && col == -1:
+			col = int(c - 'a')
+		case c >= '1' && c <= '8' && row == -1:
+			row = int(c - '1')
+		default:
+			return Move{}, fmt.Errorf("invalid move: %q", san)
+		}
+	}
+
+	return g.findLegal(san, func(move Move) bool {
+		if isCastling(move) || move.Source.fig != fig || move.Target.Position != target ||
+			(col != -1 && move.Source.col != col) || (row != -1 && move.Source.row != row) {
+			return false
+		}
+
+		if move.Action == Promotion {
+			return move.Target.fig == promoted
+		}
+		return promoted == 0
+	})
+}
+
+// Returns the only legal move that matches
+func (g *Game) findLegal(san string, match func(Move) bool) (Move, error) {
+	var found []Move
+	for _, move := range g.LegalMoves() {
+		if match(move) {
+			found = append(found, move)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Move{}, fmt.Errorf("illegal move: %q", san)
+	case 1:
+		return found[0], nil
+	}
+	return Move{}, fmt.Errorf("ambiguous move: %q", san)
+}
+
+// Returns file, rank or square of the source which tells the move
+// from other legal moves of the same figure to the same target
+func (g *Game) disambiguation(move Move) string {
+	sameFile, sameRank, others := false, false, false
+	for _, other := range g.LegalMoves() {
+		if isCastling(other) || other.Source == move.Source || other.Source.Piece != move.Source.Piece ||
+			other.Target.Position != move.Target.Position {
+			continue
+		}
+
+		others = true
+		sameFile = sameFile || other.Source.col == move.Source.col
+		sameRank = sameRank || other.Source.row == move.Source.row
+	}
+
+	square := move.Source.Position.String()
+	switch {
+	case !others:
+		return ""
+	case !sameFile:
+		return square[:1]
+	case !sameRank:
+		return square[1:]
+	}
+	return square
+}
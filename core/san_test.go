@@ -0,0 +1,69 @@
+package core
+
+import "testing"
+
+func TestGame_SAN(t *testing.T) {
+	game := NewGame()
+
+	sans := []string{"e4", "d5", "exd5", "e5", "dxe6", "Nc6", "exf7+", "Ke7", "Nf3", "Bg4",
+		"Bc4", "Qd7", "O-O", "Qd6", "fxg8=N+", "Rxg8", "Nc3", "Rh8", "Nb5", "Qd5", "Nbd4"}
+
+	for _, san := range sans {
+		move, err := game.ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if formatted := game.SAN(move); formatted != san {
+			t.Fatalf("Move %s formatted as %s", san, formatted)
+		}
+
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGame_SANCheckmate(t *testing.T) {
+	game := NewGame()
+	for _, san := range []string{"f3", "e5", "g4"} {
+		move, err := game.ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		game.Play(move)
+	}
+
+	move, err := game.ParseSAN("Qh4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if san := game.SAN(move); san != "Qh4#" {
+		t.Fatalf("Unexpected SAN %s", san)
+	}
+}
+
+func TestGame_ParseSANErrors(t *testing.T) {
+	game := NewGame()
+	playMoves(t, &game, "b1c3", "a7a6", "g1f3", "a6a5", "f3g5", "a5a4")
+
+	tests := map[string]string{
+		"":     `invalid move: ""`,
+		"e9":   `invalid move: "e9"`,
+		"Nxe5": `illegal move: "Nxe5"`,
+		"Ne4":  `ambiguous move: "Ne4"`,
+		"O-O":  `illegal move: "O-O"`,
+		"e5=Q": `illegal move: "e5=Q"`,
+	}
+
+	for san, expected := range tests {
+		if _, err := game.ParseSAN(san); err == nil || err.Error() != expected {
+			t.Fatalf("%s: unexpected error %v", san, err)
+		}
+	}
+
+	if _, err := game.ParseSAN("Nge4"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,94 @@
+package core
+
+import "slices"
+
+// Returns value of the figure in the exchanges in centipawns, the king is worth more than the rest of the pieces
+func exchangeValue(fig Figure) int {
+	switch fig {
+	case Pawn:
+		return 100
+	case Knight, Bishop:
+		return 300
+	case Rook:
+		return 500
+	case Queen:
+		return 900
+	case King:
+		return 20000
+	}
+	return 0
+}
+
+// SEE returns material in centipawns the side of the moved piece wins by the exchange on the target of the move,
+// the pieces are taken from the board. Both sides capture by the least valuable attackers
+// and may stop the exchange any time, pieces behind the captured ones join it.
+// The move isn't validated, pins and checks are not considered
+func (g *Game) SEE(move Move) int {
+	move = g.CompleteMove(move)
+	if isCastling(move) || move.Source.Piece == Empty ||
+		!isValidPosition(move.Source.col, move.Source.row) || !isValidPosition(move.Target.col, move.Target.row) {
+		return 0
+	}
+
+	board := make([][]Piece, len(g.Board))
+	for row := range g.Board {
+		board[row] = slices.Clone(g.Board[row])
+	}
+	exchange := &Game{Board: board}
+	target := move.Target.Position
+
+	// Material won by the side making the capture if the exchange stops after it
+	gains := []int{exchangeValue(board[target.row][target.col].fig)}
+	piece := move.Source.Piece
+	switch move.Action {
+	case Enpassant:
+		gains[0] = exchangeValue(Pawn)
+		board[move.Source.row][target.col] = Empty
+	case Promotion:
+		piece = move.Target.Piece
+		gains[0] += exchangeValue(piece.fig) - exchangeValue(Pawn)
+	}
+	board[move.Source.row][move.Source.col] = Empty
+	board[target.row][target.col] = piece
+
+	for side := getOpponent(piece.side); ; side = getOpponent(side) {
+		source, ok := exchange.leastValuableAttacker(target, side)
+		if !ok {
+			break
+		}
+
+		attacker := board[source.row][source.col]
+		gain := exchangeValue(board[target.row][target.col].fig) - gains[len(gains)-1]
+		if attacker.fig == Pawn && target.row == PromotionRows[side] {
+			attacker.fig = Queen
+			gain += exchangeValue(Queen) - exchangeValue(Pawn)
+		}
+		gains = append(gains, gain)
+
+		board[source.row][source.col] = Empty
+		board[target.row][target.col] = attacker
+	}
+
+	// Each side stops the exchange when the next capture loses
+	for i := len(gains) - 1; i > 0; i-- {
+		gains[i-1] = -max(-gains[i-1], gains[i])
+	}
+	return gains[0]
+}
+
+// Returns the least valuable piece of the side attacking the position
+func (g *Game) leastValuableAttacker(pos Position, side Side) (Position, bool) {
+	var res Position
+	value := 0
+	for row := range g.Board {
+		for col, pic := range g.Board[row] {
+			if pic.side != side || pic == Empty || (value > 0 && exchangeValue(pic.fig) >= value) {
+				continue
+			}
+			if g.attacks(Position{row: row, col: col}, pos) {
+				res, value = Position{row: row, col: col}, exchangeValue(pic.fig)
+			}
+		}
+	}
+	return res, value > 0
+}
@@ -0,0 +1,44 @@
+package core
+
+import "testing"
+
+func TestGame_SEE(t *testing.T) {
+	tests := []struct {
+		fen      string
+		move     string
+		expected int
+	}{
+		// Undefended pawn
+		{"1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		// Queens behind the rook and the bishop join the exchange
+		{"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", -200},
+		{"4k3/8/4p3/3n4/4P3/8/8/4K3 w - - 0 1", "e4d5", 200},
+		// Rooks are exchanged after the pawn is won
+		{"3rk3/8/8/8/8/8/3p4/3RK3 w - - 0 1", "d1d2", 100},
+		// King can't take back the defended bishop
+		{"3rk3/8/8/8/1b6/8/3p4/3RK3 w - - 0 1", "d1d2", -400},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 100},
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8q", 1300},
+		{"1rk5/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8q", 400},
+		// Quiet moves
+		{"4k3/8/8/3p4/8/8/8/2Q1K3 w - - 0 1", "c1c4", -900},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", 0},
+		{"r3k3/8/8/8/8/8/8/4K3 b q - 0 1", "e8c8", 0},
+	}
+
+	for _, test := range tests {
+		s, err := ParseFEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		game := s.Game()
+
+		move, err := game.ParseUCI(test.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := game.SEE(move); got != test.expected {
+			t.Fatalf("%s %s: expected %d, got %d", test.fen, test.move, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,535 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Version of the serialization schema, both JSON and binary.
+// Version 2 stores the move clocks of the start position, version 1 data is still read
+const SchemaVersion = 2
+
+// Magic bytes starting a binary game
+var binaryMagic = []byte("SHIO")
+
+// Pieces by their binary codes, 0 is an empty cell
+var binaryPieces = []Piece{
+	Empty,
+	{Pawn, White}, {Knight, White}, {Bishop, White}, {Rook, White}, {Queen, White}, {King, White},
+	{Pawn, Black}, {Knight, Black}, {Bishop, Black}, {Rook, Black}, {Queen, Black}, {King, Black},
+}
+
+// Actions by their binary codes
+var binaryActions = []Action{Movement, Capture, Promotion, Enpassant, KingCastling, QueenCastling}
+
+// Castling rights in FEN notation, ordered as CastlingRights bits
+const castlingLetters = "KQkq"
+
+type (
+	cellJSON struct {
+		Piece  string `json:"piece,omitempty"`
+		Square string `json:"square"`
+	}
+	moveJSON struct {
+		Source *Cell  `json:"source,omitempty"`
+		Target *Cell  `json:"target,omitempty"`
+		Action Action `json:"action"`
+	}
+	snapshotJSON struct {
+		Board    Board  `json:"board"`
+		Turn     string `json:"turn"`
+		Castling string `json:"castling"`
+		// Square of the pawn which can be captured en passant
+		Enpassant string `json:"enpassant,omitempty"`
+		Halfmove  int    `json:"halfmove,omitempty"`
+		// Number of the full move, 1 if omitted
+		Fullmove int `json:"fullmove,omitempty"`
+	}
+	gameJSON struct {
+		Version int       `json:"version"`
+		Start   *Snapshot `json:"start,omitempty"`
+		Moves   []Move    `json:"moves"`
+		// Board and outcome are informational, they are checked against the replayed moves
+		Board   Board  `json:"board,omitempty"`
+		Outcome string `json:"outcome,omitempty"`
+	}
+)
+
+var outcomeNames = map[Outcome]string{Checkmate: "checkmate", Stalemate: "stalemate", NoOutcome: "none"}
+
+func (p Piece) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Piece) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = Empty
+		return nil
+	}
+
+	if len(text) != 1 {
+		return fmt.Errorf("invalid piece: %q", text)
+	}
+
+	pic, err := ParsePiece(rune(text[0]))
+	if err != nil {
+		return err
+	}
+
+	*p = pic
+	return nil
+}
+
+func (p Position) MarshalText() ([]byte, error) {
+	if !isValidPosition(p.col, p.row) {
+		return nil, fmt.Errorf("invalid position: row %d, col %d", p.row, p.col)
+	}
+	return []byte(p.String()), nil
+}
+
+func (p *Position) UnmarshalText(text []byte) error {
+	pos, err := ParseSquare(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = pos
+	return nil
+}
+
+func (c Cell) MarshalJSON() ([]byte, error) {
+	if !isValidPosition(c.col, c.row) {
+		return nil, fmt.Errorf("invalid position: row %d, col %d", c.row, c.col)
+	}
+	return json.Marshal(cellJSON{Piece: c.Piece.String(), Square: c.Position.String()})
+}
+
+func (c *Cell) UnmarshalJSON(data []byte) error {
+	var raw cellJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if err := c.Piece.UnmarshalText([]byte(raw.Piece)); err != nil {
+		return err
+	}
+	return c.Position.UnmarshalText([]byte(raw.Square))
+}
+
+// Castling given only by its action is encoded without cells
+func (m Move) MarshalJSON() ([]byte, error) {
+	raw := moveJSON{Action: m.Action}
+	if !isCastling(m) || m.Source.Piece != Empty {
+		raw.Source, raw.Target = &m.Source, &m.Target
+	}
+	return json.Marshal(raw)
+}
+
+func (m *Move) UnmarshalJSON(data []byte) error {
+	var raw moveJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = Move{Action: raw.Action}
+	if raw.Source != nil {
+		m.Source = *raw.Source
+	}
+	if raw.Target != nil {
+		m.Target = *raw.Target
+	}
+
+	return nil
+}
+
+// Board is encoded as 8 rows of FEN letters starting from the first rank, "." is an empty cell
+func (b Board) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+
+	if err := b.checkSize(); err != nil {
+		return nil, err
+	}
+
+	rows := make([]string, 0, len(b))
+	for _, row := range b {
+		var sb strings.Builder
+		for _, pic := range row {
+			if pic == Empty {
+				sb.WriteByte('.')
+			} else {
+				sb.WriteString(pic.String())
+			}
+		}
+		rows = append(rows, sb.String())
+	}
+
+	return json.Marshal(rows)
+}
+
+func (b *Board) UnmarshalJSON(data []byte) error {
+	var rows []string
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return err
+	}
+
+	if rows == nil {
+		*b = nil
+		return nil
+	}
+
+	if len(rows) != 8 {
+		return fmt.Errorf("invalid board: %d rows", len(rows))
+	}
+
+	board := make(Board, len(rows))
+	for i, row := range rows {
+		if len(row) != 8 {
+			return fmt.Errorf("invalid board: row %d has %d cells", i+1, len(row))
+		}
+
+		board[i] = make([]Piece, len(row))
+		for j := range row {
+			if row[j] == '.' {
+				continue
+			}
+
+			pic, err := ParsePiece(rune(row[j]))
+			if err != nil {
+				return err
+			}
+			board[i][j] = pic
+		}
+	}
+
+	*b = board
+	return nil
+}
+
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	raw := snapshotJSON{Board: s.board2D(), Turn: string(s.turn), Castling: s.castlingString(), Halfmove: s.halfmove}
+	if s.enpassant != (Position{}) {
+		raw.Enpassant = s.enpassant.String()
+	}
+	if s.fullmove > 0 {
+		raw.Fullmove = s.FullmoveNumber()
+	}
+	return json.Marshal(raw)
+}
+
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var raw snapshotJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.Board == nil {
+		return fmt.Errorf("snapshot has no board")
+	}
+
+	if len(raw.Turn) != 1 || !Side(raw.Turn[0]).IsValid() {
+		return fmt.Errorf("invalid turn: %q", raw.Turn)
+	}
+
+	castling, err := parseCastling(raw.Castling)
+	if err != nil {
+		return err
+	}
+
+	if raw.Halfmove < 0 || raw.Fullmove < 0 {
+		return fmt.Errorf("invalid move clocks: %d %d", raw.Halfmove, raw.Fullmove)
+	}
+
+	res := Snapshot{board: raw.Board.toArray(), turn: Side(raw.Turn[0]), castling: castling, halfmove: raw.Halfmove, fullmove: max(raw.Fullmove-1, 0)}
+	if raw.Enpassant != "" {
+		if res.enpassant, err = ParseSquare(raw.Enpassant); err != nil {
+			return err
+		}
+	}
+
+	*s = res
+	return nil
+}
+
+func (g Game) MarshalJSON() ([]byte, error) {
+	start := g.start
+	return json.Marshal(gameJSON{
+		Version: SchemaVersion,
+		Start:   &start,
+		Moves:   g.moves,
+		Board:   g.Board,
+		Outcome: outcomeNames[g.outcome],
+	})
+}
+
+// UnmarshalJSON replays the moves from the start position,
+// the stored board and outcome are only checked to match the replayed game
+func (g *Game) UnmarshalJSON(data []byte) error {
+	var raw gameJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.Version < 1 || raw.Version > SchemaVersion {
+		return fmt.Errorf("unsupported schema version: %d", raw.Version)
+	}
+
+	start := InitialSnapshot()
+	if raw.Start != nil {
+		start = *raw.Start
+	}
+
+	game, err := NewGameFromMoves(start, raw.Moves)
+	if err != nil {
+		return err
+	}
+
+	if raw.Board != nil && !slices.EqualFunc(raw.Board, game.Board, slices.Equal) {
+		return fmt.Errorf("stored board doesn't match the moves")
+	}
+
+	if raw.Outcome != "" && raw.Outcome != outcomeNames[game.outcome] {
+		return fmt.Errorf("stored outcome doesn't match the moves")
+	}
+
+	*g = game
+	return nil
+}
+
+// Board is encoded in 32 bytes, a cell per 4 bits
+func (b Board) MarshalBinary() ([]byte, error) {
+	if err := b.checkSize(); err != nil {
+		return nil, err
+	}
+
+	res := make([]byte, 32)
+	for row := range b {
+		for col, pic := range b[row] {
+			code := slices.Index(binaryPieces, pic)
+			if code < 0 {
+				return nil, fmt.Errorf("invalid piece at %s", Position{row: row, col: col})
+			}
+
+			i := row*8 + col
+			res[i/2] |= byte(code) << (4 * (i % 2))
+		}
+	}
+
+	return res, nil
+}
+
+func (b *Board) UnmarshalBinary(data []byte) error {
+	if len(data) != 32 {
+		return fmt.Errorf("invalid board: %d bytes", len(data))
+	}
+
+	board := make(Board, 8)
+	for row := range board {
+		board[row] = make([]Piece, 8)
+		for col := range board[row] {
+			i := row*8 + col
+			code := int(data[i/2]>>(4*(i%2))) & 0xf
+			if code >= len(binaryPieces) {
+				return fmt.Errorf("invalid piece code %d", code)
+			}
+			board[row][col] = binaryPieces[code]
+		}
+	}
+
+	*b = board
+	return nil
+}
+
+// Move is encoded in 4 bytes: source cell, target cell, pieces and action
+func (m Move) MarshalBinary() ([]byte, error) {
+	if !isValidPosition(m.Source.col, m.Source.row) || !isValidPosition(m.Target.col, m.Target.row) {
+		return nil, fmt.Errorf("invalid move position")
+	}
+
+	source, target := slices.Index(binaryPieces, m.Source.Piece), slices.Index(binaryPieces, m.Target.Piece)
+	action := slices.Index(binaryActions, m.Action)
+	if source < 0 || target < 0 || action < 0 {
+		return nil, fmt.Errorf("invalid move")
+	}
+
+	return []byte{
+		byte(m.Source.row*8 + m.Source.col),
+		byte(m.Target.row*8 + m.Target.col),
+		byte(source) | byte(target)<<4,
+		byte(action),
+	}, nil
+}
+
+func (m *Move) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return fmt.Errorf("invalid move: %d bytes", len(data))
+	}
+
+	source, target := int(data[2]&0xf), int(data[2]>>4)
+	if data[0] > 63 || data[1] > 63 || source >= len(binaryPieces) || target >= len(binaryPieces) ||
+		int(data[3]) >= len(binaryActions) {
+		return fmt.Errorf("invalid move")
+	}
+
+	*m = Move{
+		Source: Cell{binaryPieces[source], Position{row: int(data[0]) / 8, col: int(data[0]) % 8}},
+		Target: Cell{binaryPieces[target], Position{row: int(data[1]) / 8, col: int(data[1]) % 8}},
+		Action: binaryActions[data[3]],
+	}
+	return nil
+}
+
+// Game is encoded as magic, version, start position with the move clocks and moves.
+// The board isn't stored, it's restored by replaying the moves
+func (g Game) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(binaryMagic)
+	buf.WriteByte(SchemaVersion)
+
+	board, err := g.start.board2D().MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(board)
+
+	enpassant := byte(0xff)
+	if pos := g.start.enpassant; pos != (Position{}) {
+		enpassant = byte(pos.row*8 + pos.col)
+	}
+	buf.Write([]byte{byte(g.start.turn), byte(g.start.castling), enpassant})
+	buf.Write(binary.AppendUvarint(nil, uint64(g.start.halfmove)))
+	buf.Write(binary.AppendUvarint(nil, uint64(g.start.fullmove)))
+
+	buf.Write(binary.AppendUvarint(nil, uint64(len(g.moves))))
+	for _, move := range g.moves {
+		data, err := move.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replays the moves from the start position
+func (g *Game) UnmarshalBinary(data []byte) error {
+	header := len(binaryMagic) + 1
+	if len(data) < header+32+3 || !bytes.Equal(data[:len(binaryMagic)], binaryMagic) {
+		return fmt.Errorf("invalid binary game")
+	}
+
+	version := data[len(binaryMagic)]
+	if version < 1 || version > SchemaVersion {
+		return fmt.Errorf("unsupported schema version: %d", version)
+	}
+	data = data[header:]
+
+	var board Board
+	if err := board.UnmarshalBinary(data[:32]); err != nil {
+		return err
+	}
+
+	turn, castling, enpassant := Side(data[32]), CastlingRights(data[33]), data[34]
+	if !turn.IsValid() || castling&^AllCastling != 0 || (enpassant > 63 && enpassant != 0xff) {
+		return fmt.Errorf("invalid start position")
+	}
+	data = data[35:]
+
+	start := Snapshot{board: board.toArray(), turn: turn, castling: castling}
+	if enpassant != 0xff {
+		start.enpassant = Position{row: int(enpassant) / 8, col: int(enpassant) % 8}
+	}
+
+	// Version 1 has no move clocks
+	if version > 1 {
+		for _, clock := range []*int{&start.halfmove, &start.fullmove} {
+			value, n := binary.Uvarint(data)
+			if n <= 0 || value > math.MaxInt32 {
+				return fmt.Errorf("invalid start position")
+			}
+			*clock = int(value)
+			data = data[n:]
+		}
+	}
+
+	// Count is checked before multiplying, so a huge one can't wrap around
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)-n)/4 || uint64(len(data)-n) != count*4 {
+		return fmt.Errorf("invalid moves")
+	}
+	data = data[n:]
+
+	moves := make([]Move, count)
+	for i := range moves {
+		if err := moves[i].UnmarshalBinary(data[i*4 : i*4+4]); err != nil {
+			return err
+		}
+	}
+
+	game, err := NewGameFromMoves(start, moves)
+	if err != nil {
+		return err
+	}
+
+	*g = game
+	return nil
+}
+
+func (b Board) checkSize() error {
+	if len(b) != 8 {
+		return fmt.Errorf("invalid board: %d rows", len(b))
+	}
+	for i, row := range b {
+		if len(row) != 8 {
+			return fmt.Errorf("invalid board: row %d has %d cells", i+1, len(row))
+		}
+	}
+	return nil
+}
+
+func (s Snapshot) board2D() Board {
+	board := make(Board, len(s.board))
+	for row := range s.board {
+		board[row] = slices.Clone(s.board[row][:])
+	}
+	return board
+}
+
+// Returns castling rights in FEN notation, e.g. "KQkq"
+func (s Snapshot) castlingString() string {
+	var sb strings.Builder
+	for i := range len(castlingLetters) {
+		if s.castling&(1<<i) != 0 {
+			sb.WriteByte(castlingLetters[i])
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "-"
+	}
+	return sb.String()
+}
+
+func parseCastling(str string) (CastlingRights, error) {
+	var rights CastlingRights
+	if str == "-" || str == "" {
+		return rights, nil
+	}
+
+	for i := range len(str) {
+		j := strings.IndexByte(castlingLetters, str[i])
+		if j < 0 {
+			return 0, fmt.Errorf("invalid castling: %q", str)
+		}
+		rights |= 1 << j
+	}
+
+	return rights, nil
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Plays moves given by source and target squares, e.g. "e2e4" or "e7e8n"
+func playMoves(t *testing.T, game *Game, moves ...string) {
+	t.Helper()
+
+	for _, str := range moves {
+		source, err := ParseSquare(str[:2])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		target, err := ParseSquare(str[2:4])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		move := Move{Source: Cell{Position: source}, Target: Cell{Position: target}}
+		if len(str) == 5 {
+			move.Target.fig = Figure(str[4] - ('a' - 'A'))
+		}
+
+		if err := game.PlayLenient(move); err != nil {
+			t.Fatalf("Move %s: %v", str, err)
+		}
+	}
+}
+
+func newSerializedGame(t *testing.T) Game {
+	game := NewGame()
+	playMoves(t, &game, "e2e4", "d7d5", "e4d5", "e7e5", "d5e6", "b8c6", "e6f7", "e8e7",
+		"g1f3", "c8g4", "f1c4", "d8d7")
+
+	// Castling given only by the action
+	if err := game.Play(Move{Action: KingCastling}); err != nil {
+		t.Fatal(err)
+	}
+
+	playMoves(t, &game, "d7d6", "f7g8n")
+	return game
+}
+
+func TestGame_JSON(t *testing.T) {
+	game := newSerializedGame(t)
+
+	data, err := json.Marshal(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Game
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(game, decoded) {
+		t.Fatalf("Decoded game doesn't match\n%s", data)
+	}
+}
+
+func TestGame_JSONFromSnapshot(t *testing.T) {
+	game := NewGame()
+	playMoves(t, &game, "e2e4", "c7c5", "e4e5", "d7d5")
+
+	// Started right after the double step, so en passant is allowed
+	started := game.Snapshot().Game()
+	playMoves(t, &started, "e5d6")
+
+	data, err := json.Marshal(started)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Game
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(started, decoded) {
+		t.Fatalf("Decoded game doesn't match\n%s", data)
+	}
+}
+
+func TestGame_JSONReplaysMoves(t *testing.T) {
+	game := NewGame()
+	playMoves(t, &game, "e2e4", "e7e5")
+
+	data, err := json.Marshal(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		err      string
+	}{
+		{"illegal move", `"square":"e5"`, `"square":"e4"`, "move 2: "},
+		{"tampered board", `"pppp.ppp"`, `"pppppppp"`, "stored board doesn't match the moves"},
+		{"tampered outcome", `"outcome":"none"`, `"outcome":"checkmate"`, "stored outcome doesn't match the moves"},
+		{"unknown version", `"version":2`, `"version":99`, "unsupported schema version: 99"},
+	}
+
+	for _, tt := range tests {
+		tampered := strings.Replace(string(data), tt.from, tt.to, 1)
+		if tampered == string(data) {
+			t.Fatalf("%s: nothing was replaced in %s", tt.name, data)
+		}
+
+		var decoded Game
+		err := json.Unmarshal([]byte(tampered), &decoded)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+}
+
+func TestGame_Binary(t *testing.T) {
+	game := newSerializedGame(t)
+
+	data, err := game.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Game
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(game, decoded) {
+		t.Fatalf("Decoded game doesn't match")
+	}
+
+	// Count of the moves wrapping around to the length of the moves when multiplied
+	moves := len(data) - len(game.moves)*4 - 1
+	crafted := binary.AppendUvarint(slices.Clone(data[:moves]), 1<<62+uint64(len(game.moves)))
+	crafted = append(crafted, data[len(data)-len(game.moves)*4:]...)
+	if err := decoded.UnmarshalBinary(crafted); err == nil || err.Error() != "invalid moves" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// Turn e4 into an illegal e5
+	data[len(data)-len(game.moves)*4+1] = 4*8 + 4
+	if err := decoded.UnmarshalBinary(data); err == nil || !strings.HasPrefix(err.Error(), "move 1: ") {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestGame_SerializedClocks(t *testing.T) {
+	s, err := ParseFEN("4k3/8/8/8/8/8/4P3/4K1N1 b - - 12 40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := s.Game()
+	playMoves(t, &game, "e8d8")
+
+	data, err := game.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Game
+	if err := decoded.UnmarshalBinary(data); err != nil || !reflect.DeepEqual(game, decoded) {
+		t.Fatalf("Decoded game doesn't match: %v", err)
+	}
+
+	if data, err = json.Marshal(game); err != nil {
+		t.Fatal(err)
+	}
+	decoded = Game{}
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(game, decoded) {
+		t.Fatalf("Decoded game doesn't match: %v\n%s", err, data)
+	}
+
+	// Version 1 has no clocks
+	game = NewGame()
+	playMoves(t, &game, "e2e4")
+	if data, err = game.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	header := len(binaryMagic) + 1 + 35
+	v1 := append(slices.Clone(data[:header]), data[header+2:]...)
+	v1[len(binaryMagic)] = 1
+	if err := decoded.UnmarshalBinary(v1); err != nil || !reflect.DeepEqual(game, decoded) {
+		t.Fatalf("Decoded game doesn't match: %v", err)
+	}
+
+	data, _ = json.Marshal(game)
+	v1 = []byte(strings.Replace(string(data), `"version":2`, `"version":1`, 1))
+	if err := json.Unmarshal(v1, &decoded); err != nil || !reflect.DeepEqual(game, decoded) {
+		t.Fatalf("Decoded game doesn't match: %v", err)
+	}
+}
+
+func TestBoard_Binary(t *testing.T) {
+	board := NewGame().Board
+
+	data, err := board.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) != 32 {
+		t.Fatalf("Unexpected size %d", len(data))
+	}
+
+	var decoded Board
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(board, decoded) {
+		t.Fatalf("Decoded board doesn't match")
+	}
+}
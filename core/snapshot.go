@@ -0,0 +1,214 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type CastlingRights uint8
+
+const (
+	WhiteKingCastling CastlingRights = 1 << iota
+	WhiteQueenCastling
+	BlackKingCastling
+	BlackQueenCastling
+	AllCastling = WhiteKingCastling | WhiteQueenCastling | BlackKingCastling | BlackQueenCastling
+)
+
+// Snapshot is an immutable position of a game.
+// Unlike Game it has value semantics, so copies never share the board
+// and can be safely passed between goroutines
+type Snapshot struct {
+	board    [8][8]Piece
+	turn     Side
+	castling CastlingRights
+	// Pawn which can be captured en passant, zero if none
+	enpassant Position
+	// Plies since the last capture or pawn move and full moves played before the position
+	halfmove, fullmove int
+}
+
+// Returns position of the game after the last move
+func (g *Game) Snapshot() Snapshot {
+	s := Snapshot{board: g.Board.toArray(), turn: g.whoseTurn(), castling: g.castlingRights(), halfmove: g.HalfmoveClock()}
+
+	// Full move ends by a move of black
+	plies := len(g.moves)
+	if g.start.turn == Black {
+		plies++
+	}
+	s.fullmove = g.start.fullmove + plies/2
+
+	if pos, found := g.lastDoubleStep(); found {
+		s.enpassant = pos
+	}
+
+	return s
+}
+
+// Returns position the game was started from
+func (g *Game) Start() Snapshot {
+	return g.start
+}
+
+// Returns a new snapshot with the move played.
+// The snapshot itself is never changed
+func (s Snapshot) Play(move Move) (Snapshot, error) {
+	g := s.Game()
+	if err := g.processMove(move); err != nil {
+		return s, err
+	}
+	return g.Snapshot(), nil
+}
+
+// Returns a new game started from the snapshot
+func (s Snapshot) Game() Game {
+	g := Game{
+		Board:   make(Board, len(s.board)),
+		moves:   []Move{},
+		outcome: NoOutcome,
+		start:   s,
+	}
+
+	for row := range s.board {
+		g.Board[row] = slices.Clone(s.board[row][:])
+
+		for col, pic := range s.board[row] {
+			switch pic.side {
+			case White:
+				g.whiteCells++
+			case Black:
+				g.blackCells++
+			}
+
+			if pic == (Piece{King, White}) {
+				g.whiteKing = Position{row: row, col: col}
+			} else if pic == (Piece{King, Black}) {
+				g.blackKing = Position{row: row, col: col}
+			}
+		}
+	}
+
+	g.outcome = g.checkGameStatus()
+
+	return g
+}
+
+func (s Snapshot) LegalMoves() []Move {
+	g := s.Game()
+	return g.LegalMoves()
+}
+
+func (s Snapshot) Turn() Side {
+	return s.turn
+}
+
+func (s Snapshot) Castling() CastlingRights {
+	return s.castling
+}
+
+// Returns the pawn which can be captured en passant
+func (s Snapshot) EnPassant() (Position, bool) {
+	return s.enpassant, s.enpassant != Position{}
+}
+
+// Returns number of plies since the last capture or pawn move
+func (s Snapshot) HalfmoveClock() int {
+	return s.halfmove
+}
+
+// Returns number of the full move starting from 1, as in FEN
+func (s Snapshot) FullmoveNumber() int {
+	return s.fullmove + 1
+}
+
+func (s Snapshot) At(pos Position) Piece {
+	if !isValidPosition(pos.col, pos.row) {
+		return Empty
+	}
+	return s.board[pos.row][pos.col]
+}
+
+// Returns material of the position like "KRPvKR", the pieces go in the order of "KQRBNP"
+func (s Snapshot) Material() string {
+	var pieces []egPiece
+	for row := range s.board {
+		for _, pic := range s.board[row] {
+			if pic != Empty {
+				pieces = append(pieces, egPiece{Piece: pic})
+			}
+		}
+	}
+	return materialOf(pieces)
+}
+
+// ParseMaterial parses material like "KRPvKR" case-insensitively, each side has one king.
+// Returns the material as Snapshot.Material does, the pieces go in the order of "KQRBNP"
+func ParseMaterial(material string) (string, error) {
+	white, black, ok := strings.Cut(strings.ToUpper(material), "V")
+	if !ok {
+		return "", fmt.Errorf("invalid material: %q", material)
+	}
+
+	sides := []string{white, black}
+	for i, letters := range sides {
+		if strings.Count(letters, "K") != 1 || strings.Trim(letters, materialOrder) != "" {
+			return "", fmt.Errorf("invalid material: %q", material)
+		}
+
+		sorted := []byte(letters)
+		slices.SortFunc(sorted, func(a, b byte) int {
+			return strings.IndexByte(materialOrder, a) - strings.IndexByte(materialOrder, b)
+		})
+		sides[i] = string(sorted)
+	}
+	return sides[0] + "v" + sides[1], nil
+}
+
+// Returns castling rights left after the played moves
+func (g *Game) castlingRights() CastlingRights {
+	rights := g.start.castling
+
+	corners := map[Position]CastlingRights{
+		{row: 0, col: 7}: WhiteKingCastling,
+		{row: 0, col: 0}: WhiteQueenCastling,
+		{row: 7, col: 7}: BlackKingCastling,
+		{row: 7, col: 0}: BlackQueenCastling,
+	}
+
+	for i, move := range g.moves {
+		side := g.moveSide(i)
+		if isCastling(move) || move.Source.fig == King {
+			rights &^= castlingRight(side, KingCastling) | castlingRight(side, QueenCastling)
+			continue
+		}
+
+		// Rook has moved or was captured
+		rights &^= corners[move.Source.Position] | corners[move.Target.Position]
+	}
+
+	return rights
+}
+
+func castlingRight(side Side, action Action) CastlingRights {
+	switch {
+	case side == White && action == KingCastling:
+		return WhiteKingCastling
+	case side == White && action == QueenCastling:
+		return WhiteQueenCastling
+	case side == Black && action == KingCastling:
+		return BlackKingCastling
+	case side == Black && action == QueenCastling:
+		return BlackQueenCastling
+	}
+	return 0
+}
+
+func (b Board) toArray() [8][8]Piece {
+	var res [8][8]Piece
+	for row := range min(len(b), len(res)) {
+		copy(res[row][:], b[row])
+	}
+	return res
+}
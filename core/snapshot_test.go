@@ -0,0 +1,205 @@
+package core
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSnapshot_ValueSemantics(t *testing.T) {
+	game := NewGame()
+	start := game.Snapshot()
+	copied := start
+
+	move := Move{Source: Cell{Piece{'P', White}, Position{1, 4}}, Target: Cell{Position: Position{3, 4}}, Action: Movement}
+	if err := game.processMove(move); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := start.Play(move)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if start != copied || start.At(Position{1, 4}) != (Piece{'P', White}) {
+		t.Fatalf("Snapshot was changed")
+	}
+
+	if next != game.Snapshot() || next.Turn() != Black {
+		t.Fatalf("Snapshot doesn't match the game")
+	}
+}
+
+func TestSnapshot_IllegalMove(t *testing.T) {
+	start := InitialSnapshot()
+
+	move := Move{Source: Cell{Piece{'Q', White}, Position{0, 3}}, Target: Cell{Position: Position{2, 3}}, Action: Movement}
+	next, err := start.Play(move)
+	if err == nil || err.Error() != "move is blocked" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if next != start {
+		t.Fatalf("Snapshot was changed by illegal move")
+	}
+}
+
+func TestSnapshot_CastlingRights(t *testing.T) {
+	s := InitialSnapshot()
+
+	moves := []Move{
+		{Source: Cell{Piece{'N', White}, Position{0, 6}}, Target: Cell{Position: Position{2, 5}}, Action: Movement},
+		{Source: Cell{Piece{'N', Black}, Position{7, 6}}, Target: Cell{Position: Position{5, 5}}, Action: Movement},
+		{Source: Cell{Piece{'R', White}, Position{0, 7}}, Target: Cell{Position: Position{0, 6}}, Action: Movement},
+		{Source: Cell{Piece{'P', Black}, Position{6, 4}}, Target: Cell{Position: Position{5, 4}}, Action: Movement},
+		{Source: Cell{Piece{'R', White}, Position{0, 6}}, Target: Cell{Position: Position{0, 7}}, Action: Movement},
+		{Source: Cell{Piece{'B', Black}, Position{7, 5}}, Target: Cell{Position: Position{4, 2}}, Action: Movement},
+		{Source: Cell{Piece{'P', White}, Position{1, 4}}, Target: Cell{Position: Position{2, 4}}, Action: Movement},
+	}
+
+	var err error
+	for _, move := range moves {
+		if s, err = s.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s.Castling() != AllCastling&^WhiteKingCastling {
+		t.Fatalf("Unexpected castling rights %04b", s.Castling())
+	}
+
+	// Black still can castle, the rights are kept by the snapshot
+	if s, err = s.Play(Move{Action: KingCastling}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.At(Position{7, 6}) != (Piece{'K', Black}) || s.At(Position{7, 5}) != (Piece{'R', Black}) {
+		t.Fatalf("Incorrect castling")
+	}
+
+	// White has lost the right even though the rook is back
+	_, err = s.Play(Move{Action: KingCastling})
+	if err == nil || err.Error() != "castling is not allowed" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestSnapshot_Enpassant(t *testing.T) {
+	s := InitialSnapshot()
+
+	moves := []Move{
+		{Source: Cell{Piece{'P', White}, Position{1, 4}}, Target: Cell{Position: Position{3, 4}}, Action: Movement},
+		{Source: Cell{Piece{'P', Black}, Position{6, 0}}, Target: Cell{Position: Position{5, 0}}, Action: Movement},
+		{Source: Cell{Piece{'P', White}, Position{3, 4}}, Target: Cell{Position: Position{4, 4}}, Action: Movement},
+		{Source: Cell{Piece{'P', Black}, Position{6, 3}}, Target: Cell{Position: Position{4, 3}}, Action: Movement},
+	}
+
+	var err error
+	for _, move := range moves {
+		if s, err = s.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	epMove := Move{Source: Cell{Piece{'P', White}, Position{4, 4}}, Target: Cell{Position: Position{5, 3}}, Action: Enpassant}
+	if s, err = s.Play(epMove); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.At(Position{4, 3}) != Empty {
+		t.Fatalf("Pawn wasn't captured en passant")
+	}
+}
+
+func TestSnapshot_ConcurrentPlay(t *testing.T) {
+	start := InitialSnapshot()
+	moves := start.LegalMoves()
+
+	results := make([]Snapshot, len(moves))
+	var wg sync.WaitGroup
+	for i, move := range moves {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s, err := start.Play(move)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			// Replies are generated from an independent copy
+			results[i] = s
+			_ = s.LegalMoves()
+		}()
+	}
+	wg.Wait()
+
+	if start != InitialSnapshot() {
+		t.Fatalf("Start snapshot was changed")
+	}
+
+	for i, s := range results {
+		if s.Turn() != Black || s == start {
+			t.Fatalf("Unexpected result of move %v", moves[i])
+		}
+	}
+}
+
+func TestSnapshot_Material(t *testing.T) {
+	tests := map[string]string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1": "KQRRBBNNPPPPPPPPvKQRRBBNNPPPPPPPP",
+		"8/8/3k4/3r4/8/3PK3/3R4/8 w - - 0 1":                       "KRPvKR",
+		"8/8/3k4/8/8/4K3/8/8 b - - 0 1":                            "KvK",
+	}
+
+	for fen, expected := range tests {
+		s, err := ParseFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Material(); got != expected {
+			t.Fatalf("%s: expected %s, got %s", fen, expected, got)
+		}
+	}
+}
+
+func TestParseMaterial(t *testing.T) {
+	tests := map[string]string{
+		"KRPvKR": "KRPvKR",
+		"KPRvKR": "KRPvKR",
+		"kvkr":   "KvKR",
+		"NKBvK":  "KBNvK",
+		"KvKv":   "",
+		"KQvQ":   "",
+		"KXvK":   "",
+		"KQK":    "",
+	}
+
+	for material, expected := range tests {
+		got, err := ParseMaterial(material)
+		if got != expected || (err == nil) != (expected != "") {
+			t.Fatalf("%s: expected %q, got %q: %v", material, expected, got, err)
+		}
+	}
+}
+
+func TestSnapshot_Clocks(t *testing.T) {
+	start := NewGame()
+	s := start.Snapshot()
+	s.halfmove, s.fullmove = 10, 4
+	if s.HalfmoveClock() != 10 || s.FullmoveNumber() != 5 {
+		t.Fatalf("Unexpected clocks %d %d", s.HalfmoveClock(), s.FullmoveNumber())
+	}
+
+	// Clock of the start position is continued by the game
+	game := s.Game()
+	playMoves(t, &game, "g1f3")
+	if game.HalfmoveClock() != 11 {
+		t.Fatalf("Unexpected clock %d", game.HalfmoveClock())
+	}
+
+	playMoves(t, &game, "e7e5")
+	if game.HalfmoveClock() != 0 {
+		t.Fatalf("Unexpected clock %d", game.HalfmoveClock())
+	}
+}
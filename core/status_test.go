@@ -0,0 +1,107 @@
+package core
+
+import (
+	"testing"
+)
+
+func newEmptyGame() Game {
+	game := NewGame()
+	for row := range game.Board {
+		for col := range game.Board[row] {
+			game.Board[row][col] = Empty
+		}
+	}
+	game.whiteCells, game.blackCells = 0, 0
+	return game
+}
+
+func (g *Game) putCell(pic Piece, pos Position) {
+	g.Board[pos.row][pos.col] = pic
+	if pic.side == White {
+		g.whiteCells++
+	} else {
+		g.blackCells++
+	}
+
+	if pic.fig == 'K' {
+		if pic.side == White {
+			g.whiteKing = pos
+		} else {
+			g.blackKing = pos
+		}
+	}
+}
+
+func TestCheckGameStatus_Checkmate(t *testing.T) {
+	game := NewGame()
+
+	// Fool's mate
+	moves := []Move{
+		{Source: Cell{Piece{'P', White}, Position{1, 5}}, Target: Cell{Position: Position{2, 5}}, Action: Movement},
+		{Source: Cell{Piece{'P', Black}, Position{6, 4}}, Target: Cell{Position: Position{4, 4}}, Action: Movement},
+		{Source: Cell{Piece{'P', White}, Position{1, 6}}, Target: Cell{Position: Position{3, 6}}, Action: Movement},
+		{Source: Cell{Piece{'Q', Black}, Position{7, 3}}, Target: Cell{Position: Position{3, 7}}, Action: Movement},
+	}
+
+	for i, move := range moves {
+		if game.outcome != NoOutcome {
+			t.Fatalf("Game ended before move %d", i)
+		}
+
+		if err := game.processMove(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if game.outcome != Checkmate {
+		t.Fatalf("Expected checkmate, got %d", game.outcome)
+	}
+}
+
+func TestCheckGameStatus_Stalemate(t *testing.T) {
+	game := newEmptyGame()
+	game.putCell(Piece{'K', Black}, Position{7, 7})
+	game.putCell(Piece{'K', White}, Position{6, 5})
+	game.putCell(Piece{'Q', White}, Position{4, 6})
+
+	move := Move{Source: Cell{Piece{'Q', White}, Position{4, 6}}, Target: Cell{Position: Position{5, 6}}, Action: Movement}
+	if err := game.processMove(move); err != nil {
+		t.Fatal(err)
+	}
+
+	if game.outcome != Stalemate {
+		t.Fatalf("Expected stalemate, got %d", game.outcome)
+	}
+}
+
+func TestCheckGameStatus_NoOutcome(t *testing.T) {
+	game := NewGame()
+
+	moves := []Move{
+		{Source: Cell{Piece{'P', White}, Position{1, 4}}, Target: Cell{Position: Position{3, 4}}, Action: Movement},
+		{Source: Cell{Piece{'P', Black}, Position{6, 4}}, Target: Cell{Position: Position{4, 4}}, Action: Movement},
+	}
+
+	for _, move := range moves {
+		if err := game.processMove(move); err != nil {
+			t.Fatal(err)
+		}
+
+		if game.outcome != NoOutcome {
+			t.Fatalf("Unexpected outcome %d after move %v", game.outcome, move)
+		}
+	}
+}
+
+func TestGame_HalfmoveClock(t *testing.T) {
+	game := NewGame()
+	playMoves(t, &game, "g1f3", "g8f6", "f3g1")
+	if clock := game.HalfmoveClock(); clock != 3 {
+		t.Fatalf("Unexpected clock %d", clock)
+	}
+
+	playMoves(t, &game, "e7e5", "b1c3", "f6e4", "c3e4")
+	if clock := game.HalfmoveClock(); clock != 0 {
+		t.Fatalf("Unexpected clock %d", clock)
+	}
+}
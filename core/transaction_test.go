@@ -0,0 +1,116 @@
+package core
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+var fuzzActions = []Action{Movement, Capture, Promotion, Enpassant, KingCastling, QueenCastling, Action("?")}
+
+// Returns a random, mostly illegal move. Positions may lie outside the board
+func randomMove(game *Game, rnd *rand.Rand) Move {
+	// Moves which follow the movement rules are the most interesting ones
+	if candidates := game.getCandidateMoves(); rnd.Intn(2) == 0 && len(candidates) > 0 {
+		return candidates[rnd.Intn(len(candidates))]
+	}
+
+	randomCell := func() Cell {
+		pos := Position{row: rnd.Intn(10) - 1, col: rnd.Intn(10) - 1}
+		if isValidPosition(pos.col, pos.row) && rnd.Intn(2) == 0 {
+			return Cell{game.Board[pos.row][pos.col], pos}
+		}
+		return Cell{Piece{Figure("PNBRQK"[rnd.Intn(6)]), []Side{White, Black}[rnd.Intn(2)]}, pos}
+	}
+
+	return Move{Source: randomCell(), Target: randomCell(), Action: fuzzActions[rnd.Intn(len(fuzzActions))]}
+}
+
+// Checks that an illegal move leaves the game untouched
+func checkMoveIsAtomic(t *testing.T, game *Game, move Move) bool {
+	before := game.clone()
+	board := game.Board
+
+	if err := game.processMove(move); err == nil {
+		return true
+	}
+
+	if !reflect.DeepEqual(before, *game) {
+		t.Logf("game was changed by illegal move %v", move)
+		return false
+	}
+
+	if &board[0] != &game.Board[0] {
+		t.Logf("board was reallocated by illegal move %v", move)
+		return false
+	}
+
+	return true
+}
+
+func TestProcessMove_IllegalMoveIsAtomic(t *testing.T) {
+	property := func(seed int64) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		game := NewGame()
+
+		for range 60 {
+			for range 20 {
+				if !checkMoveIsAtomic(t, &game, randomMove(&game, rnd)) {
+					return false
+				}
+			}
+
+			// Continue the game with a legal move
+			moves := game.LegalMoves()
+			if len(moves) == 0 || game.outcome != NoOutcome {
+				break
+			}
+			if err := game.processMove(moves[rnd.Intn(len(moves))]); err != nil {
+				t.Log(err)
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func FuzzProcessMove(f *testing.F) {
+	f.Add([]byte{0x14, 0x34, 0, 0, 0x64, 0x44, 0, 0})
+	f.Add([]byte{0x15, 0x25, 0, 0, 0x64, 0x44, 0, 0, 0x16, 0x36, 0, 0, 0x73, 0x37, 0, 0})
+	f.Add([]byte{0x00, 0x00, 4, 0, 0x99, 0x99, 2, 1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		game := NewGame()
+
+		// Every 4 bytes describe a move: source, target, action and promotion figure
+		for ; len(data) >= 4; data = data[4:] {
+			source := Position{row: int(data[0]>>4)%10 - 1, col: int(data[0]&0xf)%10 - 1}
+			target := Position{row: int(data[1]>>4)%10 - 1, col: int(data[1]&0xf)%10 - 1}
+
+			move := Move{
+				Source: Cell{Position: source},
+				Target: Cell{Position: target},
+				Action: fuzzActions[int(data[2])%len(fuzzActions)],
+			}
+			if isValidPosition(source.col, source.row) {
+				move.Source.Piece = game.Board[source.row][source.col]
+			}
+			if isValidPosition(target.col, target.row) {
+				move.Target.Piece = game.Board[target.row][target.col]
+			}
+			if move.Action == Promotion {
+				move.Target.Piece = Piece{PromotionFigures[int(data[3])%len(PromotionFigures)], move.Source.side}
+			}
+
+			if !checkMoveIsAtomic(t, &game, move) {
+				t.FailNow()
+			}
+		}
+	})
+}
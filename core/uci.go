@@ -0,0 +1,47 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns the move in the UCI notation like "e2e4", "e1g1" or "e7e8q"
+func (g *Game) UCI(move Move) string {
+	move = g.CompleteMove(move)
+	res := move.Source.Position.String() + move.Target.Position.String()
+	if move.Action == Promotion {
+		res += strings.ToLower(string(move.Target.fig))
+	}
+	return res
+}
+
+// ParseUCI parses the legal move in the UCI notation, castling is the move of the king
+func (g *Game) ParseUCI(uci string) (Move, error) {
+	if len(uci) != 4 && len(uci) != 5 {
+		return Move{}, fmt.Errorf("invalid move: %q", uci)
+	}
+
+	source, err := ParseSquare(uci[:2])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move: %q", uci)
+	}
+	target, err := ParseSquare(uci[2:4])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move: %q", uci)
+	}
+
+	var promoted Figure
+	if len(uci) == 5 {
+		promoted = Figure(strings.ToUpper(uci[4:])[0])
+	}
+
+	return g.findLegal(uci, func(move Move) bool {
+		if move.Source.Position != source || move.Target.Position != target {
+			return false
+		}
+		if move.Action == Promotion {
+			return move.Target.fig == promoted
+		}
+		return promoted == 0
+	})
+}
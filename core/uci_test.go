@@ -0,0 +1,40 @@
+package core
+
+import "testing"
+
+func TestGame_UCI(t *testing.T) {
+	game := NewGame()
+	playMoves(t, &game, "e2e4", "d7d5", "e4d5", "c7c6", "d5c6", "g8f6", "c6b7", "e7e5", "g1f3", "f8d6", "f1e2", "e8g8")
+	tests := []struct {
+		uci, san string
+	}{
+		{"b7a8q", "bxa8=Q"},
+		{"b7a8n", "bxa8=N"},
+		{"e1g1", "O-O"},
+		{"f3e5", "Nxe5"},
+	}
+
+	for _, test := range tests {
+		move, err := game.ParseUCI(test.uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if san := game.SAN(move); san != test.san {
+			t.Fatalf("%s: expected %s, got %s", test.uci, test.san, san)
+		}
+		if uci := game.UCI(move); uci != test.uci {
+			t.Fatalf("%s: expected %s, got %s", test.san, test.uci, uci)
+		}
+	}
+
+	// Castling given by the action only
+	if uci := game.UCI(Move{Action: KingCastling}); uci != "e1g1" {
+		t.Fatalf("Expected e1g1, got %s", uci)
+	}
+
+	for _, uci := range []string{"e1c1", "b7b6", "b7a8", "b7a8k", "e2e4", "e9e8", "O-O", "f3e5q"} {
+		if _, err := game.ParseUCI(uci); err == nil {
+			t.Fatalf("%s: expected an error", uci)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package core
+
+// PlayLenient plays a move which may hold only source and target positions,
+// the pieces and the action are taken from the board, see CompleteMove
+func (g *Game) PlayLenient(move Move) error {
+	return g.processMove(g.CompleteMove(move))
+}
+
+// CompleteMove fills in the pieces of the move from the board.
+// If the action is empty it's inferred from the positions:
+// king moving 2 cells is castling, pawn reaching the last row is promoted,
+// pawn moving diagonally to an empty cell captures en passant.
+// Promotion keeps the claimed figure and defaults to a queen
+func (g *Game) CompleteMove(move Move) Move {
+	if isCastling(move) {
+		return castlingMove(g.whoseTurn(), move.Action)
+	}
+
+	if !isValidPosition(move.Source.col, move.Source.row) || !isValidPosition(move.Target.col, move.Target.row) {
+		return move
+	}
+
+	source := g.Board[move.Source.row][move.Source.col]
+	target := g.Board[move.Target.row][move.Target.col]
+	promoted := move.Target.fig
+
+	move.Source.Piece = source
+	move.Target.Piece = target
+
+	if move.Action == "" {
+		move.Action = inferAction(move)
+	}
+
+	if move.Action == Promotion {
+		if promoted == 0 {
+			promoted = Queen
+		}
+		move.Target.Piece = Piece{promoted, source.side}
+	}
+
+	if isCastling(move) {
+		return castlingMove(g.whoseTurn(), move.Action)
+	}
+
+	return move
+}
+
+// Infers the action of a move with the pieces taken from the board
+func inferAction(move Move) Action {
+	dcol := move.Target.col - move.Source.col
+
+	switch {
+	case move.Source.fig == King && move.Source.row == move.Target.row && dcol == 2:
+		return KingCastling
+	case move.Source.fig == King && move.Source.row == move.Target.row && dcol == -2:
+		return QueenCastling
+	case move.Source.fig == Pawn && move.Target.row == PromotionRows[move.Source.side]:
+		return Promotion
+	case move.Source.fig == Pawn && dcol != 0 && move.Target.Piece == Empty:
+		return Enpassant
+	case move.Target.Piece != Empty:
+		return Capture
+	}
+	return Movement
+}
+
+// Checks that the pieces claimed by the move match the board
+// and the moved piece belongs to the side to move
+func (g *Game) checkClaims(move Move) error {
+	if isCastling(move) {
+		// Castling may be given only by the action
+		if move.Source.Piece == Empty {
+			return nil
+		}
+
+		expected := castlingMove(g.whoseTurn(), move.Action)
+		if move.Source != expected.Source || move.Target.Position != expected.Target.Position {
+			err := newIllegalMoveError(ReasonPieceMismatch, move)
+			if isValidPosition(move.Source.col, move.Source.row) {
+				err.Actual = &Cell{g.Board[move.Source.row][move.Source.col], move.Source.Position}
+			}
+			return err
+		}
+		return nil
+	}
+
+	source := g.Board[move.Source.row][move.Source.col]
+	if source == Empty && move.Source.Piece == Empty {
+		return newIllegalMoveError(ReasonCellEmpty, move)
+	}
+
+	if source != move.Source.Piece {
+		err := newIllegalMoveError(ReasonPieceMismatch, move)
+		err.Actual = &Cell{source, move.Source.Position}
+		return err
+	}
+
+	if source.side != g.whoseTurn() {
+		return newIllegalMoveError(ReasonWrongTurn, move)
+	}
+
+	// Promotion claims the new piece, which is checked by the processor
+	if target := g.Board[move.Target.row][move.Target.col]; move.Action != Promotion && target != move.Target.Piece {
+		err := newIllegalMoveError(ReasonPieceMismatch, move)
+		err.Actual = &Cell{target, move.Target.Position}
+		return err
+	}
+
+	return nil
+}
+
+// Returns castling move of the side with king positions
+func castlingMove(side Side, action Action) Move {
+	kingRows := map[Side]int{Black: 7, White: 0}
+	kingCols := map[Action]int{KingCastling: 6, QueenCastling: 2}
+
+	return Move{
+		Source: Cell{Piece{King, side}, Position{row: kingRows[side], col: 4}},
+		Target: Cell{Position: Position{row: kingRows[side], col: kingCols[action]}},
+		Action: action,
+	}
+}
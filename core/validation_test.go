@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckClaims_PieceMismatch(t *testing.T) {
+	game := NewGame()
+
+	move := Move{
+		Source: Cell{Piece{'Q', White}, Position{1, 4}},
+		Target: Cell{Position: Position{2, 4}},
+		Action: Movement,
+	}
+
+	var illegal *IllegalMoveError
+	if err := game.processMove(move); !errors.As(err, &illegal) || illegal.Reason != ReasonPieceMismatch {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if illegal.Actual == nil || *illegal.Actual != (Cell{Piece{'P', White}, Position{1, 4}}) {
+		t.Fatalf("Unexpected actual cell %v", illegal.Actual)
+	}
+}
+
+func TestCheckClaims_TargetMismatch(t *testing.T) {
+	game := NewGame()
+	game.Board[2][3] = Piece{'N', Black}
+
+	move := Move{
+		Source: Cell{Piece{'P', White}, Position{1, 4}},
+		Target: Cell{Piece{'Q', Black}, Position{2, 3}},
+		Action: Capture,
+	}
+
+	var illegal *IllegalMoveError
+	if err := game.processMove(move); !errors.As(err, &illegal) || illegal.Reason != ReasonPieceMismatch {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	move.Target.Piece = Piece{'N', Black}
+	if err := game.processMove(move); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckClaims_WrongTurn(t *testing.T) {
+	game := NewGame()
+
+	move := Move{
+		Source: Cell{Piece{'P', Black}, Position{6, 4}},
+		Target: Cell{Position: Position{4, 4}},
+		Action: Movement,
+	}
+
+	if err := game.processMove(move); err == nil || err.Error() != "not your turn" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestPlayLenient(t *testing.T) {
+	game := NewGame()
+
+	squares := [][2]Position{
+		{{1, 4}, {3, 4}}, // e4
+		{{6, 3}, {4, 3}}, // d5
+		{{3, 4}, {4, 3}}, // exd5
+		{{6, 4}, {4, 4}}, // e5
+		{{4, 3}, {5, 4}}, // dxe6 e.p.
+		{{7, 1}, {5, 2}}, // Nc6
+		{{5, 4}, {6, 5}}, // exf7+
+		{{7, 4}, {6, 4}}, // Ke7
+		{{0, 6}, {2, 5}}, // Nf3
+		{{7, 2}, {3, 6}}, // Bg4
+		{{0, 5}, {3, 2}}, // Bc4
+		{{7, 3}, {6, 3}}, // Qd7
+		{{0, 4}, {0, 6}}, // O-O
+		{{6, 3}, {5, 3}}, // Qd6
+		{{6, 5}, {7, 6}}, // fxg8=Q
+	}
+
+	for _, sq := range squares {
+		move := Move{Source: Cell{Position: sq[0]}, Target: Cell{Position: sq[1]}}
+		if err := game.PlayLenient(move); err != nil {
+			t.Fatalf("Move %v: %v", sq, err)
+		}
+	}
+
+	actions := []Action{Movement, Movement, Capture, Movement, Enpassant, Movement, Capture,
+		Movement, Movement, Movement, Movement, Movement, KingCastling, Movement, Promotion}
+	for i, move := range game.moves {
+		if move.Action != actions[i] {
+			t.Fatalf("Move %d: expected action '%s', got '%s'", i, actions[i], move.Action)
+		}
+	}
+
+	if game.Board[7][6] != (Piece{'Q', White}) || game.Board[0][6] != (Piece{'K', White}) {
+		t.Fatalf("Moves weren't played correctly")
+	}
+}
+
+func TestCompleteMove_Promotion(t *testing.T) {
+	game := NewGame()
+	game.Board[6][0] = Piece{'P', White}
+	game.Board[7][0] = Empty
+
+	move := game.CompleteMove(Move{
+		Source: Cell{Position: Position{6, 0}},
+		Target: Cell{Piece{'N', 0}, Position{7, 0}},
+	})
+
+	expected := Move{
+		Source: Cell{Piece{'P', White}, Position{6, 0}},
+		Target: Cell{Piece{'N', White}, Position{7, 0}},
+		Action: Promotion,
+	}
+	if move != expected {
+		t.Fatalf("Unexpected move %v", move)
+	}
+}
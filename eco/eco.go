@@ -0,0 +1,180 @@
+// Package eco classifies games by their openings using the ECO codes
+package eco
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zzvanq/shahio/book"
+	"github.com/zzvanq/shahio/core"
+)
+
+// Table of the openings, a line holds the code, the name and the moves separated by tabs
+//
+//go:embed openings.tsv
+var table string
+
+// Opening is a named line of the ECO table
+type Opening struct {
+	// Code from A00 to E99
+	ECO  string
+	Name string
+	// Moves in SAN played from the initial position
+	Moves []string
+}
+
+func (o Opening) String() string {
+	return o.ECO + " " + o.Name
+}
+
+type index struct {
+	openings []Opening
+	// Openings by moves joined with spaces, prefixes of the lines map to nil
+	lines map[string]*Opening
+	// Openings by position key, the shortest line wins
+	positions map[uint64]*Opening
+}
+
+var (
+	indexOnce sync.Once
+	idx       *index
+)
+
+// Returns the index built from the table on the first use
+func load() *index {
+	indexOnce.Do(func() {
+		var err error
+		if idx, err = parse(table); err != nil {
+			panic(err)
+		}
+	})
+	return idx
+}
+
+func parse(text string) (*index, error) {
+	res := &index{lines: map[string]*Opening{}, positions: map[uint64]*Opening{}}
+
+	for i, row := range strings.Split(strings.TrimSpace(text), "\n") {
+		fields := strings.Split(row, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("eco: line %d: expected 3 fields, got %d", i+1, len(fields))
+		}
+
+		opening := Opening{ECO: fields[0], Name: fields[1]}
+		for _, tok := range strings.Fields(fields[2]) {
+			if !strings.HasSuffix(tok, ".") {
+				opening.Moves = append(opening.Moves, tok)
+			}
+		}
+		res.openings = append(res.openings, opening)
+	}
+
+	for i := range res.openings {
+		opening := &res.openings[i]
+
+		game := core.NewGame()
+		for j, san := range opening.Moves {
+			move, err := game.ParseSAN(san)
+			if err == nil {
+				err = game.Play(move)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("eco: %s %s: %s: %w", opening.ECO, opening.Name, san, err)
+			}
+
+			key := strings.Join(opening.Moves[:j+1], " ")
+			if _, ok := res.lines[key]; !ok {
+				res.lines[key] = nil
+			}
+		}
+
+		key := strings.Join(opening.Moves, " ")
+		if res.lines[key] == nil {
+			res.lines[key] = opening
+		}
+
+		pos := book.Key(&game)
+		if other, ok := res.positions[pos]; !ok || len(opening.Moves) < len(other.Moves) {
+			res.positions[pos] = opening
+		}
+	}
+	return res, nil
+}
+
+// Returns every opening of the table
+func Openings() []Opening {
+	return load().openings
+}
+
+// Returns the opening reaching the position by any move order
+func Lookup(s core.Snapshot) (Opening, bool) {
+	if opening, ok := load().positions[book.SnapshotKey(s)]; ok {
+		return *opening, true
+	}
+	return Opening{}, false
+}
+
+// Classify returns the deepest opening of the game, the one matched at the latest move
+func Classify(game *core.Game) (Opening, bool) {
+	c := NewClassifier(game.Start())
+	for _, move := range game.Moves() {
+		if err := c.Play(move); err != nil {
+			break
+		}
+	}
+	return c.Opening()
+}
+
+// Classifier keeps the opening of a game while it's played
+type Classifier struct {
+	game core.Game
+	// Moves played while they follow a line of the table
+	line    []string
+	inTable bool
+	opening *Opening
+}
+
+// Returns a classifier of a game started from the position. Move orders are
+// only matched from the initial position, transpositions are matched from any
+func NewClassifier(start core.Snapshot) *Classifier {
+	c := &Classifier{game: start.Game(), inTable: start == core.InitialSnapshot()}
+	if opening, ok := load().positions[book.SnapshotKey(start)]; ok {
+		c.opening = opening
+	}
+	return c
+}
+
+// Play plays the move and updates the opening. The line of the moves wins
+// over a transposition into another line
+func (c *Classifier) Play(move core.Move) error {
+	san := c.game.SAN(move)
+	if err := c.game.Play(move); err != nil {
+		return err
+	}
+
+	idx := load()
+	if c.inTable {
+		c.line = append(c.line, san)
+		opening, ok := idx.lines[strings.Join(c.line, " ")]
+		if opening != nil {
+			c.opening = opening
+			return nil
+		}
+		c.inTable = ok
+	}
+
+	if opening, ok := idx.positions[book.Key(&c.game)]; ok {
+		c.opening = opening
+	}
+	return nil
+}
+
+// Returns the deepest opening matched so far
+func (c *Classifier) Opening() (Opening, bool) {
+	if c.opening == nil {
+		return Opening{}, false
+	}
+	return *c.opening, true
+}
@@ -0,0 +1,132 @@
+package eco
+
+import (
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+func play(t *testing.T, start core.Snapshot, sans ...string) core.Game {
+	t.Helper()
+
+	game := start.Game()
+	for _, san := range sans {
+		move, err := game.ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return game
+}
+
+func TestOpenings(t *testing.T) {
+	codes := map[string]bool{}
+	for _, opening := range Openings() {
+		if len(opening.Moves) == 0 || opening.Name == "" {
+			t.Fatalf("Incomplete opening %v", opening)
+		}
+		codes[opening.ECO] = true
+	}
+
+	for _, volume := range "ABCDE" {
+		for n := range 100 {
+			if code := string(volume) + string(rune('0'+n/10)) + string(rune('0'+n%10)); !codes[code] {
+				t.Fatalf("Missing code %s", code)
+			}
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		moves []string
+		eco   string
+		name  string
+	}{
+		{[]string{"e4"}, "B00", "King's Pawn Game"},
+		{[]string{"e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3", "a6"}, "B90", "Sicilian Defense: Najdorf Variation"},
+		// The game left the table, the last matched line stays
+		{[]string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "b5", "Bb3", "Na5"}, "C70", "Ruy Lopez: Morphy Defense, Caro Gambit"},
+		// Move order of the English, position of the Queen's Gambit Declined
+		{[]string{"c4", "e6", "Nc3", "d5", "d4", "Nf6"}, "D35", "Queen's Gambit Declined: Normal Defense"},
+		// Line of the table wins over the transposition
+		{[]string{"Nf3", "d5", "g3"}, "A07", "King's Indian Attack"},
+	}
+
+	for _, test := range tests {
+		game := play(t, core.InitialSnapshot(), test.moves...)
+		opening, ok := Classify(&game)
+		if !ok || opening.ECO != test.eco || opening.Name != test.name {
+			t.Fatalf("%v: expected %s %s, got %v", test.moves, test.eco, test.name, opening)
+		}
+	}
+
+	game := core.NewGame()
+	if opening, ok := Classify(&game); ok {
+		t.Fatalf("Expected no opening, got %v", opening)
+	}
+}
+
+func TestClassifier(t *testing.T) {
+	c := NewClassifier(core.InitialSnapshot())
+	game := core.NewGame()
+
+	steps := []struct{ san, eco string }{
+		{"d4", "A40"}, {"Nf6", "A45"}, {"c4", "A50"}, {"g6", "E60"}, {"Nc3", "E61"},
+		{"Bg7", "E61"}, {"e4", "E70"}, {"d6", "E70"}, {"Nf3", "E90"},
+	}
+	for _, step := range steps {
+		move, err := game.ParseSAN(step.san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		game.Play(move)
+		if err := c.Play(move); err != nil {
+			t.Fatal(err)
+		}
+
+		if opening, ok := c.Opening(); !ok || opening.ECO != step.eco {
+			t.Fatalf("%s: expected %s, got %v", step.san, step.eco, opening)
+		}
+	}
+
+	// Transpositions are matched from a set up position
+	game = play(t, core.InitialSnapshot(), "d4", "Nf6", "c4")
+	s := game.Snapshot()
+	c = NewClassifier(s)
+	if opening, ok := c.Opening(); !ok || opening.ECO != "A50" {
+		t.Fatalf("Expected A50 at the start, got %v", opening)
+	}
+	game = s.Game()
+	for _, san := range []string{"e6", "Nc3", "Bb4"} {
+		move, _ := game.ParseSAN(san)
+		game.Play(move)
+		c.Play(move)
+	}
+	if opening, ok := c.Opening(); !ok || opening.Name != "Nimzo-Indian Defense" {
+		t.Fatalf("Expected Nimzo-Indian Defense, got %v", opening)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	game := play(t, core.InitialSnapshot(), "Nf3", "Nf6", "c4", "e6", "d4", "b6")
+	if opening, ok := Lookup(game.Snapshot()); !ok || opening.ECO != "E12" {
+		t.Fatalf("Expected E12, got %v", opening)
+	}
+
+	game = play(t, core.InitialSnapshot(), "a4", "h5")
+	if _, ok := Lookup(game.Snapshot()); ok {
+		t.Fatal("Expected no opening")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, text := range []string{"A00\tPolish Opening", "A00\tPolish Opening\t1. b5"} {
+		if _, err := parse(text); err == nil {
+			t.Fatalf("%q: expected an error", text)
+		}
+	}
+}
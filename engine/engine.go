@@ -0,0 +1,329 @@
+// Package engine searches the best moves of games by alpha-beta search
+// with iterative deepening, a transposition table and quiescence search.
+// Searches may run on several threads sharing the table (Lazy SMP)
+package engine
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/zzvanq/shahio/book"
+	"github.com/zzvanq/shahio/core"
+)
+
+const (
+	// Score of the side which mates on the board, mate in n plies scores MateScore-n
+	MateScore = 32000
+	// Plies searched at most, quiescence search included
+	maxPly = 128
+	// Scores beyond it are mates
+	mateBound = MateScore - maxPly
+	infinity  = MateScore + 1
+)
+
+// Result of a search, the score is in centipawns from the side to move
+type Result struct {
+	Move  core.Move
+	Score int
+	// Expected line starting with the move
+	Line  []core.Move
+	Depth int
+	Nodes int
+}
+
+// Returns number of moves to mate of the score, positive if the side to move mates,
+// negative if it gets mated and zero if the score isn't a mate
+func MateIn(score int) int {
+	switch {
+	case score > mateBound:
+		return (MateScore - score + 1) / 2
+	case score < -mateBound:
+		return -(MateScore + score) / 2
+	}
+	return 0
+}
+
+// Engine keeps the transposition table between searches,
+// so positions of the same game are searched faster.
+// It may search several games at once
+type Engine struct {
+	threads int
+	table   *table
+}
+
+type Options struct {
+	// Threads searching the game at once, 1 by default.
+	// The search by a single thread is deterministic
+	Threads int
+}
+
+func New(opts Options) *Engine {
+	return &Engine{threads: max(opts.Threads, 1), table: newTable(tableSize)}
+}
+
+// Search returns the best move of the game searched to the depth.
+// When ctx is done the result of the last completed depth is returned,
+// the game without legal moves has no best move.
+// Every thread searches its own copy of the game, the result is the one of the main thread
+func (e *Engine) Search(ctx context.Context, game *core.Game, depth int) Result {
+	keys := history(game)
+	helpersCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	helpers := make([]*searcher, e.threads-1)
+	for i := range helpers {
+		helpers[i] = &searcher{ctx: helpersCtx, table: e.table, history: slices.Clone(keys)}
+		pos := game.Clone()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Helpers start at different depths to search different parts of the tree
+			helpers[i].deepen(&pos, 1+(i+1)%2, depth)
+		}()
+	}
+
+	s := &searcher{ctx: ctx, table: e.table, history: keys}
+	pos := game.Clone()
+	res := s.deepen(&pos, 1, depth)
+
+	cancel()
+	wg.Wait()
+	for _, h := range helpers {
+		res.Nodes += h.nodes
+	}
+	return res
+}
+
+type searcher struct {
+	ctx     context.Context
+	table   *table
+	nodes   int
+	stopped bool
+	// Keys of the positions of the game and of the searched line
+	history []uint64
+}
+
+// Searches the game by iterative deepening from the depth till the last one
+func (s *searcher) deepen(game *core.Game, from, depth int) Result {
+	res := Result{}
+	for d := min(from, max(depth, 1)); d <= max(depth, 1); d++ {
+		if d > from && s.ctx.Err() != nil {
+			break
+		}
+
+		score, line := s.search(game, d, 0, -infinity, infinity)
+		if s.stopped && d > from {
+			break
+		}
+
+		res.Score, res.Line, res.Depth = score, line, d
+		if len(line) > 0 {
+			res.Move = line[0]
+		}
+		if len(line) == 0 || s.stopped {
+			break
+		}
+	}
+
+	res.Nodes = s.nodes
+	return res
+}
+
+// Returns keys of the positions the game went through
+func history(game *core.Game) []uint64 {
+	g := game.Start().Game()
+	var res []uint64
+	for _, move := range game.Moves() {
+		res = append(res, book.Key(&g))
+		g.Play(move)
+	}
+	return res
+}
+
+// Checks the search has to stop, the context is checked every 1024 nodes
+func (s *searcher) stop() bool {
+	s.nodes++
+	if s.nodes%1024 == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	return s.stopped
+}
+
+// Returns score of the game and the line found by the negamax search
+func (s *searcher) search(game *core.Game, depth, ply, alpha, beta int) (int, []core.Move) {
+	if s.stop() {
+		return 0, nil
+	}
+
+	switch game.Outcome() {
+	case core.Checkmate:
+		return -MateScore + ply, nil
+	case core.Stalemate:
+		return 0, nil
+	}
+
+	key := book.Key(game)
+	if ply > 0 && (slices.Contains(s.history, key) || game.HalfmoveClock() >= 100) {
+		return 0, nil
+	}
+	if depth <= 0 || ply >= maxPly {
+		return s.quiesce(game, ply, alpha, beta), nil
+	}
+
+	moves := game.LegalMoves()
+	cached, found := s.table.load(key, game)
+	// Entry of another position sharing the slot may have a move illegal here
+	if found && !slices.Contains(moves, cached.move) {
+		cached, found = entry{}, false
+	}
+	if found && ply > 0 && cached.depth >= depth {
+		score := fromTable(cached.score, ply)
+		switch {
+		case cached.bound == exact,
+			cached.bound == lower && score >= beta,
+			cached.bound == upper && score <= alpha:
+			return score, []core.Move{cached.move}
+		}
+	}
+
+	s.history = append(s.history, key)
+	defer func() { s.history = s.history[:len(s.history)-1] }()
+
+	origAlpha := alpha
+	best, line := -infinity, []core.Move(nil)
+	for _, move := range order(game, moves, cached.move) {
+		next := game.Clone()
+		next.Play(move)
+
+		score, rest := s.search(&next, depth-1, ply+1, -beta, -alpha)
+		score = -score
+		if s.stopped {
+			return 0, nil
+		}
+
+		if score > best {
+			best, line = score, append([]core.Move{move}, rest...)
+		}
+		alpha = max(alpha, score)
+		if alpha >= beta {
+			break
+		}
+	}
+
+	b := exact
+	switch {
+	case best <= origAlpha:
+		b = upper
+	case best >= beta:
+		b = lower
+	}
+	s.table.store(key, entry{depth: depth, score: toTable(best, ply), bound: b, move: line[0]})
+
+	return best, line
+}
+
+// Searches captures and promotions till the position is quiet,
+// positions in check are searched by all moves
+func (s *searcher) quiesce(game *core.Game, ply, alpha, beta int) int {
+	if s.stop() {
+		return 0
+	}
+
+	switch game.Outcome() {
+	case core.Checkmate:
+		return -MateScore + ply
+	case core.Stalemate:
+		return 0
+	}
+
+	check := game.InCheck()
+	if !check || ply >= maxPly {
+		standPat := Evaluate(game)
+		if standPat >= beta || ply >= maxPly {
+			return standPat
+		}
+		alpha = max(alpha, standPat)
+	}
+
+	for _, move := range order(game, game.LegalMoves(), core.Move{}) {
+		if !check && (move.Action != core.Capture && move.Action != core.Enpassant && move.Action != core.Promotion || losing(game, move)) {
+			continue
+		}
+
+		next := game.Clone()
+		next.Play(move)
+
+		score := -s.quiesce(&next, ply+1, -beta, -alpha)
+		if s.stopped {
+			return 0
+		}
+		if score >= beta {
+			return score
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha
+}
+
+// Mate scores are stored relative to the position
+func toTable(score, ply int) int {
+	switch {
+	case score > mateBound:
+		return score + ply
+	case score < -mateBound:
+		return score - ply
+	}
+	return score
+}
+
+func fromTable(score, ply int) int {
+	switch {
+	case score > mateBound:
+		return score - ply
+	case score < -mateBound:
+		return score + ply
+	}
+	return score
+}
+
+// Sorts the moves by the move of the table, then the captures of the most valuable pieces
+// by the least valuable ones, then the promotions, the captures losing material go last
+func order(game *core.Game, moves []core.Move, first core.Move) []core.Move {
+	ranks := make(map[core.Move]int, len(moves))
+	for _, move := range moves {
+		switch move.Action {
+		case core.Capture:
+			ranks[move] = 10*values[move.Target.Figure()] - values[move.Source.Figure()]
+			if losing(game, move) {
+				ranks[move] = game.SEE(move)
+			}
+		case core.Enpassant:
+			ranks[move] = 9 * values[core.Pawn]
+		case core.Promotion:
+			victim := game.Board[move.Target.Row()][move.Target.Col()]
+			ranks[move] = 10*values[victim.Figure()] + values[move.Target.Figure()]
+		}
+	}
+
+	slices.SortStableFunc(moves, func(a, b core.Move) int {
+		if a == first || b == first {
+			if a == b {
+				return 0
+			}
+			if a == first {
+				return -1
+			}
+			return 1
+		}
+		return ranks[b] - ranks[a]
+	})
+	return moves
+}
+
+// Checks the capture loses material by the exchange on the target,
+// the captures of pieces as valuable as the capturing one never lose
+func losing(game *core.Game, move core.Move) bool {
+	return move.Action == core.Capture && values[move.Target.Figure()] < values[move.Source.Figure()] && game.SEE(move) < 0
+}
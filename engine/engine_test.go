@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/zzvanq/shahio/book"
+	"github.com/zzvanq/shahio/core"
+)
+
+func newGame(t *testing.T, fen string) core.Game {
+	t.Helper()
+
+	s, err := core.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Game()
+}
+
+func TestEvaluate(t *testing.T) {
+	game := core.NewGame()
+	if score := Evaluate(&game); score != 0 {
+		t.Fatalf("Initial position: expected 0, got %d", score)
+	}
+
+	// Same position with the colors flipped scores the same for the side to move
+	white := newGame(t, "4k3/8/8/8/8/8/4P3/R3K3 w - - 0 1")
+	black := newGame(t, "r3k3/4p3/8/8/8/8/8/4K3 b - - 0 1")
+	if Evaluate(&white) != Evaluate(&black) || Evaluate(&white) < 500 {
+		t.Fatalf("Expected equal winning scores, got %d and %d", Evaluate(&white), Evaluate(&black))
+	}
+}
+
+func TestEngine_Search(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth int
+		san   string
+		mate  int
+	}{
+		{"mate in one", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 2, "Ra8#", 1},
+		{"mate in two", "kbK5/pp6/1P6/8/8/8/8/R7 w - - 0 1", 4, "Ra6", 2},
+		{"gets mated", "8/8/8/8/7P/1k6/3n3r/K7 w - - 0 1", 2, "", -1},
+		{"forks the king and the queen", "q3k3/8/8/1N6/8/8/8/4K3 w - - 0 1", 3, "Nc7+", 0},
+		{"takes the hanging rook", "4k3/8/8/3r4/8/8/3Q4/4K3 w - - 0 1", 2, "Qxd5", 0},
+	}
+
+	for _, test := range tests {
+		game := newGame(t, test.fen)
+		res := New(Options{}).Search(context.Background(), &game, test.depth)
+
+		if test.san != "" && game.SAN(res.Move) != test.san {
+			t.Fatalf("%s: expected %s, got %s", test.name, test.san, game.SAN(res.Move))
+		}
+		if MateIn(res.Score) != test.mate {
+			t.Fatalf("%s: expected mate in %d, got %d", test.name, test.mate, MateIn(res.Score))
+		}
+		if res.Depth != test.depth || res.Nodes == 0 {
+			t.Fatalf("%s: unexpected depth %d and nodes %d", test.name, res.Depth, res.Nodes)
+		}
+
+		// Line is played from the game
+		if len(res.Line) == 0 || res.Line[0] != res.Move {
+			t.Fatalf("%s: unexpected line %v", test.name, res.Line)
+		}
+		for _, move := range res.Line {
+			if err := game.Play(move); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+	}
+}
+
+func TestEngine_SearchEnded(t *testing.T) {
+	game := newGame(t, "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1")
+	res := New(Options{}).Search(context.Background(), &game, 3)
+	if len(res.Line) != 0 || res.Score != -MateScore {
+		t.Fatalf("Unexpected result %v", res)
+	}
+}
+
+func TestEngine_SearchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	game := core.NewGame()
+	res := New(Options{}).Search(ctx, &game, 10)
+	if res.Depth != 1 || len(res.Line) == 0 {
+		t.Fatalf("Expected a result of the first depth, got %v", res)
+	}
+}
+
+func TestEngine_SearchThreads(t *testing.T) {
+	tests := []struct {
+		fen   string
+		depth int
+		mate  int
+	}{
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 2, 1},
+		{"kbK5/pp6/1P6/8/8/8/8/R7 w - - 0 1", 4, 2},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", 3, 0},
+	}
+
+	for _, test := range tests {
+		game := newGame(t, test.fen)
+
+		// Single thread finds the same result every time
+		res := New(Options{Threads: 1}).Search(context.Background(), &game, test.depth)
+		if again := New(Options{Threads: 1}).Search(context.Background(), &game, test.depth); !reflect.DeepEqual(res, again) {
+			t.Fatalf("%s: expected %v, got %v", test.fen, res, again)
+		}
+
+		parallel := New(Options{Threads: 4}).Search(context.Background(), &game, test.depth)
+		if MateIn(parallel.Score) != test.mate || parallel.Depth != test.depth || parallel.Nodes == 0 {
+			t.Fatalf("%s: unexpected result %v", test.fen, parallel)
+		}
+		for _, move := range parallel.Line {
+			if err := game.Play(move); err != nil {
+				t.Fatalf("%s: %v", test.fen, err)
+			}
+		}
+	}
+}
+
+func TestEngine_SearchFiftyMoves(t *testing.T) {
+	// Rook can't win once the clock of the start position runs out
+	game := newGame(t, "k7/8/8/8/8/2K5/8/7R w - - 0 1")
+	if res := New(Options{}).Search(context.Background(), &game, 2); res.Score < 500 {
+		t.Fatalf("Expected a winning score, got %d", res.Score)
+	}
+
+	game = newGame(t, "k7/8/8/8/8/2K5/8/7R w - - 100 80")
+	if res := New(Options{}).Search(context.Background(), &game, 2); res.Score != 0 {
+		t.Fatalf("Expected a draw, got %d", res.Score)
+	}
+}
+
+func TestEngine_SearchCollisions(t *testing.T) {
+	game := newGame(t, "k7/8/8/8/8/2K5/8/7R w - - 0 1")
+	e := New(Options{})
+
+	// Entries of other positions in the slots of the positions after the moves
+	source, _ := core.NewPosition(3, 4)
+	target, _ := core.NewPosition(4, 4)
+	illegal := core.Move{Source: core.Cell{Position: source}, Target: core.Cell{Position: target}}
+	for _, move := range game.LegalMoves() {
+		next := game.Clone()
+		next.Play(move)
+		e.table.store(book.Key(&next), entry{depth: 10, score: MateScore - 1, bound: exact, move: illegal})
+	}
+
+	res := e.Search(context.Background(), &game, 2)
+	if len(res.Line) != 2 {
+		t.Fatalf("Expected a line of 2 moves, got %v", res.Line)
+	}
+	for _, move := range res.Line {
+		if err := game.Play(move); err != nil {
+			t.Fatalf("Illegal move %v of the line: %v", move, err)
+		}
+	}
+}
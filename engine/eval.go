@@ -0,0 +1,132 @@
+package engine
+
+import "github.com/zzvanq/shahio/core"
+
+// Values of the pieces in centipawns
+var values = map[core.Figure]int{
+	core.Pawn:   100,
+	core.Knight: 320,
+	core.Bishop: 330,
+	core.Rook:   500,
+	core.Queen:  900,
+	core.King:   0,
+}
+
+// Non-pawn material of both sides below which the kings go to the center
+const endgameMaterial = 1300
+
+// Bonuses of the pieces by their squares for white, the first row is the 8th rank
+var squares = map[core.Figure][64]int{
+	core.Pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	core.Knight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	core.Bishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	core.Rook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+	core.Queen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	core.King: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+}
+
+var endgameKing = [64]int{
+	-50, -40, -30, -20, -20, -30, -40, -50,
+	-30, -20, -10, 0, 0, -10, -20, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -30, 0, 0, 0, 0, -30, -30,
+	-50, -30, -30, -30, -30, -30, -30, -50,
+}
+
+// Evaluate returns static evaluation of the game in centipawns from the side to move
+// by the material and the squares of the pieces
+func Evaluate(game *core.Game) int {
+	score, material := 0, 0
+	var kings [2]int
+
+	for row := range game.Board {
+		for col, pic := range game.Board[row] {
+			if pic == core.Empty {
+				continue
+			}
+
+			// Tables are mirrored for black
+			sign, square := 1, (7-row)*8+col
+			if pic.Side() == core.Black {
+				sign, square = -1, row*8+col
+			}
+
+			if pic.Figure() == core.King {
+				kings[(1-sign)/2] = square
+				continue
+			}
+			if pic.Figure() != core.Pawn {
+				material += values[pic.Figure()]
+			}
+			score += sign * (values[pic.Figure()] + squares[pic.Figure()][square])
+		}
+	}
+
+	kingSquares := squares[core.King]
+	if material < endgameMaterial {
+		kingSquares = endgameKing
+	}
+	score += kingSquares[kings[0]] - kingSquares[kings[1]]
+
+	if game.Turn() == core.Black {
+		return -score
+	}
+	return score
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"sync/atomic"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Slots of the transposition table, a slot takes 16 bytes
+const tableSize = 1 << 20
+
+// Figures of the promotions in the packed moves, zero is no promotion
+var promotions = []core.Figure{0, core.Knight, core.Bishop, core.Rook, core.Queen}
+
+type bound uint8
+
+const (
+	exact bound = iota
+	lower
+	upper
+)
+
+type entry struct {
+	depth int
+	score int
+	bound bound
+	move  core.Move
+}
+
+// Transposition table shared by the threads of a search without locks.
+// A slot keeps the key xored with the data, so the torn writes of the threads
+// racing for the slot don't match the key and are ignored
+type table struct {
+	slots []slot
+}
+
+type slot struct {
+	check atomic.Uint64
+	data  atomic.Uint64
+}
+
+func newTable(size int) *table {
+	return &table{slots: make([]slot, size)}
+}
+
+// Returns the entry of the position of the game, the move is completed by the game
+func (t *table) load(key uint64, game *core.Game) (entry, bool) {
+	s := &t.slots[key%uint64(len(t.slots))]
+	data := s.data.Load()
+	if data == 0 || s.check.Load()^data != key {
+		return entry{}, false
+	}
+	return unpack(data, game), true
+}
+
+// Replaces the entry of the slot of the position
+func (t *table) store(key uint64, e entry) {
+	s := &t.slots[key%uint64(len(t.slots))]
+	data := pack(e)
+	s.data.Store(data)
+	s.check.Store(key ^ data)
+}
+
+// Packs the entry into the bits: 0-5 source, 6-11 target, 12-14 promotion,
+// 16-31 score, 32-39 depth, 40-41 bound, 42 is set for every entry
+func pack(e entry) uint64 {
+	source := e.move.Source.Row()*8 + e.move.Source.Col()
+	target := e.move.Target.Row()*8 + e.move.Target.Col()
+	promotion := 0
+	if e.move.Action == core.Promotion {
+		for i, fig := range promotions {
+			if fig == e.move.Target.Figure() {
+				promotion = i
+			}
+		}
+	}
+
+	return uint64(source) | uint64(target)<<6 | uint64(promotion)<<12 |
+		uint64(uint16(int16(e.score)))<<16 | uint64(uint8(e.depth))<<32 | uint64(e.bound)<<40 | 1<<42
+}
+
+func unpack(data uint64, game *core.Game) entry {
+	source, _ := core.NewPosition(int(data>>3&7), int(data&7))
+	target, _ := core.NewPosition(int(data>>9&7), int(data>>6&7))
+	move := core.Move{Source: core.Cell{Position: source}, Target: core.Cell{Position: target}}
+	if promotion := promotions[data>>12&7]; promotion != 0 {
+		move.Target.Piece, _ = core.NewPiece(promotion, game.Turn())
+	}
+
+	return entry{
+		depth: int(uint8(data >> 32)),
+		score: int(int16(uint16(data >> 16))),
+		bound: bound(data >> 40 & 3),
+		move:  game.CompleteMove(move),
+	}
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/zzvanq/shahio/book"
+	"github.com/zzvanq/shahio/core"
+)
+
+func TestTable(t *testing.T) {
+	tests := []struct {
+		fen   string
+		move  string
+		score int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1f3", 35},
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8n", -MateScore + 3},
+		{"r3k3/8/8/8/8/8/8/4K3 b q - 0 1", "e8c8", MateScore - 5},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", -120},
+	}
+
+	tt := newTable(1 << 4)
+	for _, test := range tests {
+		game := newGame(t, test.fen)
+		move, err := game.ParseUCI(test.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := book.Key(&game)
+		expected := entry{depth: 7, score: test.score, bound: lower, move: move}
+		tt.store(key, expected)
+		if got, ok := tt.load(key, &game); !ok || got != expected {
+			t.Fatalf("%s: expected %v, got %v", test.fen, expected, got)
+		}
+
+		// Other position of the slot
+		if _, ok := tt.load(key+1<<4, &game); ok {
+			t.Fatalf("%s: expected no entry", test.fen)
+		}
+	}
+
+	if _, ok := newTable(1<<4).load(0, &core.Game{}); ok {
+		t.Fatal("Expected no entry in the empty table")
+	}
+}
@@ -0,0 +1,335 @@
+// Package explorer indexes game collections by position to show what is played in them
+package explorer
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/zzvanq/shahio/book"
+	"github.com/zzvanq/shahio/core"
+	"github.com/zzvanq/shahio/internal/records"
+	"github.com/zzvanq/shahio/pgn"
+)
+
+const (
+	// Only the first plies of every game are indexed
+	MaxPly = 40
+	// Example games kept for a move
+	TopGames = 5
+)
+
+// Header of the index file
+const magic = "SHEX\x01"
+
+// GameInfo describes an indexed game
+type GameInfo struct {
+	// Number of the game in the index
+	ID           int
+	White, Black string
+	// Ratings of the players, zero if unknown
+	WhiteElo, BlackElo int
+	Result             string
+	Event, Date        string
+}
+
+// Returns the mean rating of the players, zero if both are unknown
+func (g GameInfo) Rating() int {
+	switch {
+	case g.WhiteElo > 0 && g.BlackElo > 0:
+		return (g.WhiteElo + g.BlackElo) / 2
+	case g.WhiteElo > 0:
+		return g.WhiteElo
+	default:
+		return g.BlackElo
+	}
+}
+
+// Move is a continuation played in the indexed games
+type Move struct {
+	Move  core.Move
+	Games int
+	// Games won by white, drawn and won by black
+	White, Draws, Black int
+	// Mean rating of the rated games, zero if none are rated
+	AverageRating int
+	// Highest rated games with the move, at most TopGames
+	TopGames []GameInfo
+}
+
+func (m Move) WhitePercent() float64 { return percent(m.White, m.Games) }
+func (m Move) DrawPercent() float64  { return percent(m.Draws, m.Games) }
+func (m Move) BlackPercent() float64 { return percent(m.Black, m.Games) }
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+type moveStats struct {
+	games, white, draws, black int
+	ratingSum, rated           int
+	// Ids of the highest rated games
+	top []int
+}
+
+// Index holds statistics of the moves by position. Games added to an opened
+// index are appended to its file, so it's never rewritten
+type Index struct {
+	games     []GameInfo
+	positions map[uint64]map[uint16]*moveStats
+	file      *records.File
+}
+
+// Returns an empty index kept in memory only
+func New() *Index {
+	return &Index{positions: map[uint64]map[uint16]*moveStats{}}
+}
+
+// Open loads the index file creating it if it doesn't exist, added games are appended to it
+func Open(path string) (*Index, error) {
+	ix := New()
+	file, err := records.Open(path, magic, func(record []byte, _ int64) error {
+		info, plies, err := decodeRecord(record)
+		if err != nil {
+			return err
+		}
+		info.ID = len(ix.games)
+		ix.add(info, plies)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("explorer: %s: %w", path, err)
+	}
+	ix.file = file
+	return ix, nil
+}
+
+func (ix *Index) Close() error {
+	if ix.file == nil {
+		return nil
+	}
+	return ix.file.Close()
+}
+
+// Returns the number of indexed games
+func (ix *Index) Len() int {
+	return len(ix.games)
+}
+
+// Returns the indexed game by its id
+func (ix *Index) Game(id int) (GameInfo, bool) {
+	if id < 0 || id >= len(ix.games) {
+		return GameInfo{}, false
+	}
+	return ix.games[id], true
+}
+
+// AddPGN adds every game of the collection and returns the number of added games
+func (ix *Index) AddPGN(r io.Reader) (int, error) {
+	games := pgn.NewReader(r)
+	n := 0
+	for {
+		game, err := games.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		added, err := ix.Add(game)
+		if err != nil {
+			return n, err
+		}
+		if added {
+			n++
+		}
+	}
+}
+
+// Add indexes the game, games without a result are skipped
+func (ix *Index) Add(game *pgn.Game) (bool, error) {
+	switch game.Result {
+	case "1-0", "0-1", "1/2-1/2":
+	default:
+		return false, nil
+	}
+
+	info := GameInfo{
+		ID:     len(ix.games),
+		White:  game.Tags["White"],
+		Black:  game.Tags["Black"],
+		Result: game.Result,
+		Event:  game.Tags["Event"],
+		Date:   game.Tags["Date"],
+	}
+	info.WhiteElo, _ = strconv.Atoi(game.Tags["WhiteElo"])
+	info.BlackElo, _ = strconv.Atoi(game.Tags["BlackElo"])
+	info.WhiteElo, info.BlackElo = max(info.WhiteElo, 0), max(info.BlackElo, 0)
+
+	var plies []ply
+	g := game.Start.Game()
+	for _, move := range game.Moves[:min(len(game.Moves), MaxPly)] {
+		plies = append(plies, ply{book.Key(&g), book.EncodeMove(move)})
+		if err := g.Play(move); err != nil {
+			return false, fmt.Errorf("explorer: %s game: %w", info.Event, err)
+		}
+	}
+
+	if ix.file != nil {
+		if _, err := ix.file.Append(encodeRecord(info, plies)); err != nil {
+			return false, err
+		}
+	}
+	ix.add(info, plies)
+	return true, nil
+}
+
+type ply struct {
+	key  uint64
+	move uint16
+}
+
+func (ix *Index) add(info GameInfo, plies []ply) {
+	ix.games = append(ix.games, info)
+
+	// Game counts once for a move in a position it repeats
+	seen := make(map[ply]bool, len(plies))
+	for _, p := range plies {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		moves := ix.positions[p.key]
+		if moves == nil {
+			moves = map[uint16]*moveStats{}
+			ix.positions[p.key] = moves
+		}
+		s := moves[p.move]
+		if s == nil {
+			s = &moveStats{}
+			moves[p.move] = s
+		}
+
+		s.games++
+		switch info.Result {
+		case "1-0":
+			s.white++
+		case "0-1":
+			s.black++
+		default:
+			s.draws++
+		}
+		if rating := info.Rating(); rating > 0 {
+			s.ratingSum += rating
+			s.rated++
+		}
+
+		// Earlier games win the ties
+		i := 0
+		for i < len(s.top) && ix.games[s.top[i]].Rating() >= info.Rating() {
+			i++
+		}
+		if i < TopGames {
+			s.top = slices.Insert(s.top, i, info.ID)
+			s.top = s.top[:min(len(s.top), TopGames)]
+		}
+	}
+}
+
+// Moves returns the moves played in the position of the game, most played first.
+// Moves that aren't legal in the position are skipped
+func (ix *Index) Moves(game *core.Game) []Move {
+	moves := ix.positions[book.Key(game)]
+	if len(moves) == 0 {
+		return nil
+	}
+
+	var res []Move
+	for _, move := range game.LegalMoves() {
+		s := moves[book.EncodeMove(move)]
+		if s == nil {
+			continue
+		}
+
+		m := Move{Move: move, Games: s.games, White: s.white, Draws: s.draws, Black: s.black}
+		if s.rated > 0 {
+			m.AverageRating = s.ratingSum / s.rated
+		}
+		for _, id := range s.top {
+			m.TopGames = append(m.TopGames, ix.games[id])
+		}
+		res = append(res, m)
+	}
+
+	slices.SortStableFunc(res, func(a, b Move) int { return cmp.Compare(b.Games, a.Games) })
+	return res
+}
+
+// Record of a game is the strings and the ratings of the game
+// and its plies, each of them is a position key and a move in the Polyglot format
+func encodeRecord(info GameInfo, plies []ply) []byte {
+	var buf []byte
+	for _, s := range []string{info.White, info.Black, info.Result, info.Event, info.Date} {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+	buf = binary.AppendUvarint(buf, uint64(info.WhiteElo))
+	buf = binary.AppendUvarint(buf, uint64(info.BlackElo))
+
+	buf = binary.AppendUvarint(buf, uint64(len(plies)))
+	for _, p := range plies {
+		buf = binary.BigEndian.AppendUint64(buf, p.key)
+		buf = binary.BigEndian.AppendUint16(buf, p.move)
+	}
+
+	return buf
+}
+
+func decodeRecord(buf []byte) (GameInfo, []ply, error) {
+	invalid := errors.New("invalid record")
+	uvarint := func() (int, error) {
+		n, size := binary.Uvarint(buf)
+		if size <= 0 || n > math.MaxInt32 {
+			return 0, invalid
+		}
+		buf = buf[size:]
+		return int(n), nil
+	}
+
+	var info GameInfo
+	for _, s := range []*string{&info.White, &info.Black, &info.Result, &info.Event, &info.Date} {
+		n, err := uvarint()
+		if err != nil || n > len(buf) {
+			return info, nil, invalid
+		}
+		*s, buf = string(buf[:n]), buf[n:]
+	}
+
+	var err error
+	if info.WhiteElo, err = uvarint(); err != nil {
+		return info, nil, err
+	}
+	if info.BlackElo, err = uvarint(); err != nil {
+		return info, nil, err
+	}
+
+	n, err := uvarint()
+	if err != nil || len(buf) != n*10 {
+		return info, nil, invalid
+	}
+	plies := make([]ply, n)
+	for i := range plies {
+		plies[i] = ply{binary.BigEndian.Uint64(buf[i*10:]), binary.BigEndian.Uint16(buf[i*10+8:])}
+	}
+	return info, plies, nil
+}
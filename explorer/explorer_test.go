@@ -0,0 +1,214 @@
+package explorer
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+const collection = `[White "A"]
+[Black "B"]
+[WhiteElo "2400"]
+[BlackElo "2200"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 1-0
+
+[White "C"]
+[Black "D"]
+[WhiteElo "2000"]
+[Result "0-1"]
+
+1. e4 c5 2. Nf3 d6 0-1
+
+[White "E"]
+[Black "F"]
+[Result "1/2-1/2"]
+
+1. e4 e5 2. Nf3 Nf6 1/2-1/2
+
+[White "G"]
+[Black "H"]
+[WhiteElo "2600"]
+[BlackElo "2600"]
+[Result "1-0"]
+
+1. d4 d5 2. c4 1-0
+
+[White "I"]
+[Black "J"]
+[Result "*"]
+
+1. e4 e5 *
+`
+
+func play(t *testing.T, sans ...string) core.Game {
+	t.Helper()
+
+	game := core.NewGame()
+	for _, san := range sans {
+		move, err := game.ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return game
+}
+
+type summary struct {
+	san                 string
+	games               int
+	white, draws, black int
+	rating              int
+	top                 []string
+}
+
+func summarize(game *core.Game, moves []Move) []summary {
+	var res []summary
+	for _, m := range moves {
+		s := summary{game.SAN(m.Move), m.Games, m.White, m.Draws, m.Black, m.AverageRating, nil}
+		for _, info := range m.TopGames {
+			s.top = append(s.top, info.White)
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func checkIndex(t *testing.T, ix *Index) {
+	t.Helper()
+
+	if ix.Len() != 4 {
+		t.Fatalf("Expected 4 games, got %d", ix.Len())
+	}
+
+	tests := []struct {
+		moves    []string
+		expected []summary
+	}{
+		{nil, []summary{
+			{"e4", 3, 1, 1, 1, 2150, []string{"A", "C", "E"}},
+			{"d4", 1, 1, 0, 0, 2600, []string{"G"}},
+		}},
+		{[]string{"e4", "e5", "Nf3"}, []summary{
+			{"Nc6", 1, 1, 0, 0, 2300, []string{"A"}},
+			{"Nf6", 1, 0, 1, 0, 0, []string{"E"}},
+		}},
+		{[]string{"e4", "e5", "Nf3", "Nc6", "Bb5"}, nil},
+	}
+
+	for _, test := range tests {
+		game := play(t, test.moves...)
+		got := summarize(&game, ix.Moves(&game))
+		equal := slices.EqualFunc(got, test.expected, func(a, b summary) bool {
+			return a.san == b.san && a.games == b.games && a.white == b.white && a.draws == b.draws &&
+				a.black == b.black && a.rating == b.rating && slices.Equal(a.top, b.top)
+		})
+		if !equal {
+			t.Fatalf("%v: expected %v, got %v", test.moves, test.expected, got)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	ix := New()
+	n, err := ix.AddPGN(strings.NewReader(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("Expected 4 added games, got %d", n)
+	}
+	checkIndex(t, ix)
+
+	game := play(t)
+	m := ix.Moves(&game)[0]
+	if m.WhitePercent()+m.DrawPercent()+m.BlackPercent() != 100 || m.DrawPercent() <= 33 || m.DrawPercent() >= 34 {
+		t.Fatalf("Unexpected percentages %v %v %v", m.WhitePercent(), m.DrawPercent(), m.BlackPercent())
+	}
+}
+
+func TestIndex_Repetition(t *testing.T) {
+	ix := New()
+	if _, err := ix.AddPGN(strings.NewReader("[White \"A\"]\n[Result \"1-0\"]\n\n1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 1-0\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repeated positions count the game once
+	game := play(t)
+	got := summarize(&game, ix.Moves(&game))
+	if len(got) != 1 || got[0].san != "Nf3" || got[0].games != 1 || got[0].white != 1 || !slices.Equal(got[0].top, []string{"A"}) {
+		t.Fatalf("Unexpected moves %v", got)
+	}
+}
+
+func TestIndex_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.idx")
+
+	// Games are added in two sessions
+	games := strings.SplitAfter(collection, "1-0\n")
+	for _, part := range []string{games[0] + games[1], strings.Join(games[2:], "")} {
+		ix, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ix.AddPGN(strings.NewReader(part)); err != nil {
+			t.Fatal(err)
+		}
+		if err := ix.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ix, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	checkIndex(t, ix)
+
+	if info, ok := ix.Game(3); !ok || info.White != "G" || info.Rating() != 2600 {
+		t.Fatalf("Unexpected game %v", info)
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "full.idx")
+	ix, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.AddPGN(strings.NewReader(collection)); err != nil {
+		t.Fatal(err)
+	}
+	ix.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string][]byte{
+		"header": []byte("PGN"),
+		// Size too large for a record
+		"corrupt": binary.AppendUvarint(slices.Clone(data), 1<<40),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
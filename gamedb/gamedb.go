@@ -0,0 +1,360 @@
+// Package gamedb stores games in a file and searches them by the players,
+// the dates, the results, the openings, the positions and the materials
+package gamedb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/zzvanq/shahio/book"
+	"github.com/zzvanq/shahio/core"
+	"github.com/zzvanq/shahio/eco"
+	"github.com/zzvanq/shahio/internal/records"
+	"github.com/zzvanq/shahio/pgn"
+)
+
+// Header of the database file
+const magic = "SHDB\x02"
+
+// Query selects games matching all of its fields, empty fields match any game
+type Query struct {
+	// Name of either player, matched case-insensitively as a part of the name
+	Player string
+	White  string
+	Black  string
+	// Dates in the PGN format like "2024.01.31", both are inclusive.
+	// Games with unknown dates don't match the limits
+	From, To string
+	Result   string
+	// Prefix of the code, "B9" matches B90 to B99
+	ECO string
+	// Games reaching the position
+	Position *core.Snapshot
+	// Games reaching the material like "KRPvKR", either side may have the first pieces
+	Material string
+}
+
+type entry struct {
+	offset       int64
+	size         int
+	white, black string
+	date, result string
+	eco          string
+}
+
+// DB is a file of games, the games are only appended to it.
+// Indexes are kept in memory and rebuilt when the file is opened
+type DB struct {
+	file    *records.File
+	entries []entry
+	// Ids of the games by the positions and the materials they reach
+	positions map[uint64][]int32
+	materials map[string][]int32
+}
+
+// Open loads the database file creating it if it doesn't exist
+func Open(path string) (*DB, error) {
+	db := &DB{positions: map[uint64][]int32{}, materials: map[string][]int32{}}
+	file, err := records.Open(path, magic, func(record []byte, offset int64) error {
+		game, err := decodeRecord(record)
+		if err != nil {
+			return err
+		}
+		db.index(game.Tags, game.Result, offset, len(record), game.Start, game.Moves)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gamedb: %s: %w", path, err)
+	}
+	db.file = file
+	return db, nil
+}
+
+func (db *DB) Close() error {
+	return db.file.Close()
+}
+
+// Returns the number of stored games
+func (db *DB) Len() int {
+	return len(db.entries)
+}
+
+// Import adds every game of the PGN collection and returns the number of added games
+func (db *DB) Import(r io.Reader) (int, error) {
+	games := pgn.NewReader(r)
+	n := 0
+	for {
+		game, err := games.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		if _, err := db.Add(game); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// Add stores the game and returns its id. Games without the ECO tag get it by their moves
+func (db *DB) Add(game *pgn.Game) (int, error) {
+	g, err := core.NewGameFromMoves(game.Start, game.Moves)
+	if err != nil {
+		return 0, fmt.Errorf("gamedb: %s game: %w", game.Tags["Event"], err)
+	}
+
+	tags := maps.Clone(game.Tags)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	if _, ok := tags["ECO"]; !ok {
+		if opening, ok := eco.Classify(&g); ok {
+			tags["ECO"] = opening.ECO
+		}
+	}
+
+	record := encodeRecord(&pgn.Game{Tags: tags, Start: game.Start, Result: game.Result}, g.CompactMoves())
+	offset, err := db.file.Append(record)
+	if err != nil {
+		return 0, err
+	}
+
+	id := len(db.entries)
+	db.index(tags, game.Result, offset, len(record), game.Start, game.Moves)
+	return id, nil
+}
+
+// Returns the stored game by its id
+func (db *DB) Game(id int) (*pgn.Game, error) {
+	if id < 0 || id >= len(db.entries) {
+		return nil, fmt.Errorf("gamedb: no game %d", id)
+	}
+
+	e := db.entries[id]
+	buf, err := db.file.ReadAt(e.offset, e.size)
+	if err != nil {
+		return nil, err
+	}
+
+	game, err := decodeRecord(buf)
+	if err != nil {
+		return nil, fmt.Errorf("gamedb: game %d: %w", id, err)
+	}
+	return game, nil
+}
+
+// Find returns ids of the games matching the query in the order they were added
+func (db *DB) Find(q Query) ([]int, error) {
+	// Positions and materials narrow down the games to check
+	var candidates []int32
+	narrowed := false
+	if q.Position != nil {
+		candidates, narrowed = db.positions[book.SnapshotKey(*q.Position)], true
+	}
+
+	if q.Material != "" {
+		material, err := core.ParseMaterial(q.Material)
+		if err != nil {
+			return nil, fmt.Errorf("gamedb: %w", err)
+		}
+		white, black, _ := strings.Cut(material, "v")
+
+		games := union(db.materials[white+"v"+black], db.materials[black+"v"+white])
+		if narrowed {
+			candidates = intersect(candidates, games)
+		} else {
+			candidates, narrowed = games, true
+		}
+	}
+
+	var res []int
+	match := func(id int) {
+		if db.entries[id].match(q) {
+			res = append(res, id)
+		}
+	}
+
+	if narrowed {
+		for _, id := range candidates {
+			match(int(id))
+		}
+	} else {
+		for id := range db.entries {
+			match(id)
+		}
+	}
+	return res, nil
+}
+
+func (e entry) match(q Query) bool {
+	contains := func(name, part string) bool {
+		return strings.Contains(strings.ToLower(name), strings.ToLower(part))
+	}
+
+	switch {
+	case q.Player != "" && !contains(e.white, q.Player) && !contains(e.black, q.Player):
+		return false
+	case q.White != "" && !contains(e.white, q.White):
+		return false
+	case q.Black != "" && !contains(e.black, q.Black):
+		return false
+	case (q.From != "" || q.To != "") && (e.date == "" || strings.HasPrefix(e.date, "?")):
+		return false
+	case q.From != "" && e.date < q.From:
+		return false
+	case q.To != "" && e.date > q.To:
+		return false
+	case q.Result != "" && e.result != q.Result:
+		return false
+	case q.ECO != "" && !strings.HasPrefix(e.eco, q.ECO):
+		return false
+	}
+	return true
+}
+
+// Merges sorted ids
+func union(a, b []int32) []int32 {
+	res := slices.Concat(a, b)
+	slices.Sort(res)
+	return slices.Compact(res)
+}
+
+func intersect(a, b []int32) []int32 {
+	var res []int32
+	for _, id := range a {
+		if _, found := slices.BinarySearch(b, id); found {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// Adds the game to the indexes, the moves must be legal
+func (db *DB) index(tags map[string]string, result string, offset int64, size int, start core.Snapshot, moves []core.Move) {
+	id := int32(len(db.entries))
+	db.entries = append(db.entries, entry{
+		offset: offset, size: size,
+		white: tags["White"], black: tags["Black"], date: tags["Date"], result: result, eco: tags["ECO"],
+	})
+
+	add := func(ids []int32) []int32 {
+		if len(ids) > 0 && ids[len(ids)-1] == id {
+			return ids
+		}
+		return append(ids, id)
+	}
+
+	g := start.Game()
+	material := ""
+	for i := 0; ; i++ {
+		s := g.Snapshot()
+		key := book.SnapshotKey(s)
+		db.positions[key] = add(db.positions[key])
+
+		// Material changes only by captures and promotions
+		if i == 0 || moves[i-1].Action != core.Movement {
+			if m := s.Material(); m != material {
+				material = m
+				db.materials[m] = add(db.materials[m])
+			}
+		}
+
+		if i == len(moves) {
+			break
+		}
+		g.Play(moves[i])
+	}
+}
+
+// Record of a game is the tags sorted by name,
+// the FEN of the start position, empty for the initial one, the result
+// and the moves in the compact encoding, see core.MoveEncoder
+func encodeRecord(game *pgn.Game, moves []byte) []byte {
+	var buf []byte
+	appendString := func(s string) {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(game.Tags)))
+	for _, name := range slices.Sorted(maps.Keys(game.Tags)) {
+		appendString(name)
+		appendString(game.Tags[name])
+	}
+
+	fen := ""
+	if game.Start != core.InitialSnapshot() {
+		fen = game.Start.FEN()
+	}
+	appendString(fen)
+	appendString(game.Result)
+	buf = append(buf, moves...)
+
+	return buf
+}
+
+var errInvalidRecord = errors.New("invalid record")
+
+func decodeRecord(buf []byte) (*pgn.Game, error) {
+	uvarint := func() (int, error) {
+		n, size := binary.Uvarint(buf)
+		if size <= 0 || n > math.MaxInt32 {
+			return 0, errInvalidRecord
+		}
+		buf = buf[size:]
+		return int(n), nil
+	}
+	readString := func() (string, error) {
+		n, err := uvarint()
+		if err != nil || n > len(buf) {
+			return "", errInvalidRecord
+		}
+		s := string(buf[:n])
+		buf = buf[n:]
+		return s, nil
+	}
+
+	game := &pgn.Game{Tags: map[string]string{}, Start: core.InitialSnapshot()}
+	count, err := uvarint()
+	if err != nil {
+		return nil, err
+	}
+	for range count {
+		name, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		if game.Tags[name], err = readString(); err != nil {
+			return nil, err
+		}
+	}
+
+	fen, err := readString()
+	if err != nil {
+		return nil, err
+	}
+	if fen != "" {
+		if game.Start, err = core.ParseFEN(fen); err != nil {
+			return nil, err
+		}
+	}
+	if game.Result, err = readString(); err != nil {
+		return nil, err
+	}
+
+	g, err := core.NewGameFromCompact(game.Start, buf)
+	if err != nil {
+		return nil, err
+	}
+	game.Moves = g.Moves()
+	return game, nil
+}
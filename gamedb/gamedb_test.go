@@ -0,0 +1,183 @@
+package gamedb
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+const collection = `[White "Carlsen, Magnus"]
+[Black "Nepomniachtchi, Ian"]
+[Date "2021.12.03"]
+[Result "1-0"]
+
+1. d4 Nf6 2. Nf3 d5 3. g3 e6 4. Bg2 Be7 5. O-O O-O 6. b3 c5 7. dxc5 Bxc5 1-0
+
+[White "Anand, Viswanathan"]
+[Black "Carlsen, Magnus"]
+[Date "2014.11.09"]
+[Result "0-1"]
+
+1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 0-1
+
+[White "Unknown"]
+[Black "Player"]
+[Date "????.??.??"]
+[Result "1/2-1/2"]
+[SetUp "1"]
+[FEN "8/8/3k4/3r4/8/3PK3/3R4/7q w - - 0 1"]
+
+1. Rh2 Rd4 2. Rxh1 Rxd3+ 3. Kxd3 1/2-1/2
+
+[White "Caruana, Fabiano"]
+[Black "Anand, Viswanathan"]
+[Date "2016.03.20"]
+[ECO "C65"]
+[Result "*"]
+
+1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 *
+`
+
+func open(t *testing.T) (*DB, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "games.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := db.Import(strings.NewReader(collection)); err != nil || n != 4 {
+		t.Fatalf("Imported %d games: %v", n, err)
+	}
+	return db, path
+}
+
+func snapshot(t *testing.T, fen string) *core.Snapshot {
+	t.Helper()
+
+	s, err := core.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestDB_Find(t *testing.T) {
+	db, _ := open(t)
+	defer db.Close()
+
+	najdorf := snapshot(t, "rnbqkb1r/1p2pppp/p2p1n2/8/3NP3/2N5/PPP2PPP/R1BQKB1R w KQkq - 0 6")
+	tests := []struct {
+		name  string
+		query Query
+		ids   []int
+	}{
+		{"all", Query{}, []int{0, 1, 2, 3}},
+		{"player", Query{Player: "carlsen"}, []int{0, 1}},
+		{"white", Query{White: "Anand"}, []int{1}},
+		{"black", Query{Black: "Anand"}, []int{3}},
+		{"dates", Query{From: "2014.01.01", To: "2020.12.31"}, []int{1, 3}},
+		{"result", Query{Result: "0-1"}, []int{1}},
+		{"eco", Query{ECO: "B9"}, []int{1}},
+		{"eco tag", Query{ECO: "C"}, []int{3}},
+		{"eco by transposition", Query{ECO: "A"}, []int{0}},
+		{"position", Query{Position: najdorf}, []int{1, 3}},
+		{"position and player", Query{Position: najdorf, Player: "Caruana"}, []int{3}},
+		{"material", Query{Material: "KRPvKR"}, []int{2}},
+		{"material of the other side", Query{Material: "KRvKPR"}, []int{2}},
+		{"material and position", Query{Material: "KRPvKR", Position: najdorf}, nil},
+		{"final material", Query{Material: "kvkr"}, []int{2}},
+		{"missing material", Query{Material: "KQvK"}, nil},
+	}
+
+	for _, test := range tests {
+		ids, err := db.Find(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(ids, test.ids) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.ids, ids)
+		}
+	}
+
+	for _, material := range []string{"KRP", "RvK", "KKvK", "KXvK"} {
+		if _, err := db.Find(Query{Material: material}); err == nil {
+			t.Fatalf("%s: expected an error", material)
+		}
+	}
+}
+
+func TestDB_Reopen(t *testing.T) {
+	db, path := open(t)
+	db.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if db.Len() != 4 {
+		t.Fatalf("Expected 4 games, got %d", db.Len())
+	}
+	if ids, _ := db.Find(Query{Material: "KRPvKR"}); !slices.Equal(ids, []int{2}) {
+		t.Fatalf("Unexpected games %v", ids)
+	}
+
+	game, err := db.Game(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Tags["White"] != "Unknown" || game.Result != "1/2-1/2" || len(game.Moves) != 5 ||
+		game.Start.FEN() != "8/8/3k4/3r4/8/3PK3/3R4/7q w - - 0 1" {
+		t.Fatalf("Unexpected game %v", game)
+	}
+
+	game, err = db.Game(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Tags["ECO"] != "B90" || game.Start != core.InitialSnapshot() || len(game.Moves) != 10 {
+		t.Fatalf("Unexpected game %v", game)
+	}
+
+	// Games are appended after the reopening
+	if n, err := db.Import(strings.NewReader(collection)); err != nil || n != 4 {
+		t.Fatalf("Imported %d games: %v", n, err)
+	}
+	if ids, _ := db.Find(Query{Player: "Caruana"}); !slices.Equal(ids, []int{3, 7}) {
+		t.Fatalf("Unexpected games %v", ids)
+	}
+	if _, err := db.Game(8); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	db, path := open(t)
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string][]byte{
+		"header": []byte("[Event"),
+		// Size too large for a record
+		"corrupt": binary.AppendUvarint(slices.Clone(data), 1<<40),
+	} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
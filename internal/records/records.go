@@ -0,0 +1,115 @@
+// Package records stores records of bytes in a file after its header,
+// every record is prefixed by its length
+package records
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Size of a record at most, larger ones are corrupt
+const MaxSize = 1 << 24
+
+// File is a file of records, the records are only appended to it
+type File struct {
+	f   *os.File
+	end int64
+}
+
+// Open opens the file creating it with the header if it doesn't exist and passes every record
+// with its offset to the read. The last record cut off by an interrupted append is truncated
+func Open(path, header string, read func(record []byte, offset int64) error) (*File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{f: f}
+	if err := file.load(header, read); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (file *File) load(header string, read func([]byte, int64) error) error {
+	info, err := file.f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		_, err := file.f.WriteAt([]byte(header), 0)
+		file.end = int64(len(header))
+		return err
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(file.f, 0, info.Size()))
+	buf := make([]byte, len(header))
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != header {
+		return errors.New("invalid header")
+	}
+
+	offset := int64(len(header))
+	for n := 0; ; n++ {
+		size, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF || size > MaxSize {
+			return fmt.Errorf("corrupt record %d", n)
+		}
+
+		prefix := int64(len(binary.AppendUvarint(nil, size)))
+		if err == io.ErrUnexpectedEOF || prefix+int64(size) > info.Size()-offset {
+			if err := file.f.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+
+		record := make([]byte, size)
+		if _, err := io.ReadFull(r, record); err != nil {
+			return err
+		}
+		if err := read(record, offset+prefix); err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+		offset += prefix + int64(size)
+	}
+
+	file.end = offset
+	return nil
+}
+
+// Append writes the record at the end of the file and returns its offset
+func (file *File) Append(record []byte) (int64, error) {
+	if len(record) > MaxSize {
+		return 0, fmt.Errorf("record of %d bytes is too large", len(record))
+	}
+
+	buf := append(binary.AppendUvarint(nil, uint64(len(record))), record...)
+	if _, err := file.f.WriteAt(buf, file.end); err != nil {
+		return 0, err
+	}
+
+	offset := file.end + int64(len(buf)-len(record))
+	file.end += int64(len(buf))
+	return offset, nil
+}
+
+// ReadAt reads the record of the size at the offset given by Open or Append
+func (file *File) ReadAt(offset int64, size int) ([]byte, error) {
+	record := make([]byte, size)
+	if _, err := file.f.ReadAt(record, offset); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (file *File) Close() error {
+	return file.f.Close()
+}
@@ -0,0 +1,131 @@
+package records
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const header = "TEST"
+
+// Opens the file collecting its records
+func open(t *testing.T, path string) (*File, []string) {
+	t.Helper()
+
+	var res []string
+	file, err := Open(path, header, func(record []byte, offset int64) error {
+		res = append(res, string(record))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file, res
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records")
+	file, read := open(t, path)
+	if len(read) != 0 {
+		t.Fatalf("Unexpected records %v", read)
+	}
+
+	var offsets []int64
+	for _, record := range []string{"first", "", "third"} {
+		offset, err := file.Append([]byte(record))
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets = append(offsets, offset)
+	}
+	file.Close()
+
+	var reopened []int64
+	file, err := Open(path, header, func(record []byte, offset int64) error {
+		reopened = append(reopened, offset)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if !slices.Equal(offsets, reopened) {
+		t.Fatalf("Expected offsets %v, got %v", offsets, reopened)
+	}
+	if record, err := file.ReadAt(offsets[2], len("third")); err != nil || string(record) != "third" {
+		t.Fatalf("Unexpected record %q: %v", record, err)
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"header": []byte("TES"),
+		// Size too large for a record
+		"corrupt": binary.AppendUvarint([]byte(header), MaxSize+1),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path, header, func([]byte, int64) error { return nil }); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestOpen_TornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "full")
+	file, _ := open(t, path)
+	for _, record := range []string{"first", "second"} {
+		if _, err := file.Append([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Appends interrupted while writing the record and its size
+	tests := []struct {
+		name    string
+		content []byte
+		records []string
+	}{
+		{"record", data[:len(data)-1], []string{"first"}},
+		{"size", append(slices.Clone(data), 0x80), []string{"first", "second"}},
+		{"empty record", binary.AppendUvarint(slices.Clone(data), 100), []string{"first", "second"}},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		if err := os.WriteFile(path, test.content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		file, read := open(t, path)
+		if !slices.Equal(read, test.records) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.records, read)
+		}
+
+		// Records are appended after the truncated tail
+		if _, err := file.Append([]byte("last")); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+
+		file, read = open(t, path)
+		if expected := append(test.records, "last"); !slices.Equal(read, expected) {
+			t.Fatalf("%s: expected %v after reopening, got %v", test.name, expected, read)
+		}
+		file.Close()
+	}
+}
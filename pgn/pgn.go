@@ -0,0 +1,175 @@
+// Package pgn reads games in Portable Game Notation
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Game is a game read from PGN, only its main line is kept, see GameTree
+type Game struct {
+	Tags map[string]string
+	// Position the game was started from, see the FEN tag
+	Start  core.Snapshot
+	Moves  []core.Move
+	Result string
+}
+
+var results = map[string]bool{"1-0": true, "0-1": true, "1/2-1/2": true, "*": true}
+
+// Reader reads games one by one from a PGN collection
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), line: 1}
+}
+
+// Returns the next game, io.EOF if there are no more games.
+// Comments, variations and NAGs are dropped
+func (r *Reader) Read() (*Game, error) {
+	tree, err := r.ReadTree()
+	if err != nil {
+		return nil, err
+	}
+	return &Game{Tags: tree.Tags, Start: tree.Start, Moves: tree.MainLine(), Result: tree.Result}, nil
+}
+
+type tokenKind int
+
+const (
+	tagToken tokenKind = iota
+	// Move, result or move suffix annotation
+	moveToken
+	nagToken
+	commentToken
+	openToken
+	closeToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// Returns the next token skipping move numbers
+func (r *Reader) token() (token, error) {
+	for {
+		c, err := r.readRune()
+		if err != nil {
+			return token{}, err
+		}
+
+		switch {
+		case unicode.IsSpace(c):
+		case c == '[':
+			line, err := r.readUntil(']')
+			return token{tagToken, "[" + line}, err
+		case c == '{':
+			text, err := r.readUntil('}')
+			return token{commentToken, strings.TrimSuffix(text, "}")}, err
+		case c == ';':
+			text, err := r.readUntil('\n')
+			if err != nil && err != io.EOF {
+				return token{}, err
+			}
+			return token{commentToken, strings.TrimSuffix(text, "\n")}, nil
+		case c == '%':
+			if _, err := r.readUntil('\n'); err != nil && err != io.EOF {
+				return token{}, err
+			}
+		case c == '(':
+			return token{kind: openToken}, nil
+		case c == ')':
+			return token{kind: closeToken}, nil
+		default:
+			tok := string(c)
+			for {
+				c, err := r.readRune()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return token{}, err
+				}
+				if unicode.IsSpace(c) || strings.ContainsRune("[{;()", c) {
+					r.unreadRune(c)
+					break
+				}
+				tok += string(c)
+			}
+
+			// Move numbers are dropped, e.g. "12." or "12...e5"
+			if i := strings.LastIndexByte(tok, '.'); i != -1 && strings.Trim(tok[:i+1], "0123456789.") == "" {
+				tok = tok[i+1:]
+			}
+			switch {
+			case tok == "":
+				continue
+			case tok[0] == '$':
+				return token{nagToken, tok[1:]}, nil
+			}
+			return token{moveToken, tok}, nil
+		}
+	}
+}
+
+func (r *Reader) parseTag(tree *GameTree, tok string) error {
+	name, value, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(tok, "["), "]"), " ")
+	value = strings.TrimSpace(value)
+	if !ok || len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return r.errorf("invalid tag: %s", tok)
+	}
+
+	value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	tree.Tags[name] = value
+	if name == "Result" && results[value] {
+		tree.Result = value
+	}
+	return nil
+}
+
+// Reads runes up to and including the delimiter
+func (r *Reader) readUntil(delim rune) (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := r.readRune()
+		if err == io.EOF && delim != '\n' {
+			return "", r.errorf("missing %q", delim)
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+
+		sb.WriteRune(c)
+		if c == delim {
+			return sb.String(), nil
+		}
+	}
+}
+
+func (r *Reader) readRune() (rune, error) {
+	c, _, err := r.r.ReadRune()
+	if c == '\n' {
+		r.line++
+	}
+	return c, err
+}
+
+func (r *Reader) unreadRune(c rune) {
+	if c == '\n' {
+		r.line--
+	}
+	r.r.UnreadRune()
+}
+
+func (r *Reader) errorf(format string, args ...any) error {
+	return fmt.Errorf("pgn: line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,69 @@
+package pgn
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+const collection = `[Event "Casual"]
+[White "Anderssen"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 {main line} Nc6 (2... d6 3. d4 (3. Bc4) exd4) 3. Bb5 $1 a6
+; a comment till the end of the line
+4. Ba4 Nf6 5. O-O 1-0
+
+[Event "From a position"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 2. e5 *
+`
+
+func TestReader_Read(t *testing.T) {
+	r := NewReader(strings.NewReader(collection))
+
+	game, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Tags["White"] != "Anderssen" || game.Result != "1-0" || len(game.Moves) != 9 {
+		t.Fatalf("Unexpected game %v", game)
+	}
+
+	if last := game.Moves[len(game.Moves)-1]; last.Action != core.KingCastling {
+		t.Fatalf("Unexpected last move %v", last)
+	}
+
+	game, err = r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.Start.FEN() != "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1" || len(game.Moves) != 3 || game.Result != "*" {
+		t.Fatalf("Unexpected game %v", game)
+	}
+
+	if _, err := r.Read(); err != io.EOF {
+		t.Fatalf("Expected EOF, got %v", err)
+	}
+}
+
+func TestReader_ReadErrors(t *testing.T) {
+	tests := map[string]string{
+		"1. e4 e5 2. Ke3 *":         `pgn: line 1: illegal move: "Ke3"`,
+		"[Event \"x\"\n\n1. e4":     `pgn: line 3: missing ']'`,
+		"1. e4 (1. d4 *":            "pgn: line 1: unterminated variation",
+		"[Event x]\n1. e4 *":        "pgn: line 1: invalid tag: [Event x]",
+		"[FEN \"8/8 w - -\"]\ne4 *": `pgn: line 2: invalid FEN board: "8/8"`,
+	}
+
+	for pgn, expected := range tests {
+		if _, err := NewReader(strings.NewReader(pgn)).Read(); err == nil || err.Error() != expected {
+			t.Fatalf("%q: unexpected error %v", pgn, err)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Numeric annotation glyphs written as move suffixes
+const (
+	NAGGood        = 1
+	NAGMistake     = 2
+	NAGBrilliant   = 3
+	NAGBlunder     = 4
+	NAGInteresting = 5
+	NAGDubious     = 6
+)
+
+var nagSymbols = map[string]int{
+	"!": NAGGood, "?": NAGMistake, "!!": NAGBrilliant,
+	"??": NAGBlunder, "!?": NAGInteresting, "?!": NAGDubious,
+}
+
+// Clock and evaluation commands embedded into comments
+var (
+	clockCommand = regexp.MustCompile(`\[%clk\s+(\d+):(\d{1,2}):(\d{1,2}(?:\.\d+)?)\]`)
+	evalCommand  = regexp.MustCompile(`\[%eval\s+(#?-?\+?[\d.]+)\]`)
+)
+
+// GameTree is a game with variations and annotations
+type GameTree struct {
+	Tags map[string]string
+	// Position the game was started from, see the FEN tag
+	Start  core.Snapshot
+	Result string
+	// Root holds no move, its comment is the comment of the game
+	Root *Node
+}
+
+// Node is a move of the tree with its annotations
+type Node struct {
+	Move core.Move
+	// Comment written before the move, it starts a variation
+	Prelude string
+	Comment string
+	NAGs    []int
+	// Clock time left after the move, nil if unknown
+	Clock *time.Duration
+	// Evaluation of the position after the move, nil if unknown
+	Eval   *Eval
+	Parent *Node
+	// First child continues the main line, the others are variations
+	Children []*Node
+}
+
+// Eval is an engine evaluation from the white side
+type Eval struct {
+	Centipawns int
+	// Moves to mate, negative if black mates, zero if there is no mate
+	Mate int
+}
+
+// Returns a tree without moves started from the position
+func NewGameTree(start core.Snapshot) *GameTree {
+	return &GameTree{Tags: map[string]string{}, Start: start, Result: "*", Root: &Node{}}
+}
+
+// Returns moves of the main line
+func (t *GameTree) MainLine() []core.Move {
+	var res []core.Move
+	for n := t.Root.Next(); n != nil; n = n.Next() {
+		res = append(res, n.Move)
+	}
+	return res
+}
+
+// Returns the game after the move of the node
+func (t *GameTree) Game(n *Node) (core.Game, error) {
+	return core.NewGameFromMoves(t.Start, n.Line())
+}
+
+// Play adds the move after the node as its last variation and returns the new node.
+// If the move is already there, its node is returned
+func (t *GameTree) Play(n *Node, move core.Move) (*Node, error) {
+	for _, child := range n.Children {
+		if child.Move == move {
+			return child, nil
+		}
+	}
+
+	game, err := t.Game(n)
+	if err != nil {
+		return nil, err
+	}
+	if err := game.Play(move); err != nil {
+		return nil, err
+	}
+
+	child := &Node{Move: move, Parent: n}
+	n.Children = append(n.Children, child)
+	return child, nil
+}
+
+// Returns moves from the root to the node
+func (n *Node) Line() []core.Move {
+	var res []core.Move
+	for ; n.Parent != nil; n = n.Parent {
+		res = append(res, n.Move)
+	}
+	slices.Reverse(res)
+	return res
+}
+
+// Returns the next move of the main line, nil at the end of the line
+func (n *Node) Next() *Node {
+	if len(n.Children) == 0 {
+		return nil
+	}
+	return n.Children[0]
+}
+
+// Returns the previous move, nil for the root
+func (n *Node) Previous() *Node {
+	return n.Parent
+}
+
+// Returns moves played instead of the node
+func (n *Node) Variations() []*Node {
+	if n.Parent == nil {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(n.Parent.Children), func(c *Node) bool { return c == n })
+}
+
+// Promote makes the variation the main line of its parent
+func (n *Node) Promote() {
+	if n.Parent == nil {
+		return
+	}
+
+	siblings := n.Parent.Children
+	i := slices.Index(siblings, n)
+	copy(siblings[1:i+1], siblings[:i])
+	siblings[0] = n
+}
+
+// Delete removes the node with its moves from the tree
+func (n *Node) Delete() {
+	if n.Parent == nil {
+		return
+	}
+
+	n.Parent.Children = slices.DeleteFunc(n.Parent.Children, func(c *Node) bool { return c == n })
+	n.Parent = nil
+}
+
+// Returns the next game as a tree, io.EOF if there are no more games.
+// Moves of the variations are validated as well
+func (r *Reader) ReadTree() (*GameTree, error) {
+	tree := NewGameTree(core.InitialSnapshot())
+
+	// Game after the current node and the games left at the variation starts
+	var game *core.Game
+	type branch struct {
+		node *Node
+		game core.Game
+	}
+	var branches []branch
+	node := tree.Root
+	// Comments at the start of a variation are kept till its first move
+	starting, prelude := false, ""
+
+	for {
+		tok, err := r.token()
+		if err == io.EOF {
+			if game == nil {
+				return nil, io.EOF
+			}
+			if len(branches) > 0 {
+				return nil, r.errorf("unterminated variation")
+			}
+			return tree, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.kind == tagToken {
+			if game != nil {
+				return nil, r.errorf("tag after the moves")
+			}
+			if err := r.parseTag(tree, tok.text); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if game == nil {
+			if fen, ok := tree.Tags["FEN"]; ok {
+				if tree.Start, err = core.ParseFEN(fen); err != nil {
+					return nil, r.errorf("%v", err)
+				}
+			}
+			g := tree.Start.Game()
+			game = &g
+		}
+
+		switch tok.kind {
+		case commentToken:
+			text := strings.TrimSpace(tok.text)
+			switch {
+			case starting:
+				prelude = joinComment(prelude, text)
+			case node == tree.Root:
+				tree.Root.Comment = joinComment(tree.Root.Comment, text)
+			default:
+				node.parseComment(text)
+			}
+
+		case nagToken:
+			nag, err := strconv.Atoi(tok.text)
+			if err != nil || node == tree.Root {
+				return nil, r.errorf("invalid NAG: $%s", tok.text)
+			}
+			node.NAGs = append(node.NAGs, nag)
+
+		case openToken:
+			if node.Parent == nil {
+				return nil, r.errorf("variation without a move")
+			}
+			branches = append(branches, branch{node, game.Clone()})
+			g, err := tree.Game(node.Parent)
+			if err != nil {
+				return nil, r.errorf("%v", err)
+			}
+			game, node, starting = &g, node.Parent, true
+
+		case closeToken:
+			if len(branches) == 0 {
+				return nil, r.errorf("unexpected ')'")
+			}
+			last := branches[len(branches)-1]
+			branches = branches[:len(branches)-1]
+			g := last.game
+			game, node, starting, prelude = &g, last.node, false, ""
+
+		case moveToken:
+			if results[tok.text] {
+				if len(branches) > 0 {
+					return nil, r.errorf("unterminated variation")
+				}
+				tree.Result = tok.text
+				return tree, nil
+			}
+
+			san := strings.TrimRight(tok.text, "!?")
+			nag, annotated := nagSymbols[tok.text[len(san):]]
+			if san == "" {
+				if !annotated || node == tree.Root {
+					return nil, r.errorf("invalid annotation: %s", tok.text)
+				}
+				node.NAGs = append(node.NAGs, nag)
+				continue
+			}
+
+			move, err := game.ParseSAN(san)
+			if err != nil {
+				return nil, r.errorf("%v", err)
+			}
+			if err := game.Play(move); err != nil {
+				return nil, r.errorf("%s: %v", tok.text, err)
+			}
+
+			child := &Node{Move: move, Parent: node, Prelude: prelude}
+			if annotated {
+				child.NAGs = append(child.NAGs, nag)
+			}
+			node.Children = append(node.Children, child)
+			node, starting, prelude = child, false, ""
+		}
+	}
+}
+
+// Extracts clock and evaluation commands from the comment after the move
+func (n *Node) parseComment(text string) {
+	if m := clockCommand.FindStringSubmatch(text); m != nil {
+		hours, _ := strconv.Atoi(m[1])
+		minutes, _ := strconv.Atoi(m[2])
+		// Fraction of the second is parsed exactly up to nanoseconds
+		whole, fraction, _ := strings.Cut(m[3], ".")
+		seconds, _ := strconv.Atoi(whole)
+		nanos, _ := strconv.Atoi((fraction + "000000000")[:9])
+		clock := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+			time.Duration(seconds)*time.Second + time.Duration(nanos)
+		n.Clock = &clock
+		text = clockCommand.ReplaceAllString(text, "")
+	}
+
+	if m := evalCommand.FindStringSubmatch(text); m != nil {
+		var eval Eval
+		if mate, ok := strings.CutPrefix(m[1], "#"); ok {
+			eval.Mate, _ = strconv.Atoi(mate)
+		} else {
+			pawns, _ := strconv.ParseFloat(m[1], 64)
+			eval.Centipawns = int(pawns*100 + 0.5*sign(pawns))
+		}
+		n.Eval = &eval
+		text = evalCommand.ReplaceAllString(text, "")
+	}
+
+	n.Comment = joinComment(n.Comment, strings.Join(strings.Fields(text), " "))
+}
+
+// Returns the comment after the move with the clock and evaluation commands
+func (n *Node) comment() string {
+	var parts []string
+	if n.Clock != nil {
+		d := *n.Clock
+		clock := fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+		if fraction := d % time.Second; fraction != 0 {
+			clock += strings.TrimRight(fmt.Sprintf(".%09d", fraction), "0")
+		}
+		parts = append(parts, "[%clk "+clock+"]")
+	}
+
+	if n.Eval != nil {
+		if n.Eval.Mate != 0 {
+			parts = append(parts, fmt.Sprintf("[%%eval #%d]", n.Eval.Mate))
+		} else {
+			parts = append(parts, fmt.Sprintf("[%%eval %.2f]", float64(n.Eval.Centipawns)/100))
+		}
+	}
+
+	if n.Comment != "" {
+		parts = append(parts, n.Comment)
+	}
+	return strings.Join(parts, " ")
+}
+
+func joinComment(comment, text string) string {
+	if comment == "" || text == "" {
+		return comment + text
+	}
+	return comment + " " + text
+}
+
+func sign(x float64) float64 {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
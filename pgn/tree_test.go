@@ -0,0 +1,170 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+const annotated = `[Event "Study"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Anderssen"]
+[Black "?"]
+[Result "1-0"]
+[Annotator "Coach"]
+
+{Game comment} 1. e4 {[%clk 0:05:00] [%eval 0.30] best by test} 1... e5
+(1... c5!? {Sicilian} 2. Nf3 (2. c3 $14) 2... d6) (1... e6 {French}) 2. Nf3 Nc6
+3. Bb5! ({Or} 3. Bc4 Bc5 4. c3 {[%eval #3]}) 3... a6?! {[%clk 0:00:05.3]} 4. Ba4
+1-0
+
+`
+
+func readTree(t *testing.T, pgn string) *GameTree {
+	t.Helper()
+
+	tree, err := NewReader(strings.NewReader(pgn)).ReadTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func writeTree(t *testing.T, tree *GameTree) string {
+	t.Helper()
+
+	var sb strings.Builder
+	if _, err := tree.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestReader_ReadTree(t *testing.T) {
+	tree := readTree(t, annotated)
+
+	if tree.Root.Comment != "Game comment" || len(tree.MainLine()) != 7 || tree.Result != "1-0" {
+		t.Fatalf("Unexpected tree %+v", tree)
+	}
+
+	e4 := tree.Root.Next()
+	if e4.Comment != "best by test" || *e4.Clock != 5*time.Minute || *e4.Eval != (Eval{Centipawns: 30}) {
+		t.Fatalf("Unexpected annotations %+v", e4)
+	}
+
+	variations := e4.Next().Variations()
+	if len(variations) != 2 || variations[0].NAGs[0] != NAGInteresting || variations[1].Comment != "French" {
+		t.Fatalf("Unexpected variations %+v", variations)
+	}
+
+	// Nested variation
+	if c3 := variations[0].Next().Variations()[0]; c3.NAGs[0] != 14 || c3.Parent != variations[0] {
+		t.Fatalf("Unexpected nested variation %+v", c3)
+	}
+
+	// Fraction of the second is kept
+	if a6 := e4.Next().Next().Next().Next().Next(); a6.Clock == nil || *a6.Clock != 5300*time.Millisecond {
+		t.Fatalf("Unexpected clock %v", a6.Clock)
+	}
+
+	bc4 := e4.Next().Next().Next().Next().Variations()[0]
+	if bc4.Prelude != "Or" || bc4.Next().Next().Eval.Mate != 3 {
+		t.Fatalf("Unexpected variation %+v", bc4)
+	}
+}
+
+func TestGameTree_RoundTrip(t *testing.T) {
+	tree := readTree(t, annotated)
+	if pgn := writeTree(t, tree); pgn != annotated {
+		t.Fatalf("Unexpected PGN:\n%s", pgn)
+	}
+
+	// Black starts the game
+	const fen = "4k3/8/8/8/8/8/4P3/4K3 b - - 0 1"
+	tree = readTree(t, `[FEN "`+fen+`"] 1... Kd7 (1... Ke7 2. e4) 2. e4 *`)
+	expected := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+[FEN "` + fen + `"]
+[SetUp "1"]
+
+1... Kd7 (1... Ke7 2. e4) 2. e4 *
+
+`
+	if pgn := writeTree(t, tree); pgn != expected {
+		t.Fatalf("Unexpected PGN:\n%s", pgn)
+	}
+}
+
+func TestGameTree_CommentBraces(t *testing.T) {
+	tree := readTree(t, "1. e4 e5 *")
+	tree.Root.Comment = "{start}"
+	tree.Root.Next().Comment = "see {x} here"
+	tree.Root.Next().Next().Prelude = "}"
+
+	read := readTree(t, writeTree(t, tree))
+	e4 := read.Root.Next()
+	if read.Root.Comment != "{start" || e4.Comment != "see {x here" || e4.Next() == nil || e4.Next().Prelude != "" {
+		t.Fatalf("Unexpected comments %q %q", read.Root.Comment, e4.Comment)
+	}
+}
+
+func TestGameTree_Edit(t *testing.T) {
+	tree := readTree(t, "1. e4 e5 (1... c5) (1... e6) 2. Nf3 *")
+	e5 := tree.Root.Next().Next()
+	e6 := e5.Variations()[1]
+
+	e6.Promote()
+	if tree.Root.Next().Next() != e6 || len(e6.Variations()) != 2 || e6.Variations()[0] != e5 {
+		t.Fatalf("Variation isn't promoted: %v", writeTree(t, tree))
+	}
+
+	game, err := tree.Game(e6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	move, err := game.ParseSAN("d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d4, err := tree.Play(e6, move)
+	if err != nil || d4.Previous() != e6 {
+		t.Fatalf("Move isn't added: %v", err)
+	}
+	if again, _ := tree.Play(e6, move); again != d4 {
+		t.Fatal("Existing move is added again")
+	}
+	if _, err := tree.Play(e6, core.Move{}); err == nil {
+		t.Fatal("Expected error for an illegal move")
+	}
+
+	e5.Delete()
+	if pgn := writeTree(t, tree); !strings.HasSuffix(pgn, "1. e4 e6 (1... c5) 2. d4 *\n\n") {
+		t.Fatalf("Unexpected PGN:\n%s", pgn)
+	}
+}
+
+func TestReader_ReadTreeErrors(t *testing.T) {
+	tests := map[string]string{
+		"1. e4 (1. d4 d5 (1... Nf6) *": "pgn: line 1: unterminated variation",
+		"1. e4 e5) *":                  "pgn: line 1: unexpected ')'",
+		"(1. e4) *":                    "pgn: line 1: variation without a move",
+		"1. e4 (1. d4 e4) *":           `pgn: line 1: illegal move: "e4"`,
+		"$1 1. e4 *":                   "pgn: line 1: invalid NAG: $1",
+	}
+
+	for pgn, expected := range tests {
+		if _, err := NewReader(strings.NewReader(pgn)).ReadTree(); err == nil || err.Error() != expected {
+			t.Fatalf("%q: unexpected error %v", pgn, err)
+		}
+	}
+}
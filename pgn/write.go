@@ -0,0 +1,185 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Width of the movetext lines
+const lineWidth = 80
+
+// Tags written first in this order with the default values, the others follow sorted by name
+var roster = []struct{ name, value string }{
+	{"Event", "?"}, {"Site", "?"}, {"Date", "????.??.??"}, {"Round", "?"},
+	{"White", "?"}, {"Black", "?"}, {"Result", "*"},
+}
+
+// WriteTo writes the tree in PGN with its variations and annotations
+func (t *GameTree) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	tags := maps.Clone(t.Tags)
+	tags["Result"] = t.Result
+	if t.Start != core.InitialSnapshot() {
+		tags["SetUp"], tags["FEN"] = "1", t.Start.FEN()
+	}
+
+	for _, tag := range roster {
+		value, ok := tags[tag.name]
+		if !ok {
+			value = tag.value
+		}
+		writeTag(&sb, tag.name, value)
+		delete(tags, tag.name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(tags)) {
+		writeTag(&sb, name, tags[name])
+	}
+	sb.WriteByte('\n')
+
+	m := &movetext{}
+	if t.Start.Turn() == core.Black {
+		m.offset = 1
+	}
+	if t.Root.Comment != "" {
+		m.comment(t.Root.Comment)
+	}
+	if err := m.line(t.Root, t.Start.Game(), 0, true); err != nil {
+		return 0, err
+	}
+	m.add(t.Result)
+
+	sb.WriteString(m.wrap())
+	sb.WriteString("\n\n")
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func writeTag(sb *strings.Builder, name, value string) {
+	fmt.Fprintf(sb, "[%s \"%s\"]\n", name, strings.ReplaceAll(value, `"`, `\"`))
+}
+
+type movetext struct {
+	tokens []string
+	// Ply of the start position, 1 if black starts
+	offset int
+}
+
+func (m *movetext) add(tok string) {
+	m.tokens = append(m.tokens, tok)
+}
+
+// Adds the comment in braces, closing braces can't be written inside it and are dropped
+func (m *movetext) comment(text string) {
+	m.add("{" + strings.ReplaceAll(text, "}", "") + "}")
+}
+
+// Writes the moves after the node, the variations follow the main move they replace.
+// Move number of black is written if the line is interrupted
+func (m *movetext) line(node *Node, game core.Game, ply int, number bool) error {
+	for len(node.Children) > 0 {
+		main := node.Children[0]
+		before := game.Clone()
+		if err := m.move(main, &game, ply, number); err != nil {
+			return err
+		}
+
+		for _, variation := range node.Children[1:] {
+			m.add("(")
+			g := before.Clone()
+			if err := m.move(variation, &g, ply, true); err != nil {
+				return err
+			}
+			if err := m.line(variation, g, ply+1, variation.comment() != ""); err != nil {
+				return err
+			}
+			m.add(")")
+		}
+
+		number = len(node.Children) > 1 || main.comment() != ""
+		node = main
+		ply++
+	}
+	return nil
+}
+
+func (m *movetext) move(n *Node, game *core.Game, ply int, number bool) error {
+	if n.Prelude != "" {
+		m.comment(n.Prelude)
+		number = true
+	}
+
+	// Numbers are kept on the line of their moves
+	prefix := ""
+	if num := (ply+m.offset)/2 + 1; (ply+m.offset)%2 == 0 {
+		prefix = fmt.Sprintf("%d. ", num)
+	} else if number {
+		prefix = fmt.Sprintf("%d... ", num)
+	}
+
+	san := game.SAN(n.Move)
+	if err := game.Play(n.Move); err != nil {
+		return fmt.Errorf("pgn: %s: %w", san, err)
+	}
+
+	for i, nag := range n.NAGs {
+		if symbol, ok := nagSuffix(nag); ok && i == 0 {
+			san += symbol
+			continue
+		}
+		san += fmt.Sprintf(" $%d", nag)
+	}
+	m.add(prefix + san)
+
+	if comment := n.comment(); comment != "" {
+		m.comment(comment)
+	}
+	return nil
+}
+
+func nagSuffix(nag int) (string, bool) {
+	for symbol, n := range nagSymbols {
+		if n == nag {
+			return symbol, true
+		}
+	}
+	return "", false
+}
+
+// Joins the tokens into lines, parentheses stick to the tokens inside them
+func (m *movetext) wrap() string {
+	var words []string
+	glue := false
+	for _, tok := range m.tokens {
+		switch {
+		case glue:
+			words[len(words)-1] += tok
+		case tok == ")":
+			words[len(words)-1] += tok
+		default:
+			words = append(words, tok)
+		}
+		glue = tok == "("
+	}
+
+	var sb strings.Builder
+	width := 0
+	for _, word := range words {
+		if width > 0 && width+1+len(word) > lineWidth {
+			sb.WriteByte('\n')
+			width = 0
+		} else if width > 0 {
+			sb.WriteByte(' ')
+			width++
+		}
+		sb.WriteString(word)
+		width += len(word)
+	}
+	return sb.String()
+}
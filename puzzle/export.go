@@ -0,0 +1,171 @@
+package puzzle
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Columns of the CSV export, the moves are in the UCI notation separated by spaces
+// and the themes are separated by spaces
+var csvHeader = []string{"id", "fen", "moves", "themes", "difficulty", "game", "ply"}
+
+// Puzzle as exported to JSON
+type record struct {
+	ID         string   `json:"id"`
+	FEN        string   `json:"fen"`
+	Moves      []string `json:"moves"`
+	Themes     []string `json:"themes"`
+	Difficulty string   `json:"difficulty"`
+	Game       string   `json:"game,omitempty"`
+	Ply        int      `json:"ply"`
+}
+
+func (p *Puzzle) record() record {
+	game := p.Start.Game()
+	moves := make([]string, 0, len(p.Moves))
+	for _, move := range p.Moves {
+		moves = append(moves, game.UCI(move))
+		game.Play(move)
+	}
+
+	return record{
+		ID:         p.ID,
+		FEN:        p.Start.FEN(),
+		Moves:      moves,
+		Themes:     p.Themes,
+		Difficulty: p.Difficulty.String(),
+		Game:       p.Game,
+		Ply:        p.Ply,
+	}
+}
+
+// WriteCSV writes the puzzles with the header
+func WriteCSV(w io.Writer, puzzles []Puzzle) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for i := range puzzles {
+		r := puzzles[i].record()
+		err := cw.Write([]string{
+			r.ID, r.FEN, strings.Join(r.Moves, " "), strings.Join(r.Themes, " "),
+			r.Difficulty, r.Game, strconv.Itoa(r.Ply),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the puzzles as an array of objects
+func WriteJSON(w io.Writer, puzzles []Puzzle) error {
+	records := make([]record, 0, len(puzzles))
+	for i := range puzzles {
+		records = append(records, puzzles[i].record())
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// Returns the puzzle of the record, the moves are checked by the rules
+func (r *record) puzzle() (Puzzle, error) {
+	start, err := core.ParseFEN(r.FEN)
+	if err != nil {
+		return Puzzle{}, fmt.Errorf("puzzle %s: %w", r.ID, err)
+	}
+
+	p := Puzzle{ID: r.ID, Start: start, Themes: r.Themes, Game: r.Game, Ply: r.Ply}
+	game := start.Game()
+	for _, uci := range r.Moves {
+		move, err := game.ParseUCI(uci)
+		if err != nil {
+			return Puzzle{}, fmt.Errorf("puzzle %s: %w", r.ID, err)
+		}
+		p.Moves = append(p.Moves, move)
+		game.Play(move)
+	}
+	if err := checkLine(p); err != nil {
+		return Puzzle{}, err
+	}
+
+	difficulty := slices.IndexFunc([]Difficulty{Easy, Medium, Hard}, func(d Difficulty) bool { return d.String() == r.Difficulty })
+	if difficulty < 0 {
+		return Puzzle{}, fmt.Errorf("puzzle %s: invalid difficulty: %q", r.ID, r.Difficulty)
+	}
+	p.Difficulty = Difficulty(difficulty)
+	return p, nil
+}
+
+// ReadCSV reads the puzzles written by WriteCSV, the columns are found by the header
+func ReadCSV(r io.Reader) ([]Puzzle, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("puzzle: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[name] = i
+	}
+	for _, name := range csvHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("puzzle: no column %q", name)
+		}
+	}
+
+	var res []Puzzle
+	for _, row := range rows[1:] {
+		rec := record{
+			ID:         row[columns["id"]],
+			FEN:        row[columns["fen"]],
+			Moves:      strings.Fields(row[columns["moves"]]),
+			Themes:     strings.Fields(row[columns["themes"]]),
+			Difficulty: row[columns["difficulty"]],
+			Game:       row[columns["game"]],
+		}
+		if rec.Ply, err = strconv.Atoi(row[columns["ply"]]); err != nil {
+			return nil, fmt.Errorf("puzzle %s: invalid ply: %q", rec.ID, row[columns["ply"]])
+		}
+
+		p, err := rec.puzzle()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// ReadJSON reads the puzzles written by WriteJSON
+func ReadJSON(r io.Reader) ([]Puzzle, error) {
+	var records []record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("puzzle: %w", err)
+	}
+
+	res := make([]Puzzle, 0, len(records))
+	for i := range records {
+		p, err := records[i].puzzle()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
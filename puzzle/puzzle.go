@@ -0,0 +1,351 @@
+// Package puzzle mines puzzles from games: positions where a single move wins
+// decisively, by a mate or by a large material gain, and every other move doesn't
+package puzzle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/zzvanq/shahio/book"
+	"github.com/zzvanq/shahio/core"
+	"github.com/zzvanq/shahio/engine"
+	"github.com/zzvanq/shahio/pgn"
+	"github.com/zzvanq/shahio/tactics"
+)
+
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+)
+
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "easy"
+	case Medium:
+		return "medium"
+	case Hard:
+		return "hard"
+	}
+	return fmt.Sprintf("Difficulty(%d)", int(d))
+}
+
+// Puzzle is a position with the only winning line
+type Puzzle struct {
+	// Key of the position in hex, see book.SnapshotKey
+	ID    string
+	Start core.Snapshot
+	// Moves of the solver alternating with the replies, the line ends by a move of the solver
+	Moves      []core.Move
+	Themes     []string
+	Difficulty Difficulty
+	// Game the puzzle was found in and the number of plies played before the position
+	Game string
+	Ply  int
+}
+
+type Options struct {
+	// Depth of the searches, 3 by default
+	Depth int
+	// Centipawns the solution has to gain at least, 300 by default
+	MinGain int
+	// Moves of the solver at most, 3 by default
+	MaxMoves int
+	// Plies of the games skipped before searching for puzzles
+	SkipPlies int
+}
+
+// Generator mines puzzles, positions met again are skipped
+type Generator struct {
+	opts   Options
+	engine *engine.Engine
+	seen   map[uint64]bool
+}
+
+func NewGenerator(opts Options) *Generator {
+	if opts.Depth <= 0 {
+		opts.Depth = 3
+	}
+	if opts.MinGain <= 0 {
+		opts.MinGain = 300
+	}
+	if opts.MaxMoves <= 0 {
+		opts.MaxMoves = 3
+	}
+	return &Generator{opts: opts, engine: engine.New(engine.Options{}), seen: map[uint64]bool{}}
+}
+
+// Mine returns puzzles found in the games of the PGN archive
+func (g *Generator) Mine(ctx context.Context, r io.Reader) ([]Puzzle, error) {
+	games := pgn.NewReader(r)
+	var res []Puzzle
+	for n := 1; ; n++ {
+		game, err := games.Read()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+
+		played, err := core.NewGameFromMoves(game.Start, game.Moves)
+		if err != nil {
+			return res, fmt.Errorf("puzzle: game %d: %w", n, err)
+		}
+
+		puzzles, err := g.FromGame(ctx, &played, describe(game, n))
+		if err != nil {
+			return res, err
+		}
+		res = append(res, puzzles...)
+	}
+}
+
+// Returns the game like "Event: White - Black", the number of the game is used without tags
+func describe(game *pgn.Game, n int) string {
+	white, black := game.Tags["White"], game.Tags["Black"]
+	if white == "" && black == "" {
+		return fmt.Sprintf("game %d", n)
+	}
+
+	res := white + " - " + black
+	if event := game.Tags["Event"]; event != "" && event != "?" {
+		res = event + ": " + res
+	}
+	return res
+}
+
+// FromGame returns puzzles found in the positions of the game
+func (g *Generator) FromGame(ctx context.Context, game *core.Game, source string) ([]Puzzle, error) {
+	var res []Puzzle
+	replay := game.Start().Game()
+	moves := game.Moves()
+	for ply := 0; ; ply++ {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		if ply >= g.opts.SkipPlies {
+			if puzzle, ok := g.Position(ctx, &replay); ok {
+				puzzle.Game, puzzle.Ply = source, ply
+				res = append(res, puzzle)
+			}
+		}
+
+		if ply == len(moves) {
+			return res, nil
+		}
+		replay.Play(moves[ply])
+	}
+}
+
+// Position returns the puzzle of the side to move in the position of the game
+func (g *Generator) Position(ctx context.Context, game *core.Game) (Puzzle, bool) {
+	start := game.Snapshot()
+	key := book.SnapshotKey(start)
+	if game.Outcome() != core.NoOutcome || g.seen[key] {
+		return Puzzle{}, false
+	}
+	g.seen[key] = true
+
+	s := &solver{Generator: g, ctx: ctx, side: game.Turn(), eval: engine.Evaluate(game), material: material(game, game.Turn())}
+	best := g.engine.Search(ctx, game, g.opts.Depth)
+	if !s.wins(best.Score) {
+		return Puzzle{}, false
+	}
+	s.mate = engine.MateIn(best.Score) > 0
+
+	line, ok := s.solve(game)
+	if !ok {
+		return Puzzle{}, false
+	}
+
+	puzzle := Puzzle{ID: fmt.Sprintf("%016x", key), Start: start, Moves: line}
+	puzzle.Themes = s.themes(game, line)
+	puzzle.Difficulty = difficulty(game, line)
+	return puzzle, true
+}
+
+type solver struct {
+	*Generator
+	ctx  context.Context
+	side core.Side
+	// Static evaluation and material of the solver at the start
+	eval, material int
+	mate           bool
+}
+
+// Checks the score of the solver is a win
+func (s *solver) wins(score int) bool {
+	if s.mate {
+		return engine.MateIn(score) > 0
+	}
+	return engine.MateIn(score) > 0 || score-s.eval >= s.opts.MinGain
+}
+
+// Returns the solution line from the game, every move of the solver has to be the only winning one
+func (s *solver) solve(game *core.Game) ([]core.Move, bool) {
+	pos := game.Clone()
+	var line []core.Move
+	for range s.opts.MaxMoves {
+		move, ok := s.onlyWin(&pos)
+		if !ok {
+			return nil, false
+		}
+		line = append(line, move)
+		pos.Play(move)
+
+		if pos.Outcome() == core.Checkmate {
+			return line, true
+		}
+		if pos.Outcome() != core.NoOutcome {
+			return nil, false
+		}
+
+		reply := s.engine.Search(s.ctx, &pos, s.opts.Depth)
+		next := pos.Clone()
+		next.Play(reply.Move)
+
+		// Material is won once the reply can't win it back
+		if !s.mate && material(&next, s.side)-s.material >= s.opts.MinGain {
+			return line, true
+		}
+
+		line = append(line, reply.Move)
+		pos = next
+	}
+	return nil, false
+}
+
+// Returns the only move of the side to move which wins,
+// any mate in one is accepted as the solution is complete then
+func (s *solver) onlyWin(game *core.Game) (core.Move, bool) {
+	best := s.engine.Search(s.ctx, game, s.opts.Depth)
+	if best.Move == (core.Move{}) || !s.wins(best.Score) {
+		return core.Move{}, false
+	}
+
+	next := game.Clone()
+	next.Play(best.Move)
+	if next.Outcome() == core.Checkmate {
+		return best.Move, true
+	}
+
+	for _, move := range game.LegalMoves() {
+		if move == best.Move {
+			continue
+		}
+
+		next := game.Clone()
+		next.Play(move)
+		if s.wins(-s.engine.Search(s.ctx, &next, s.opts.Depth-1).Score) {
+			return core.Move{}, false
+		}
+	}
+	return best.Move, true
+}
+
+// Tags of the motifs of the solver
+var motifThemes = map[tactics.Motif]string{
+	tactics.Fork:               "fork",
+	tactics.Pin:                "pin",
+	tactics.Skewer:             "skewer",
+	tactics.DiscoveredAttack:   "discoveredAttack",
+	tactics.DoubleCheck:        "doubleCheck",
+	tactics.OverloadedDefender: "overloading",
+	tactics.HangingPiece:       "hangingPiece",
+}
+
+// Returns themes of the solution: the goal, the motifs of the solver
+// in the start position and after the first move, and the length
+func (s *solver) themes(game *core.Game, line []core.Move) []string {
+	moves := (len(line) + 1) / 2
+
+	var res []string
+	if s.mate {
+		res = append(res, "mate", fmt.Sprintf("mateIn%d", moves))
+	} else {
+		pos := game.Clone()
+		for _, move := range line {
+			pos.Play(move)
+		}
+		if material(&pos, s.side)-s.material >= 2*s.opts.MinGain {
+			res = append(res, "crushing")
+		} else {
+			res = append(res, "advantage")
+		}
+	}
+
+	first := game.Clone()
+	first.Play(line[0])
+	for _, pos := range []*core.Game{game, &first} {
+		for _, t := range tactics.Find(pos) {
+			if theme, ok := motifThemes[t.Motif]; ok && t.Side == s.side && !slices.Contains(res, theme) {
+				res = append(res, theme)
+			}
+		}
+	}
+
+	// Mate delivered on the back rank by a rook or a queen
+	if s.mate {
+		before := game.Clone()
+		for _, move := range line[:len(line)-1] {
+			before.Play(move)
+		}
+		last := before.CompleteMove(line[len(line)-1])
+		if last.Target.Row() == core.PromotionRows[s.side] && (last.Source.Figure() == core.Rook || last.Source.Figure() == core.Queen) &&
+			len(tactics.FindMotif(&before, tactics.BackRankWeakness)) > 0 {
+			res = append(res, "backRankMate")
+		}
+	}
+
+	switch {
+	case moves == 1:
+		res = append(res, "oneMove")
+	case moves == 2:
+		res = append(res, "short")
+	case moves == 3:
+		res = append(res, "long")
+	default:
+		res = append(res, "veryLong")
+	}
+	return res
+}
+
+// Rates the solution by its length, quiet first moves and sacrifices are harder to find
+func difficulty(game *core.Game, line []core.Move) Difficulty {
+	points := (len(line) - 1) / 2
+
+	first := game.CompleteMove(line[0])
+	next := game.Clone()
+	next.Play(first)
+	if first.Action == core.Movement && !next.InCheck() {
+		points++
+	}
+	if first.Source.Figure() != core.Pawn && len(next.Attackers(first.Target.Position, next.Turn())) > 0 {
+		points++
+	}
+
+	return min(Difficulty(points), Hard)
+}
+
+// Returns material of the side minus material of the opponent in centipawns
+func material(game *core.Game, side core.Side) int {
+	values := map[core.Figure]int{core.Pawn: 100, core.Knight: 300, core.Bishop: 300, core.Rook: 500, core.Queen: 900}
+	res := 0
+	for row := range game.Board {
+		for _, pic := range game.Board[row] {
+			if pic.Side() == side {
+				res += values[pic.Figure()]
+			} else {
+				res -= values[pic.Figure()]
+			}
+		}
+	}
+	return res
+}
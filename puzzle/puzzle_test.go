@@ -0,0 +1,115 @@
+package puzzle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const archive = `[Event "Club"]
+[White "A"]
+[Black "B"]
+[SetUp "1"]
+[FEN "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"]
+[Result "1-0"]
+
+1. Ra8# 1-0
+
+[White "C"]
+[Black "D"]
+[SetUp "1"]
+[FEN "q3k3/8/8/1N6/8/8/8/4K3 w - - 0 1"]
+[Result "*"]
+
+1. Nc7+ *
+
+[White "E"]
+[Black "F"]
+[SetUp "1"]
+[FEN "q3k3/8/8/1N6/8/8/8/R3K3 w - - 0 1"]
+[Result "*"]
+
+1. Rxa8+ *
+`
+
+func mine(t *testing.T) []Puzzle {
+	t.Helper()
+
+	puzzles, err := NewGenerator(Options{}).Mine(context.Background(), strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return puzzles
+}
+
+func TestGenerator_Mine(t *testing.T) {
+	puzzles := mine(t)
+
+	var got []record
+	for _, p := range puzzles {
+		got = append(got, p.record())
+	}
+
+	expected := []record{
+		{
+			FEN: "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1", Moves: []string{"a1a8"},
+			Themes: []string{"mate", "mateIn1", "backRankMate", "oneMove"}, Difficulty: "easy", Game: "Club: A - B",
+		},
+		{
+			FEN: "q3k3/8/8/1N6/8/8/8/4K3 w - - 0 1", Moves: []string{"b5c7", "e8f7", "c7a8"},
+			Themes: []string{"crushing", "fork", "hangingPiece", "short"}, Difficulty: "medium", Game: "C - D",
+		},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d puzzles, got %+v", len(expected), got)
+	}
+	for i := range expected {
+		e, g := expected[i], got[i]
+		if g.FEN != e.FEN || !slices.Equal(g.Moves, e.Moves) || !slices.Equal(g.Themes, e.Themes) ||
+			g.Difficulty != e.Difficulty || g.Game != e.Game || g.Ply != 0 || len(g.ID) != 16 {
+			t.Fatalf("Expected %+v, got %+v", e, g)
+		}
+	}
+}
+
+func TestGenerator_Seen(t *testing.T) {
+	g := NewGenerator(Options{})
+	for i, expected := range []int{2, 0} {
+		puzzles, err := g.Mine(context.Background(), strings.NewReader(archive))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(puzzles) != expected {
+			t.Fatalf("Run %d: expected %d puzzles, got %d", i, expected, len(puzzles))
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	puzzles := mine(t)
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, puzzles); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != "id,fen,moves,themes,difficulty,game,ply" ||
+		!strings.HasSuffix(lines[1], ",6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1,a1a8,mate mateIn1 backRankMate oneMove,easy,Club: A - B,0") {
+		t.Fatalf("Unexpected CSV %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, puzzles); err != nil {
+		t.Fatal(err)
+	}
+	var records []record
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !slices.Equal(records[1].Moves, []string{"b5c7", "e8f7", "c7a8"}) || records[1].ID != puzzles[1].ID {
+		t.Fatalf("Unexpected JSON %s", buf.String())
+	}
+}
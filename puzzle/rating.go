@@ -0,0 +1,104 @@
+package puzzle
+
+import "math"
+
+const (
+	// Ratio of the Glicko-2 scale to the Glicko one
+	glickoScale = 173.7178
+	// Constraint of the volatility changes
+	tau = 0.5
+	// Tolerance of the volatility iteration
+	epsilon = 0.000001
+)
+
+// Rating is a Glicko-2 rating
+type Rating struct {
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+}
+
+// Returns rating of a new player
+func NewRating() Rating {
+	return Rating{Rating: 1500, Deviation: 350, Volatility: 0.06}
+}
+
+// Outcome is a result of a game of a rating period, the score is 1 for a win,
+// 0.5 for a draw and 0 for a loss
+type Outcome struct {
+	Opponent Rating
+	Score    float64
+}
+
+// Update returns the rating after the rating period with the outcomes,
+// the deviation grows when there are no outcomes
+func (r Rating) Update(outcomes ...Outcome) Rating {
+	mu := (r.Rating - 1500) / glickoScale
+	phi := r.Deviation / glickoScale
+	sigma := r.Volatility
+
+	if len(outcomes) == 0 {
+		r.Deviation = math.Sqrt(phi*phi+sigma*sigma) * glickoScale
+		return r
+	}
+
+	// Estimated variance and improvement
+	var variance, sum float64
+	for _, o := range outcomes {
+		muj := (o.Opponent.Rating - 1500) / glickoScale
+		g := impact(o.Opponent.Deviation / glickoScale)
+		e := 1 / (1 + math.Exp(-g*(mu-muj)))
+
+		variance += g * g * e * (1 - e)
+		sum += g * (o.Score - e)
+	}
+	v := 1 / variance
+	delta := v * sum
+
+	sigma = volatility(delta, phi, v, sigma)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	mu += phi * phi * sum
+
+	return Rating{Rating: mu*glickoScale + 1500, Deviation: phi * glickoScale, Volatility: sigma}
+}
+
+// Reduces the impact of the outcome by the deviation of the opponent
+func impact(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// Returns the new volatility found by the Illinois algorithm
+func volatility(delta, phi, v, sigma float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	lo := a
+	var hi float64
+	if delta*delta > phi*phi+v {
+		hi = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		hi = a - k*tau
+	}
+
+	fLo, fHi := f(lo), f(hi)
+	for math.Abs(hi-lo) > epsilon {
+		c := lo + (lo-hi)*fLo/(fHi-fLo)
+		fC := f(c)
+		if fC*fHi <= 0 {
+			lo, fLo = hi, fHi
+		} else {
+			fLo /= 2
+		}
+		hi, fHi = c, fC
+	}
+	return math.Exp(lo / 2)
+}
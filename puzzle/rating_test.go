@@ -0,0 +1,27 @@
+package puzzle
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRating_Update(t *testing.T) {
+	// Example of the Glicko-2 paper
+	r := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}.Update(
+		Outcome{Opponent: Rating{Rating: 1400, Deviation: 30}, Score: 1},
+		Outcome{Opponent: Rating{Rating: 1550, Deviation: 100}, Score: 0},
+		Outcome{Opponent: Rating{Rating: 1700, Deviation: 300}, Score: 0},
+	)
+
+	expected := Rating{Rating: 1464.06, Deviation: 151.52, Volatility: 0.05999}
+	if math.Abs(r.Rating-expected.Rating) > 0.01 || math.Abs(r.Deviation-expected.Deviation) > 0.01 ||
+		math.Abs(r.Volatility-expected.Volatility) > 0.00001 {
+		t.Fatalf("Expected %+v, got %+v", expected, r)
+	}
+
+	// Deviation grows without games
+	idle := expected.Update()
+	if idle.Rating != expected.Rating || math.Abs(idle.Deviation-151.875) > 0.01 {
+		t.Fatalf("Unexpected idle rating %+v", idle)
+	}
+}
@@ -0,0 +1,215 @@
+package puzzle
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+type Status int
+
+const (
+	Solving Status = iota
+	Solved
+	Failed
+)
+
+func (s Status) String() string {
+	switch s {
+	case Solving:
+		return "solving"
+	case Solved:
+		return "solved"
+	case Failed:
+		return "failed"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+var ErrFinished = errors.New("puzzle: session is finished")
+
+// Ratings of the puzzles by their difficulties
+var difficultyRatings = map[Difficulty]Rating{
+	Easy:   {Rating: 1200, Deviation: 100, Volatility: 0.06},
+	Medium: {Rating: 1600, Deviation: 100, Volatility: 0.06},
+	Hard:   {Rating: 2000, Deviation: 100, Volatility: 0.06},
+}
+
+// Session is a try to solve a puzzle, the replies of the solution are played automatically
+type Session struct {
+	puzzle Puzzle
+	game   core.Game
+	// Index of the next move of the solution
+	next       int
+	status     Status
+	start, end time.Time
+	now        func() time.Time
+	// Stats of a user are updated by the session only once
+	recorded bool
+}
+
+// Starts the session and its clock, the puzzle has to have a solution
+func NewSession(p Puzzle) (*Session, error) {
+	if err := checkLine(p); err != nil {
+		return nil, err
+	}
+
+	s := &Session{puzzle: p, game: p.Start.Game(), now: time.Now}
+	s.start = s.now()
+	return s, nil
+}
+
+// Checks that the solution ends by a move of the solver
+func checkLine(p Puzzle) error {
+	if len(p.Moves) == 0 {
+		return fmt.Errorf("puzzle %s: no moves", p.ID)
+	}
+	if len(p.Moves)%2 == 0 {
+		return fmt.Errorf("puzzle %s: line ends by a reply", p.ID)
+	}
+	return nil
+}
+
+func (s *Session) Puzzle() Puzzle {
+	return s.puzzle
+}
+
+// Returns the position the user has to move in
+func (s *Session) Snapshot() core.Snapshot {
+	return s.game.Snapshot()
+}
+
+func (s *Session) Status() Status {
+	return s.status
+}
+
+// Returns time spent on the puzzle till it was finished
+func (s *Session) Elapsed() time.Duration {
+	if s.status == Solving {
+		return s.now().Sub(s.start)
+	}
+	return s.end.Sub(s.start)
+}
+
+// Play validates the move of the user by the rules, the pieces are taken from the board.
+// Returns the reply played after the correct move, the zero move if the puzzle is solved.
+// Any mate solves the puzzle, other moves out of the solution fail it
+func (s *Session) Play(move core.Move) (core.Move, error) {
+	if s.status != Solving {
+		return core.Move{}, ErrFinished
+	}
+	if s.next >= len(s.puzzle.Moves) {
+		return core.Move{}, fmt.Errorf("puzzle %s: no move %d in the line", s.puzzle.ID, s.next)
+	}
+
+	move = s.game.CompleteMove(move)
+	expected := s.game.CompleteMove(s.puzzle.Moves[s.next])
+	if err := s.game.Play(move); err != nil {
+		return core.Move{}, err
+	}
+
+	switch {
+	case s.game.Outcome() == core.Checkmate:
+		s.finish(Solved)
+		return core.Move{}, nil
+	case move != expected:
+		s.finish(Failed)
+		return core.Move{}, nil
+	case s.next+1 == len(s.puzzle.Moves):
+		s.finish(Solved)
+		return core.Move{}, nil
+	}
+
+	reply := s.puzzle.Moves[s.next+1]
+	if err := s.game.Play(reply); err != nil {
+		return core.Move{}, fmt.Errorf("puzzle %s: reply: %w", s.puzzle.ID, err)
+	}
+	s.next += 2
+	return reply, nil
+}
+
+func (s *Session) finish(status Status) {
+	s.status, s.end = status, s.now()
+}
+
+// Stats are the puzzle rating of a user and the results of the solved puzzles
+type Stats struct {
+	Rating         Rating
+	Solved, Failed int
+	// Puzzles solved in a row now and the most of them ever
+	Streak, BestStreak int
+	// Time spent on all puzzles and on the solved ones
+	Time, SolvedTime time.Duration
+}
+
+// Returns average time spent on a solved puzzle
+func (st Stats) AverageSolveTime() time.Duration {
+	if st.Solved == 0 {
+		return 0
+	}
+	return st.SolvedTime / time.Duration(st.Solved)
+}
+
+// Trainer keeps the stats of the users, it can be shared between goroutines
+type Trainer struct {
+	mu    sync.Mutex
+	users map[string]*Stats
+}
+
+func NewTrainer() *Trainer {
+	return &Trainer{users: map[string]*Stats{}}
+}
+
+// Returns stats of the user, new users have the initial rating
+func (t *Trainer) Stats(user string) Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *t.stats(user)
+}
+
+func (t *Trainer) stats(user string) *Stats {
+	st, ok := t.users[user]
+	if !ok {
+		st = &Stats{Rating: NewRating()}
+		t.users[user] = st
+	}
+	return st
+}
+
+// Record updates the stats of the user by the finished session, the puzzle is rated by its difficulty.
+// A session is recorded only once
+func (t *Trainer) Record(user string, s *Session) (Stats, error) {
+	if s.Status() == Solving {
+		return Stats{}, errors.New("puzzle: session isn't finished")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if s.recorded {
+		return Stats{}, errors.New("puzzle: session is already recorded")
+	}
+	s.recorded = true
+
+	st := t.stats(user)
+	outcome := Outcome{Opponent: difficultyRatings[s.puzzle.Difficulty]}
+	elapsed := s.Elapsed()
+
+	st.Time += elapsed
+	if s.Status() == Solved {
+		outcome.Score = 1
+		st.Solved++
+		st.Streak++
+		st.BestStreak = max(st.BestStreak, st.Streak)
+		st.SolvedTime += elapsed
+	} else {
+		st.Failed++
+		st.Streak = 0
+	}
+
+	st.Rating = st.Rating.Update(outcome)
+	return *st, nil
+}
@@ -0,0 +1,177 @@
+package puzzle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+func newPuzzle(t *testing.T, fen, difficulty string, moves ...string) Puzzle {
+	t.Helper()
+
+	rec := record{ID: "test", FEN: fen, Moves: moves, Difficulty: difficulty}
+	p, err := rec.puzzle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// Returns the move given by its squares only
+func squares(t *testing.T, uci string) core.Move {
+	t.Helper()
+
+	source, err := core.ParseSquare(uci[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := core.ParseSquare(uci[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return core.Move{Source: core.Cell{Position: source}, Target: core.Cell{Position: target}}
+}
+
+// Session with a clock which advances by the step on every reading
+func newSession(t *testing.T, p Puzzle, step time.Duration) *Session {
+	t.Helper()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := NewSession(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+	s.start = now
+	return s
+}
+
+func TestSession_Play(t *testing.T) {
+	fork := newPuzzle(t, "q3k3/8/8/1N6/8/8/8/4K3 w - - 0 1", "medium", "b5c7", "e8f7", "c7a8")
+	mate := newPuzzle(t, "6k1/5ppp/8/8/8/8/8/RR4K1 w - - 0 1", "easy", "a1a8")
+
+	tests := []struct {
+		name    string
+		puzzle  Puzzle
+		moves   []string
+		replies []string
+		status  Status
+	}{
+		{"solved", fork, []string{"b5c7", "c7a8"}, []string{"e8f7", ""}, Solved},
+		{"wrong move", fork, []string{"b5d6"}, []string{""}, Failed},
+		{"wrong second move", fork, []string{"b5c7", "c7e6"}, []string{"e8f7", ""}, Failed},
+		{"unfinished", fork, []string{"b5c7"}, []string{"e8f7"}, Solving},
+		{"alternative mate", mate, []string{"b1b8"}, []string{""}, Solved},
+	}
+
+	for _, test := range tests {
+		s := newSession(t, test.puzzle, time.Second)
+		for i, uci := range test.moves {
+			game := s.Snapshot().Game()
+			reply, err := s.Play(squares(t, uci))
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+
+			game.Play(game.CompleteMove(squares(t, uci)))
+			if expected := test.replies[i]; (expected == "" && reply != core.Move{}) || (expected != "" && game.UCI(reply) != expected) {
+				t.Fatalf("%s: expected reply %q, got %v", test.name, expected, reply)
+			}
+		}
+		if s.Status() != test.status {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.status, s.Status())
+		}
+	}
+}
+
+func TestSession_Errors(t *testing.T) {
+	s := newSession(t, newPuzzle(t, "q3k3/8/8/1N6/8/8/8/4K3 w - - 0 1", "medium", "b5c7", "e8f7", "c7a8"), time.Second)
+
+	// Illegal moves don't fail the puzzle
+	if _, err := s.Play(squares(t, "b5b6")); err == nil || s.Status() != Solving {
+		t.Fatalf("Expected an error, got %v with %v", err, s.Status())
+	}
+
+	s.Play(squares(t, "b5a3"))
+	if _, err := s.Play(squares(t, "e1e2")); !errors.Is(err, ErrFinished) {
+		t.Fatalf("Expected ErrFinished, got %v", err)
+	}
+
+	empty := Puzzle{ID: "empty", Start: s.Puzzle().Start}
+	if _, err := NewSession(empty); err == nil {
+		t.Fatal("Expected an error for a puzzle without moves")
+	}
+
+	// Line can't end by a reply
+	rec := record{ID: "reply", FEN: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", Moves: []string{"e2e4", "e7e5"}, Difficulty: "easy"}
+	if _, err := rec.puzzle(); err == nil {
+		t.Fatal("Expected an error for a line ending by a reply")
+	}
+	reply := Puzzle{ID: "reply", Start: s.Puzzle().Start, Moves: []core.Move{squares(t, "e1e2"), squares(t, "e8e7")}}
+	if _, err := NewSession(reply); err == nil {
+		t.Fatal("Expected an error for a line ending by a reply")
+	}
+}
+
+func TestTrainer_Record(t *testing.T) {
+	fork := newPuzzle(t, "q3k3/8/8/1N6/8/8/8/4K3 w - - 0 1", "medium", "b5c7", "e8f7", "c7a8")
+	trainer := NewTrainer()
+
+	play := func(moves ...string) Stats {
+		t.Helper()
+
+		s := newSession(t, fork, 10*time.Second)
+		for _, uci := range moves {
+			if _, err := s.Play(squares(t, uci)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		stats, err := trainer.Record("alice", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+
+	initial := trainer.Stats("alice")
+	first := play("b5c7", "c7a8")
+	second := play("b5c7", "c7a8")
+	third := play("b5d6")
+
+	if first.Rating.Rating <= initial.Rating.Rating || second.Rating.Rating <= first.Rating.Rating ||
+		third.Rating.Rating >= second.Rating.Rating || third.Rating.Deviation >= initial.Rating.Deviation {
+		t.Fatalf("Unexpected ratings %v, %v, %v, %v", initial.Rating, first.Rating, second.Rating, third.Rating)
+	}
+	if third.Solved != 2 || third.Failed != 1 || third.Streak != 0 || third.BestStreak != 2 {
+		t.Fatalf("Unexpected results %+v", third)
+	}
+	// Clock is read once when the session finishes
+	if third.Time != 30*time.Second || third.SolvedTime != 20*time.Second || third.AverageSolveTime() != 10*time.Second {
+		t.Fatalf("Unexpected times %v, %v, %v", third.Time, third.SolvedTime, third.AverageSolveTime())
+	}
+
+	if other := trainer.Stats("bob"); other.Rating != NewRating() || other.Solved != 0 {
+		t.Fatalf("Unexpected stats of another user %+v", other)
+	}
+	if _, err := trainer.Record("alice", newSession(t, fork, time.Second)); err == nil {
+		t.Fatal("Expected an error for an unfinished session")
+	}
+
+	// Session counts once
+	s := newSession(t, fork, time.Second)
+	s.Play(squares(t, "b5d6"))
+	if _, err := trainer.Record("alice", s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trainer.Record("alice", s); err == nil {
+		t.Fatal("Expected an error for a recorded session")
+	}
+	if stats := trainer.Stats("alice"); stats.Failed != 2 {
+		t.Fatalf("Unexpected results %+v", stats)
+	}
+}
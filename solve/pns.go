@@ -0,0 +1,99 @@
+package solve
+
+import "github.com/zzvanq/shahio/core"
+
+// Proof and disproof numbers of solved nodes
+const infinity = 1 << 40
+
+// Node of proof-number search, positions are kept as snapshots to save memory
+type pnNode struct {
+	snapshot core.Snapshot
+	ply      int
+	// Proof and disproof numbers
+	pn, dn   int
+	expanded bool
+	parent   *pnNode
+	children []*pnNode
+}
+
+// Proves the game reached after the plies by proof-number search,
+// the game mustn't be ended
+func (s *solver) prove(game *core.Game, ply int) bool {
+	root := &pnNode{snapshot: game.Snapshot(), ply: ply, pn: 1, dn: 1}
+
+	for root.pn != 0 && root.dn != 0 {
+		node := root
+		for node.expanded {
+			node = s.mostProving(node)
+		}
+
+		s.expand(node)
+		for ; node != nil; node = node.parent {
+			s.update(node)
+		}
+	}
+
+	return root.pn == 0
+}
+
+// Returns the child whose solution solves the node the soonest
+func (s *solver) mostProving(node *pnNode) *pnNode {
+	var best *pnNode
+	for _, c := range node.children {
+		switch {
+		case best == nil:
+			best = c
+		case s.attacks(node.ply) && c.pn < best.pn:
+			best = c
+		case !s.attacks(node.ply) && c.dn < best.dn:
+			best = c
+		}
+	}
+	return best
+}
+
+func (s *solver) expand(node *pnNode) {
+	game := node.snapshot.Game()
+	node.expanded = true
+
+	for _, c := range s.children(&game) {
+		next := &pnNode{snapshot: c.game.Snapshot(), ply: node.ply + 1, parent: node, pn: 1, dn: 1}
+
+		res, ok := s.results[key{next.snapshot, next.ply}]
+		switch st := s.status(&c.game, next.ply); {
+		case st == proven || (ok && res):
+			next.pn, next.dn = 0, infinity
+		case st == disproven || ok:
+			next.pn, next.dn = infinity, 0
+		}
+
+		node.children = append(node.children, next)
+	}
+}
+
+func (s *solver) update(node *pnNode) {
+	if !node.expanded {
+		return
+	}
+
+	sum, least := 0, infinity
+	for _, c := range node.children {
+		if s.attacks(node.ply) {
+			sum, least = min(sum+c.dn, infinity), min(least, c.pn)
+		} else {
+			sum, least = min(sum+c.pn, infinity), min(least, c.dn)
+		}
+	}
+
+	if s.attacks(node.ply) {
+		node.pn, node.dn = least, sum
+	} else {
+		node.pn, node.dn = sum, least
+	}
+
+	// Solved subtrees are kept as results only
+	if node.pn == 0 || node.dn == 0 {
+		s.results[key{node.snapshot, node.ply}] = node.pn == 0
+		node.children = nil
+	}
+}
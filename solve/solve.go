@@ -0,0 +1,194 @@
+// Package solve solves mate problems: direct mates, helpmates, selfmates and reflexmates
+package solve
+
+import "github.com/zzvanq/shahio/core"
+
+// Mode is a stipulation of a problem
+type Mode int
+
+const (
+	// The side to move mates against any defence
+	Direct Mode = iota
+	// Both sides cooperate to mate the side to move,
+	// so the problem starts with the move of the mated side
+	Help
+	// The side to move forces the opponent to mate it against any defence
+	Self
+	// Selfmate where either side has to mate whenever it can mate in one
+	Reflex
+)
+
+// Remaining plies searched exhaustively, deeper positions are proven by proof-number search
+const shallowPlies = 3
+
+// Node is a move of a solution with every move the solution continues with,
+// Next of the mating move is empty
+type Node struct {
+	Move core.Move
+	Next []*Node
+}
+
+// MateIn returns key moves of the side to move which force mate within n moves
+func MateIn(game *core.Game, n int) []*Node {
+	return Solve(game, Direct, n)
+}
+
+func Helpmate(game *core.Game, n int) []*Node {
+	return Solve(game, Help, n)
+}
+
+func Selfmate(game *core.Game, n int) []*Node {
+	return Solve(game, Self, n)
+}
+
+func Reflexmate(game *core.Game, n int) []*Node {
+	return Solve(game, Reflex, n)
+}
+
+// Solve returns key moves of the problem in n moves with the solution trees.
+// Attacking moves of the tree are every move which keeps the solution,
+// defending moves are every move the defence has
+func Solve(game *core.Game, mode Mode, n int) []*Node {
+	if n < 1 || game.Outcome() != core.NoOutcome {
+		return nil
+	}
+
+	s := &solver{mode: mode, plies: 2 * n, mated: game.Turn(), results: map[key]bool{}}
+	if mode == Direct {
+		s.plies--
+		s.mated = opponent(game.Turn())
+	}
+
+	return s.tree(game, 0)
+}
+
+type status int
+
+const (
+	open status = iota
+	proven
+	disproven
+)
+
+type solver struct {
+	mode  Mode
+	plies int
+	// Side which has to get mated
+	mated core.Side
+	// Solved positions by the ply they are reached at
+	results map[key]bool
+}
+
+type key struct {
+	core.Snapshot
+	ply int
+}
+
+type child struct {
+	move core.Move
+	game core.Game
+}
+
+// Returns the moves after the given ply and the games they lead to
+func (s *solver) children(game *core.Game) []child {
+	var res, mates []child
+	for _, move := range game.LegalMoves() {
+		next := game.Clone()
+		if err := next.Play(move); err != nil {
+			continue
+		}
+
+		res = append(res, child{move, next})
+		if next.Outcome() == core.Checkmate {
+			mates = append(mates, child{move, next})
+		}
+	}
+
+	// Mate in one is obligatory in reflexmates
+	if s.mode == Reflex && len(mates) > 0 {
+		return mates
+	}
+	return res
+}
+
+// Checks if the side to move after the ply picks the moves
+func (s *solver) attacks(ply int) bool {
+	return s.mode == Help || ply%2 == 0
+}
+
+// Returns status of the game reached after the plies without searching
+func (s *solver) status(game *core.Game, ply int) status {
+	switch game.Outcome() {
+	case core.Checkmate:
+		if game.Turn() == s.mated {
+			return proven
+		}
+		return disproven
+	case core.Stalemate:
+		return disproven
+	}
+
+	if ply == s.plies {
+		return disproven
+	}
+	return open
+}
+
+// Checks if the goal is reached from the game reached after the plies
+func (s *solver) reached(game *core.Game, ply int) bool {
+	switch s.status(game, ply) {
+	case proven:
+		return true
+	case disproven:
+		return false
+	}
+
+	k := key{game.Snapshot(), ply}
+	if res, ok := s.results[k]; ok {
+		return res
+	}
+
+	var res bool
+	if s.plies-ply > shallowPlies {
+		res = s.prove(game, ply)
+	} else {
+		res = s.search(game, ply)
+	}
+
+	s.results[k] = res
+	return res
+}
+
+func (s *solver) search(game *core.Game, ply int) bool {
+	attacks := s.attacks(ply)
+	for _, c := range s.children(game) {
+		if s.reached(&c.game, ply+1) == attacks {
+			return attacks
+		}
+	}
+	return !attacks
+}
+
+// Returns moves of the game which keep the solution
+func (s *solver) tree(game *core.Game, ply int) []*Node {
+	var res []*Node
+	for _, c := range s.children(game) {
+		if !s.reached(&c.game, ply+1) {
+			continue
+		}
+
+		node := &Node{Move: c.move}
+		if c.game.Outcome() == core.NoOutcome {
+			node.Next = s.tree(&c.game, ply+1)
+		}
+		res = append(res, node)
+	}
+	return res
+}
+
+func opponent(side core.Side) core.Side {
+	if side == core.White {
+		return core.Black
+	}
+	return core.White
+}
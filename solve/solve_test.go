@@ -0,0 +1,154 @@
+package solve
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+func newGame(t *testing.T, fen string) core.Game {
+	t.Helper()
+
+	s, err := core.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Game()
+}
+
+func keys(game *core.Game, nodes []*Node) []string {
+	var res []string
+	for _, node := range nodes {
+		res = append(res, game.SAN(node.Move))
+	}
+	return res
+}
+
+// Checks the tree by the rules of the mode: every defence is answered
+// and every line ends by a mate of the right side within the plies
+func checkTree(t *testing.T, game *core.Game, mode Mode, nodes []*Node, ply, plies int, mated core.Side) {
+	t.Helper()
+
+	if mode != Help && ply%2 == 1 {
+		expected := len(game.LegalMoves())
+		if mode == Reflex {
+			if mates := mates(game); mates > 0 {
+				expected = mates
+			}
+		}
+		if len(nodes) != expected {
+			t.Fatalf("%s: %d defences of %d are answered", game.Snapshot().FEN(), len(nodes), expected)
+		}
+	}
+
+	for _, node := range nodes {
+		next := game.Clone()
+		if err := next.Play(node.Move); err != nil {
+			t.Fatal(err)
+		}
+
+		switch {
+		case len(node.Next) > 0 && ply+1 < plies:
+			checkTree(t, &next, mode, node.Next, ply+1, plies, mated)
+		case next.Outcome() != core.Checkmate || next.Turn() != mated:
+			t.Fatalf("%s: line doesn't end by a mate", next.Snapshot().FEN())
+		}
+	}
+}
+
+func mates(game *core.Game) int {
+	res := 0
+	for _, move := range game.LegalMoves() {
+		next := game.Clone()
+		if next.Play(move) == nil && next.Outcome() == core.Checkmate {
+			res++
+		}
+	}
+	return res
+}
+
+func TestMateIn(t *testing.T) {
+	tests := []struct {
+		fen  string
+		n    int
+		keys []string
+	}{
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 1, []string{"Ra8#"}},
+		{"k7/8/2K5/8/8/8/8/1R6 w - - 0 1", 1, nil},
+		{"k7/8/2K5/8/8/8/8/1R6 w - - 0 1", 2, []string{"Kc7"}},
+		// Deeper mates are proven by proof-number search
+		{"k7/8/2K5/8/8/8/8/1R6 w - - 0 1", 3, []string{"Rd1", "Re1", "Rf1", "Rg1", "Rh1", "Rb6", "Ra1+", "Kb6", "Kc7"}},
+	}
+
+	for _, test := range tests {
+		game := newGame(t, test.fen)
+		nodes := MateIn(&game, test.n)
+
+		if got := keys(&game, nodes); !slices.Equal(got, test.keys) {
+			t.Fatalf("%s #%d: expected %v, got %v", test.fen, test.n, test.keys, got)
+		}
+		checkTree(t, &game, Direct, nodes, 0, 2*test.n-1, core.Black)
+	}
+}
+
+func TestHelpmate(t *testing.T) {
+	game := newGame(t, "7k/8/6K1/8/8/8/8/R7 b - - 0 1")
+
+	nodes := Helpmate(&game, 1)
+	if got := keys(&game, nodes); !slices.Equal(got, []string{"Kg8"}) {
+		t.Fatalf("Unexpected keys %v", got)
+	}
+	if len(nodes[0].Next) != 1 || nodes[0].Next[0].Next != nil {
+		t.Fatalf("Unexpected solution %v", nodes[0].Next)
+	}
+
+	checkTree(t, &game, Help, Helpmate(&game, 2), 0, 4, core.Black)
+}
+
+func TestSelfmate(t *testing.T) {
+	tests := []struct {
+		fen    string
+		mode   Mode
+		keys   []string
+		defend []string
+	}{
+		{"4r3/8/8/4Q3/8/8/2R3PP/k6K w - - 0 1", Self, []string{"Qe1+"}, []string{"Rxe1#"}},
+		// The king escapes from the check
+		{"4r3/8/8/4Q3/8/8/6PP/k6K w - - 0 1", Self, nil, nil},
+		// White has to mate by Qb2#
+		{"4r3/8/8/4Q3/8/8/2R3PP/k6K w - - 0 1", Reflex, nil, nil},
+		// Black has to mate, so quiet moves of the queen work too
+		{"4r3/8/8/4Q3/8/8/6PP/k6K w - - 0 1", Reflex, []string{"Qd6", "Qc7", "Qb8", "Qe1+", "Qg5", "Qh5", "Qd5"}, []string{"Re1#"}},
+	}
+
+	for _, test := range tests {
+		game := newGame(t, test.fen)
+		nodes := Solve(&game, test.mode, 1)
+
+		if got := keys(&game, nodes); !slices.Equal(got, test.keys) {
+			t.Fatalf("%s: expected %v, got %v", test.fen, test.keys, got)
+		}
+		checkTree(t, &game, test.mode, nodes, 0, 2, core.White)
+
+		if len(nodes) > 0 {
+			next := game.Clone()
+			next.Play(nodes[0].Move)
+			if got := keys(&next, nodes[0].Next); !slices.Equal(got, test.defend) {
+				t.Fatalf("%s: expected %v, got %v", test.fen, test.defend, got)
+			}
+		}
+	}
+}
+
+func TestSolve_Ended(t *testing.T) {
+	game := newGame(t, "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1")
+	if nodes := MateIn(&game, 2); nodes != nil {
+		t.Fatalf("Expected no solution of an ended game, got %d", len(nodes))
+	}
+
+	game = core.NewGame()
+	if nodes := MateIn(&game, 0); nodes != nil {
+		t.Fatalf("Expected no solution in zero moves, got %d", len(nodes))
+	}
+}
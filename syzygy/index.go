@@ -0,0 +1,149 @@
+package syzygy
+
+// Position encoding of the Syzygy tables.
+// Squares are numbered 0..63 from a1 to h8 rank by rank
+
+const maxPieces = 7
+
+var (
+	// Squares below the a1-h8 diagonal to 0..27
+	mapB1H1H7 [64]int
+	// Squares of the a1-d1-d4 triangle to 0..9, the diagonal goes last
+	mapA1D1D4 [64]int
+	// Legal placements of two kings with the first one in the a1-d1-d4 triangle to 0..461
+	mapKK [10][64]int
+	// binomial[k][n] ways to choose k out of n
+	binomial [maxPieces][64]uint64
+	// Squares a2-h7 to 0..47, the leading pawn has the highest value
+	mapPawns [64]int
+	// Index of the leading pawns group by their count and the first square
+	leadPawnIdx [maxPieces][64]uint64
+	// Number of the leading pawns placements by their count and file
+	leadPawnsSize [maxPieces][4]uint64
+)
+
+func init() {
+	code := 0
+	for s := range 64 {
+		if offA1H8(s) < 0 {
+			mapB1H1H7[s] = code
+			code++
+		}
+	}
+
+	var diagonal []int
+	code = 0
+	for s := range 28 {
+		if offA1H8(s) < 0 && file(s) <= 3 {
+			mapA1D1D4[s] = code
+			code++
+		} else if offA1H8(s) == 0 && file(s) <= 3 {
+			diagonal = append(diagonal, s)
+		}
+	}
+	for _, s := range diagonal {
+		mapA1D1D4[s] = code
+		code++
+	}
+
+	type kk struct{ idx, s2 int }
+	var bothOnDiagonal []kk
+	code = 0
+	for idx := range 10 {
+		for s1 := range 28 {
+			// b1 is mapped to 0
+			if mapA1D1D4[s1] != idx || (idx == 0 && s1 != 1) {
+				continue
+			}
+
+			for s2 := range 64 {
+				switch {
+				case distance(s1, s2) <= 1:
+					// Illegal position
+				case offA1H8(s1) == 0 && offA1H8(s2) > 0:
+					// First on the diagonal, second above
+				case offA1H8(s1) == 0 && offA1H8(s2) == 0:
+					bothOnDiagonal = append(bothOnDiagonal, kk{idx, s2})
+				default:
+					mapKK[idx][s2] = code
+					code++
+				}
+			}
+		}
+	}
+	for _, p := range bothOnDiagonal {
+		mapKK[p.idx][p.s2] = code
+		code++
+	}
+
+	binomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < maxPieces && k <= n; k++ {
+			if k > 0 {
+				binomial[k][n] += binomial[k-1][n-1]
+			}
+			if k < n {
+				binomial[k][n] += binomial[k][n-1]
+			}
+		}
+	}
+
+	available := 47
+	for count := 1; count < maxPieces; count++ {
+		for f := range 4 {
+			var idx uint64
+			for r := 1; r <= 6; r++ {
+				s := r*8 + f
+				if count == 1 {
+					mapPawns[s] = available
+					mapPawns[flipFile(s)] = available - 1
+					available -= 2
+				}
+				leadPawnIdx[count][s] = idx
+				idx += binomial[count-1][mapPawns[s]]
+			}
+			leadPawnsSize[count][f] = idx
+		}
+	}
+}
+
+func file(s int) int {
+	return s & 7
+}
+
+func rank(s int) int {
+	return s >> 3
+}
+
+// Negative below the a1-h8 diagonal, positive above it
+func offA1H8(s int) int {
+	return rank(s) - file(s)
+}
+
+func flipFile(s int) int {
+	return s ^ 7
+}
+
+func flipRank(s int) int {
+	return s ^ 56
+}
+
+func flipDiagonal(s int) int {
+	return ((s >> 3) | (s << 3)) & 63
+}
+
+func distance(s1, s2 int) int {
+	return max(abs(file(s1)-file(s2)), abs(rank(s1)-rank(s2)))
+}
+
+// Distance of the file from the nearest edge
+func edgeDistance(f int) int {
+	return min(f, 7-f)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
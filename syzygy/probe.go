@@ -0,0 +1,273 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"slices"
+	"strings"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Piece codes of the figures, white ones
+var figureCodes = map[core.Figure]int{
+	core.Pawn: 1, core.Knight: 2, core.Bishop: 3, core.Rook: 4, core.Queen: 5, core.King: 6,
+}
+
+// Piece of a probed position
+type placed struct {
+	code   int
+	square int
+}
+
+// Returns material of the position like "KRPvKR", white goes first
+func materialKey(s core.Snapshot) string {
+	var white, black []byte
+	for _, p := range pieces(s) {
+		letter := pieceLetters[6-p.code&7]
+		if p.code&codeBlack != 0 {
+			black = append(black, letter)
+		} else {
+			white = append(white, letter)
+		}
+	}
+
+	order := func(a, b byte) int {
+		return strings.IndexByte(pieceLetters, a) - strings.IndexByte(pieceLetters, b)
+	}
+	slices.SortFunc(white, order)
+	slices.SortFunc(black, order)
+	return string(white) + "v" + string(black)
+}
+
+// Returns pieces of the position by squares in ascending order
+func pieces(s core.Snapshot) []placed {
+	var res []placed
+	for row := range 8 {
+		for col := range 8 {
+			pos, _ := core.NewPosition(row, col)
+			pic := s.At(pos)
+			if pic == core.Empty {
+				continue
+			}
+
+			code := figureCodes[pic.Figure()]
+			if pic.Side() == core.Black {
+				code |= codeBlack
+			}
+			res = append(res, placed{code, row*8 + col})
+		}
+	}
+	return res
+}
+
+// Returns the stored value of the position and the table part file,
+// ok is false if a DTZ table stores values only for the other side to move
+func (t *table) probe(s core.Snapshot) (value, f int, ok bool) {
+	d, idx, f, ok := t.encode(s)
+	if !ok {
+		return 0, 0, false
+	}
+	return d.decompress(idx), f, true
+}
+
+// Returns the table part and the index of the position in it
+func (t *table) encode(s core.Snapshot) (d *pairsData, idx uint64, f int, ok bool) {
+	black := s.Turn() == core.Black
+
+	// Symmetric tables store only white to move.
+	// Tables have the stronger side as white, otherwise the colors are switched
+	flip := (t.key == t.key2 && black) || materialKey(s) != t.key
+	flipColor, flipSquares := 0, 0
+	if flip {
+		flipColor, flipSquares = codeBlack, 56
+	}
+	stm := 0
+	if flip != black {
+		stm = 1
+	}
+
+	all := pieces(s)
+	squares := make([]int, 0, len(all))
+	codes := make([]int, 0, len(all))
+
+	// Tables with pawns are split by the file of the leading pawn,
+	// the pawn nearest to the edge and with the lowest rank
+	tbFile, leadPawns := 0, 0
+	if t.hasPawns {
+		lead := t.get(0, 0).pieces[0] ^ flipColor
+		for _, p := range all {
+			if p.code == lead {
+				squares = append(squares, p.square^flipSquares)
+				codes = append(codes, p.code^flipColor)
+			}
+		}
+		leadPawns = len(squares)
+
+		i := 0
+		for j := range squares {
+			if mapPawns[squares[j]] > mapPawns[squares[i]] {
+				i = j
+			}
+		}
+		squares[0], squares[i] = squares[i], squares[0]
+		tbFile = edgeDistance(file(squares[0]))
+	}
+
+	if t.typ == dtzTable {
+		flags := t.get(stm, tbFile).flags
+		if int(flags&flagSTM) != stm && (t.key != t.key2 || t.hasPawns) {
+			return nil, 0, 0, false
+		}
+	}
+
+	for _, p := range all {
+		if t.hasPawns && p.code == t.get(0, 0).pieces[0]^flipColor {
+			continue
+		}
+		squares = append(squares, p.square^flipSquares)
+		codes = append(codes, p.code^flipColor)
+	}
+
+	d = t.get(stm, tbFile)
+
+	// Pieces are reordered as the table stores them
+	for i := leadPawns; i < len(squares)-1; i++ {
+		for j := i + 1; j < len(squares); j++ {
+			if d.pieces[i] == codes[j] {
+				codes[i], codes[j] = codes[j], codes[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	return d, t.index(d, squares, leadPawns), tbFile, true
+}
+
+// Returns index of the position in the table,
+// the squares are ordered as the table pieces
+func (t *table) index(d *pairsData, squares []int, leadPawns int) uint64 {
+	// The leading piece is moved into the a1-d1-d4 triangle
+	if file(squares[0]) > 3 {
+		for i := range squares {
+			squares[i] = flipFile(squares[i])
+		}
+	}
+
+	var idx uint64
+	if t.hasPawns {
+		idx = leadPawnIdx[leadPawns][squares[0]]
+
+		rest := squares[1:leadPawns]
+		slices.SortStableFunc(rest, func(a, b int) int { return mapPawns[a] - mapPawns[b] })
+		for i, s := range rest {
+			idx += binomial[i+1][mapPawns[s]]
+		}
+	} else {
+		if rank(squares[0]) > 3 {
+			for i := range squares {
+				squares[i] = flipRank(squares[i])
+			}
+		}
+
+		// The first piece of the leading group off the diagonal goes below it
+		for i := range d.groupLen[0] {
+			if offA1H8(squares[i]) == 0 {
+				continue
+			}
+			if offA1H8(squares[i]) > 0 {
+				for j := i; j < len(squares); j++ {
+					squares[j] = flipDiagonal(squares[j])
+				}
+			}
+			break
+		}
+
+		idx = t.leadingIndex(squares)
+	}
+
+	idx *= d.groupIdx[0]
+
+	// Remaining pawns and then pieces by ascending squares
+	remainingPawns := 0
+	if t.hasPawns && t.pawnCount[1] > 0 {
+		remainingPawns = 1
+	}
+
+	start := d.groupLen[0]
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := squares[start : start+d.groupLen[next]]
+		slices.Sort(group)
+
+		var n uint64
+		for i, s := range group {
+			adjust := 0
+			for _, prev := range squares[:start] {
+				if s > prev {
+					adjust++
+				}
+			}
+			n += binomial[i+1][s-adjust-8*remainingPawns]
+		}
+
+		remainingPawns = 0
+		idx += n * d.groupIdx[next]
+		start += d.groupLen[next]
+	}
+
+	return idx
+}
+
+// Returns index of the leading group of a pawnless table
+func (t *table) leadingIndex(squares []int) uint64 {
+	if !t.hasUniquePieces {
+		return uint64(mapKK[mapA1D1D4[squares[0]]][squares[1]])
+	}
+
+	var adjust1, adjust2 int
+	if squares[1] > squares[0] {
+		adjust1 = 1
+	}
+	if squares[2] > squares[0] {
+		adjust2++
+	}
+	if squares[2] > squares[1] {
+		adjust2++
+	}
+
+	var idx int
+	switch {
+	case offA1H8(squares[0]) != 0:
+		idx = (mapA1D1D4[squares[0]]*63+squares[1]-adjust1)*62 + squares[2] - adjust2
+	case offA1H8(squares[1]) != 0:
+		idx = (6*63+rank(squares[0])*28+mapB1H1H7[squares[1]])*62 + squares[2] - adjust2
+	case offA1H8(squares[2]) != 0:
+		idx = 6*63*62 + 4*28*62 + rank(squares[0])*7*28 + (rank(squares[1])-adjust1)*28 + mapB1H1H7[squares[2]]
+	default:
+		idx = 6*63*62 + 4*28*62 + 4*7*28 + rank(squares[0])*7*6 + (rank(squares[1])-adjust1)*6 + rank(squares[2]) - adjust2
+	}
+	return uint64(idx)
+}
+
+// Returns DTZ in plies of the value stored in the table part of the file
+func (t *table) mapDTZ(f, value int, wdl WDL) int {
+	d := t.get(0, f)
+
+	// Offsets of the maps by the WDL score
+	wdlMap := map[WDL]int{Loss: 1, BlessedLoss: 3, Draw: 0, CursedWin: 2, Win: 0}
+	if d.flags&flagMapped != 0 {
+		i := d.mapIdx[wdlMap[wdl]] + value
+		if d.flags&flagWide != 0 {
+			value = int(binary.LittleEndian.Uint16(t.dtzMap[2*i:]))
+		} else {
+			value = int(t.dtzMap[i])
+		}
+	}
+
+	// Values may be stored in moves
+	if (wdl == Win && d.flags&flagWinPlies == 0) || (wdl == Loss && d.flags&flagLossPlies == 0) ||
+		wdl == CursedWin || wdl == BlessedLoss {
+		value *= 2
+	}
+	return value + 1
+}
@@ -0,0 +1,430 @@
+// Package syzygy probes Syzygy endgame tablebases from local files
+package syzygy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// WDL is a result of the side to move, cursed wins and blessed losses
+// are drawn by the fifty-move rule
+type WDL int
+
+const (
+	Loss WDL = iota - 2
+	BlessedLoss
+	Draw
+	CursedWin
+	Win
+)
+
+// Verdict is a game result decided by the tables
+type Verdict int
+
+const (
+	Undecided Verdict = iota
+	WhiteWins
+	BlackWins
+	Drawn
+)
+
+var (
+	ErrMissingTable = errors.New("syzygy: missing table")
+	ErrCastling     = errors.New("syzygy: position with castling rights")
+)
+
+// Result of probing a position
+type Result struct {
+	// Result of the side to move with the fifty-move rule applied
+	WDL WDL
+	// Plies to the next capture or pawn move with the best play,
+	// positive if the side to move wins, negative if it loses
+	DTZ int
+	// Move which keeps the best result and reaches it the fastest
+	Move core.Move
+}
+
+// Tablebase is a set of tables found in local directories,
+// the tables are read on the first probe
+type Tablebase struct {
+	wdl       map[string]*table
+	dtz       map[string]*table
+	maxPieces int
+}
+
+// Open finds WDL (.rtbw) and DTZ (.rtbz) tables in the directories
+func Open(dirs ...string) (*Tablebase, error) {
+	tb := &Tablebase{wdl: map[string]*table{}, dtz: map[string]*table{}}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			tables := map[string]map[string]*table{extensions[wdlTable]: tb.wdl, extensions[dtzTable]: tb.dtz}[ext]
+			if entry.IsDir() || tables == nil {
+				continue
+			}
+
+			typ := wdlTable
+			if ext == extensions[dtzTable] {
+				typ = dtzTable
+			}
+
+			t, err := newTable(typ, filepath.Join(dir, entry.Name()), strings.TrimSuffix(entry.Name(), ext))
+			if err != nil {
+				return nil, err
+			}
+
+			tables[t.key], tables[t.key2] = t, t
+			tb.maxPieces = max(tb.maxPieces, t.pieceCount)
+		}
+	}
+
+	return tb, nil
+}
+
+// Returns the largest number of pieces, kings included, the tables cover
+func (tb *Tablebase) MaxPieces() int {
+	return tb.maxPieces
+}
+
+// ProbeWDL returns result of the side to move with the fifty-move rule ignored
+func (tb *Tablebase) ProbeWDL(game *core.Game) (WDL, error) {
+	s := game.Snapshot()
+	if err := tb.check(s); err != nil {
+		return Draw, err
+	}
+
+	wdl, _, err := tb.search(s, false)
+	return wdl, err
+}
+
+// ProbeDTZ returns plies to the next capture or pawn move with the best play,
+// positive if the side to move wins, negative if it loses, zero for a draw.
+// Values of cursed wins and blessed losses are beyond 100 plies
+func (tb *Tablebase) ProbeDTZ(game *core.Game) (int, error) {
+	s := game.Snapshot()
+	if err := tb.check(s); err != nil {
+		return 0, err
+	}
+	return tb.probeDTZ(s)
+}
+
+// Probe returns result of the position with the fifty-move rule applied
+// and the best move. Plies since the last capture or pawn move include
+// the clock of the start position, see core.Game.HalfmoveClock
+func (tb *Tablebase) Probe(game *core.Game) (Result, error) {
+	s := game.Snapshot()
+	if err := tb.check(s); err != nil {
+		return Result{}, err
+	}
+
+	dtz, err := tb.probeDTZ(s)
+	if err != nil {
+		return Result{}, err
+	}
+
+	clock := game.HalfmoveClock()
+	res := Result{WDL: applyFiftyMoves(dtz, clock), DTZ: dtz}
+
+	g := s.Game()
+	best := Loss - 1
+	bestDTZ := 0
+	for _, move := range g.LegalMoves() {
+		next, err := s.Play(move)
+		if err != nil {
+			return Result{}, err
+		}
+
+		var moveDTZ int
+		if isZeroing(move) {
+			wdl, _, err := tb.search(next, false)
+			if err != nil {
+				return Result{}, err
+			}
+			moveDTZ = dtzBeforeZeroing(-wdl)
+		} else {
+			if moveDTZ, err = tb.probeDTZ(next); err != nil {
+				return Result{}, err
+			}
+			moveDTZ = -moveDTZ
+			if moveDTZ != 0 {
+				moveDTZ += sign(moveDTZ)
+			}
+		}
+
+		// Mate is reached by the move itself
+		if ng := next.Game(); moveDTZ == 2 && ng.Outcome() == core.Checkmate {
+			moveDTZ = 1
+		}
+
+		// Zeroing move resets the clock
+		moveClock := clock
+		if isZeroing(move) {
+			moveClock = 0
+		}
+		wdl := applyFiftyMoves(moveDTZ, moveClock)
+
+		// Wins are reached the fastest and losses are delayed the most
+		if wdl > best || (wdl == best && wdl != Draw && moveDTZ < bestDTZ) {
+			best, bestDTZ, res.Move = wdl, moveDTZ, move
+		}
+	}
+
+	return res, nil
+}
+
+// Adjudicate decides the game if its position is in the tables.
+// Finished games are decided by their outcome
+func (tb *Tablebase) Adjudicate(game *core.Game) (Verdict, error) {
+	switch game.Outcome() {
+	case core.Checkmate:
+		if game.Turn() == core.White {
+			return BlackWins, nil
+		}
+		return WhiteWins, nil
+	case core.Stalemate:
+		return Drawn, nil
+	}
+
+	res, err := tb.Probe(game)
+	if errors.Is(err, ErrMissingTable) || errors.Is(err, ErrCastling) {
+		return Undecided, nil
+	}
+	if err != nil {
+		return Undecided, err
+	}
+
+	switch {
+	case res.WDL == Win && game.Turn() == core.White, res.WDL == Loss && game.Turn() == core.Black:
+		return WhiteWins, nil
+	case res.WDL == Win || res.WDL == Loss:
+		return BlackWins, nil
+	}
+	return Drawn, nil
+}
+
+// Checks the position is covered by the tables
+func (tb *Tablebase) check(s core.Snapshot) error {
+	if s.Castling() != 0 {
+		return ErrCastling
+	}
+	if n := len(pieces(s)); n > tb.maxPieces {
+		return fmt.Errorf("%w: %d pieces", ErrMissingTable, n)
+	}
+	return nil
+}
+
+// Returns WDL score of the position, zeroing is set if the best move
+// is a capture or a pawn move, then DTZ value of the position isn't stored.
+// Tables don't store positions where the side to move wins by a capture,
+// so the captures are searched. With checkZeroing pawn moves are searched too
+func (tb *Tablebase) search(s core.Snapshot, checkZeroing bool) (WDL, bool, error) {
+	g := s.Game()
+	moves := g.LegalMoves()
+
+	best := Loss
+	searched := 0
+	for _, move := range moves {
+		if !isCapture(move) && (!checkZeroing || move.Source.Figure() != core.Pawn) {
+			continue
+		}
+		searched++
+
+		next, err := s.Play(move)
+		if err != nil {
+			return Draw, false, err
+		}
+
+		wdl, _, err := tb.search(next, false)
+		if err != nil {
+			return Draw, false, err
+		}
+
+		if -wdl > best {
+			best = -wdl
+			if best == Win {
+				return Win, true, nil
+			}
+		}
+	}
+
+	// All the moves were searched, the stored value could be wrong
+	if searched > 0 && searched == len(moves) {
+		return best, true, nil
+	}
+
+	value, err := tb.probeWDLTable(s)
+	if err != nil {
+		return Draw, false, err
+	}
+
+	if best >= value {
+		return best, best > Draw, nil
+	}
+	return value, false, nil
+}
+
+func (tb *Tablebase) probeWDLTable(s core.Snapshot) (WDL, error) {
+	key := materialKey(s)
+	if key == "KvK" {
+		return Draw, nil
+	}
+
+	t := tb.wdl[key]
+	if t == nil {
+		return Draw, fmt.Errorf("%w: %s", ErrMissingTable, key)
+	}
+	if err := t.load(); err != nil {
+		return Draw, err
+	}
+
+	value, _, _ := t.probe(s)
+	return WDL(value - 2), nil
+}
+
+func (tb *Tablebase) probeDTZ(s core.Snapshot) (int, error) {
+	wdl, zeroing, err := tb.search(s, true)
+	if err != nil || wdl == Draw {
+		return 0, err
+	}
+
+	// Value isn't stored when the best move is zeroing
+	if zeroing {
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	key := materialKey(s)
+	t := tb.dtz[key]
+	if t == nil {
+		return 0, fmt.Errorf("%w: %s", ErrMissingTable, key)
+	}
+	if err := t.load(); err != nil {
+		return 0, err
+	}
+
+	if value, f, ok := t.probe(s); ok {
+		dtz := t.mapDTZ(f, value, wdl)
+		if wdl == BlessedLoss || wdl == CursedWin {
+			dtz += 100
+		}
+		return dtz * sign(int(wdl)), nil
+	}
+
+	// Table stores the other side to move, so the best move is searched
+	g := s.Game()
+	minDTZ := 0xffff
+	for _, move := range g.LegalMoves() {
+		next, err := s.Play(move)
+		if err != nil {
+			return 0, err
+		}
+
+		var dtz int
+		if isZeroing(move) {
+			wdl, _, err := tb.search(next, false)
+			if err != nil {
+				return 0, err
+			}
+			dtz = -dtzBeforeZeroing(wdl)
+		} else {
+			if dtz, err = tb.probeDTZ(next); err != nil {
+				return 0, err
+			}
+			dtz = -dtz
+		}
+
+		if ng := next.Game(); dtz == 1 && ng.Outcome() == core.Checkmate {
+			minDTZ = 1
+		}
+
+		if !isZeroing(move) {
+			dtz += sign(dtz)
+		}
+
+		if dtz < minDTZ && sign(dtz) == sign(int(wdl)) {
+			minDTZ = dtz
+		}
+	}
+
+	// No legal moves, the side to move is mated
+	if minDTZ == 0xffff {
+		return -1, nil
+	}
+	return minDTZ, nil
+}
+
+// Returns DTZ of a position where the best move is zeroing
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case Win:
+		return 1
+	case CursedWin:
+		return 101
+	case BlessedLoss:
+		return -101
+	case Loss:
+		return -1
+	}
+	return 0
+}
+
+// Returns result of the DTZ with the plies already played since the last zeroing move
+func applyFiftyMoves(dtz, clock int) WDL {
+	switch {
+	case dtz > 0 && dtz+clock <= 100:
+		return Win
+	case dtz > 0:
+		return CursedWin
+	case dtz < 0 && -dtz+clock <= 100:
+		return Loss
+	case dtz < 0:
+		return BlessedLoss
+	}
+	return Draw
+}
+
+func isCapture(move core.Move) bool {
+	return move.Action == core.Capture || move.Action == core.Enpassant ||
+		(move.Action == core.Promotion && move.Source.Col() != move.Target.Col())
+}
+
+// Checks the move resets the fifty-move counter
+func isZeroing(move core.Move) bool {
+	return isCapture(move) || move.Source.Figure() == core.Pawn
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+func (w WDL) String() string {
+	switch w {
+	case Loss:
+		return "loss"
+	case BlessedLoss:
+		return "blessed loss"
+	case Draw:
+		return "draw"
+	case CursedWin:
+		return "cursed win"
+	case Win:
+		return "win"
+	}
+	return fmt.Sprintf("WDL(%d)", int(w))
+}
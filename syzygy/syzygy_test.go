@@ -0,0 +1,470 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+// Writes a table with the values given by the side to move, the file of the leading pawn and the index.
+// Values are coded by 4 bits, equal neighbours are joined into pairs
+func writeTable(t *testing.T, dir, name string, typ tableType, flags byte, value func(stm, f int, idx uint64) int) {
+	t.Helper()
+
+	tbl, err := newTable(typ, "", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sides := 1
+	if typ == wdlTable && tbl.key != tbl.key2 {
+		sides = 2
+	}
+	maxFile := 0
+	if tbl.hasPawns {
+		maxFile = 3
+	}
+
+	// Pieces are stored as they are named, white pawns lead
+	var codes []int
+	white, black, _ := strings.Cut(name, "v")
+	for _, letter := range strings.Repeat("P", strings.Count(white, "P")) + strings.TrimRight(white, "P") {
+		codes = append(codes, figureCodes[core.Figure(letter)])
+	}
+	for _, letter := range black {
+		codes = append(codes, figureCodes[core.Figure(letter)]|codeBlack)
+	}
+
+	magic := magics[typ]
+	data := append([]byte{}, magic[:]...)
+	header := byte(0)
+	if sides == 2 {
+		header |= 1
+	}
+	if tbl.hasPawns {
+		header |= 2
+	}
+	data = append(data, header)
+
+	for f := 0; f <= maxFile; f++ {
+		data = append(data, 0)
+		for _, code := range codes {
+			data = append(data, byte(code|code<<4))
+		}
+		for i := range sides {
+			d := tbl.get(i, f)
+			copy(d.pieces[:], codes)
+			tbl.setGroups(d, [2]int{0, 0xf}, f)
+		}
+	}
+	if len(data)&1 != 0 {
+		data = append(data, 0)
+	}
+
+	const (
+		blockBits = 5
+		spanBits  = 6
+		symBits   = 4
+	)
+
+	type part struct {
+		sparse, lengths, blocks []byte
+	}
+	var parts []part
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			d := tbl.get(i, f)
+			values := make([]int, d.groupIdx[slices.Index(d.groupLen[:], 0)])
+			for idx := range values {
+				values[idx] = value(i, f, uint64(idx))
+			}
+
+			// Symbols 0..4 are values, 5..9 are pairs of the same values
+			var blocks [][]int
+			var starts []int
+			for idx := 0; idx < len(values); {
+				starts = append(starts, idx)
+				var block []int
+				for idx < len(values) && len(block) < (8<<blockBits)/symBits {
+					if idx+1 < len(values) && values[idx] == values[idx+1] {
+						block = append(block, 5+values[idx])
+						idx += 2
+					} else {
+						block = append(block, values[idx])
+						idx++
+					}
+				}
+				blocks = append(blocks, block)
+			}
+			starts = append(starts, len(values))
+
+			var p part
+			for b, block := range blocks {
+				p.lengths = binary.LittleEndian.AppendUint16(p.lengths, uint16(starts[b+1]-starts[b]-1))
+
+				packed := make([]byte, 1<<blockBits)
+				for j, sym := range block {
+					packed[j/2] |= byte(sym << (4 * (1 - j%2)))
+				}
+				p.blocks = append(p.blocks, packed...)
+			}
+
+			span := 1 << spanBits
+			for k := 0; k*span < len(values); k++ {
+				mid := k*span + span/2
+				b := len(blocks) - 1
+				for b > 0 && starts[b] > mid {
+					b--
+				}
+				p.sparse = binary.LittleEndian.AppendUint32(p.sparse, uint32(b))
+				p.sparse = binary.LittleEndian.AppendUint16(p.sparse, uint16(mid-starts[b]))
+			}
+			parts = append(parts, p)
+
+			data = append(data, flags, blockBits, spanBits, 0)
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(blocks)))
+			data = append(data, symBits, symBits, 0, 0)
+			data = binary.LittleEndian.AppendUint16(data, 10)
+			for sym := range 10 {
+				left, right := sym, 0xfff
+				if sym >= 5 {
+					left, right = sym-5, sym-5
+				}
+				data = append(data, byte(left), byte(left>>8)|byte(right<<4), byte(right>>4))
+			}
+		}
+	}
+
+	if typ == dtzTable && len(data)&1 != 0 {
+		data = append(data, 0)
+	}
+	for _, p := range parts {
+		data = append(data, p.sparse...)
+	}
+	for _, p := range parts {
+		data = append(data, p.lengths...)
+	}
+	for _, p := range parts {
+		for len(data)%64 != 0 {
+			data = append(data, 0)
+		}
+		data = append(data, p.blocks...)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name+extensions[typ]), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func snapshot(t *testing.T, fen string) core.Snapshot {
+	t.Helper()
+
+	s, err := core.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newGame(t *testing.T, fen string) *core.Game {
+	game := snapshot(t, fen).Game()
+	return &game
+}
+
+// Returns FEN of the pieces given by squares
+func fen(board map[int]byte, turn core.Side) string {
+	var sb strings.Builder
+	for r := 7; r >= 0; r-- {
+		empty := 0
+		for f := range 8 {
+			letter, ok := board[r*8+f]
+			if !ok {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(letter)
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if r > 0 {
+			sb.WriteByte('/')
+		}
+	}
+	return sb.String() + " " + string(turn) + " - - 0 1"
+}
+
+func hashValue(stm, f int, idx uint64) int {
+	return int((idx*2654435761>>7)+uint64(f)+uint64(stm)) % 5
+}
+
+func TestTable_Encode(t *testing.T) {
+	dir := t.TempDir()
+	writeTable(t, dir, "KRvK", wdlTable, 0, hashValue)
+	writeTable(t, dir, "KPvK", wdlTable, 0, hashValue)
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	symmetries := []func(int) int{
+		func(s int) int { return s },
+		flipFile,
+		flipRank,
+		flipDiagonal,
+		func(s int) int { return flipFile(flipRank(s)) },
+		func(s int) int { return flipDiagonal(flipFile(s)) },
+		func(s int) int { return flipDiagonal(flipRank(s)) },
+		func(s int) int { return flipDiagonal(flipFile(flipRank(s))) },
+	}
+
+	tests := []struct {
+		name    string
+		letters string
+		// Symmetries which keep the position index
+		symmetries int
+	}{
+		{"KRvK", "KRk", 8},
+		{"KPvK", "KPk", 2},
+	}
+
+	for _, tt := range tests {
+		tbl := tb.wdl[tt.name]
+		if err := tbl.load(); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range 4000 {
+			board := map[int]byte{}
+			squares := []int{(i * 37) % 64, (i * 11) % 64, (i * 53 / 7) % 64}
+			for j, s := range squares {
+				board[s] = tt.letters[j]
+			}
+			if len(board) != 3 || distance(squares[0], squares[2]) <= 1 || (tt.name == "KPvK" && (rank(squares[1]) == 0 || rank(squares[1]) == 7)) {
+				continue
+			}
+
+			for _, turn := range []core.Side{core.White, core.Black} {
+				s := snapshot(t, fen(board, turn))
+				d, idx, f, _ := tbl.encode(s)
+
+				size := d.groupIdx[slices.Index(d.groupLen[:], 0)]
+				if idx >= size {
+					t.Fatalf("%s: index %d of %s is beyond %d", tt.name, idx, s.FEN(), size)
+				}
+
+				stm := 0
+				if turn == core.Black {
+					stm = 1
+				}
+				if value := d.decompress(idx); value != hashValue(stm, f, idx) {
+					t.Fatalf("%s: unexpected value %d of %s", tt.name, value, s.FEN())
+				}
+
+				for _, symmetry := range symmetries[:tt.symmetries] {
+					mirrored := map[int]byte{}
+					for s, letter := range board {
+						mirrored[symmetry(s)] = letter
+					}
+
+					if md, midx, _, _ := tbl.encode(snapshot(t, fen(mirrored, turn))); md != d || midx != idx {
+						t.Fatalf("%s: mirrored %s has another index", tt.name, fen(mirrored, turn))
+					}
+				}
+
+				// The same position with the colors switched
+				switched := map[int]byte{}
+				for s, letter := range board {
+					switched[flipRank(s)] = letter ^ ('a' - 'A')
+				}
+				other := core.White
+				if turn == core.White {
+					other = core.Black
+				}
+				if sd, sidx, _, _ := tbl.encode(snapshot(t, fen(switched, other))); sd != d || sidx != idx {
+					t.Fatalf("%s: switched %s has another index", tt.name, fen(switched, other))
+				}
+			}
+		}
+	}
+}
+
+// Tables where white always wins with KRvK and DTZ
+// of white to move are from 1 to 4 plies
+func newTablebase(t *testing.T) *Tablebase {
+	dir := t.TempDir()
+	writeTable(t, dir, "KRvK", wdlTable, 0, func(stm, f int, idx uint64) int {
+		return 4 * (1 - stm)
+	})
+	writeTable(t, dir, "KRvK", dtzTable, flagWinPlies, func(stm, f int, idx uint64) int {
+		return int(idx % 4)
+	})
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tb.MaxPieces() != 3 {
+		t.Fatalf("Unexpected max pieces %d", tb.MaxPieces())
+	}
+	return tb
+}
+
+func TestTablebase_ProbeWDL(t *testing.T) {
+	tb := newTablebase(t)
+
+	tests := []struct {
+		fen string
+		wdl WDL
+	}{
+		{"k7/8/8/8/8/2K5/8/7R w - - 0 1", Win},
+		{"k7/8/8/8/8/2K5/8/7R b - - 0 1", Loss},
+		// Black is stronger
+		{"K7/8/8/8/8/2k5/8/7r b - - 0 1", Win},
+		// Rook is captured
+		{"k7/1R6/8/8/8/2K5/8/8 b - - 0 1", Draw},
+		{"k7/8/1R6/2K5/8/8/8/8 b - - 0 1", Loss},
+		{"k7/8/8/8/8/2K5/8/8 w - - 0 1", Draw},
+	}
+
+	for _, tt := range tests {
+		if wdl, err := tb.ProbeWDL(newGame(t, tt.fen)); err != nil || wdl != tt.wdl {
+			t.Fatalf("%s: unexpected result %s: %v", tt.fen, wdl, err)
+		}
+	}
+}
+
+func TestTablebase_Probe(t *testing.T) {
+	tb := newTablebase(t)
+	game := newGame(t, "k7/8/8/8/8/2K5/8/7R w - - 0 1")
+
+	dtz, err := tb.ProbeDTZ(game)
+	if err != nil || dtz < 1 || dtz > 4 {
+		t.Fatalf("Unexpected DTZ %d: %v", dtz, err)
+	}
+
+	res, err := tb.Probe(game)
+	if err != nil || res.WDL != Win || res.DTZ != dtz {
+		t.Fatalf("Unexpected result %v: %v", res, err)
+	}
+
+	// The move keeps the win and reaches it the fastest
+	best := 0
+	for _, move := range game.LegalMoves() {
+		next := game.Snapshot()
+		if next, err = next.Play(move); err != nil {
+			t.Fatal(err)
+		}
+		if dtz, err := tb.probeDTZ(next); err != nil || (dtz < 0 && (best == 0 || -dtz < best)) {
+			best = -dtz
+		}
+	}
+
+	next, _ := game.Snapshot().Play(res.Move)
+	if dtz, _ := tb.probeDTZ(next); -dtz != best {
+		t.Fatalf("Move %v isn't the fastest", res.Move)
+	}
+
+	if _, err := tb.ProbeDTZ(newGame(t, "k7/1R6/8/8/8/2K5/8/8 b - - 0 1")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = tb.Probe(newGame(t, "k7/1R6/8/8/8/2K5/8/8 b - - 0 1"))
+	if err != nil || res.WDL != Draw || res.Move.Action != core.Capture {
+		t.Fatalf("Unexpected result %v: %v", res, err)
+	}
+}
+
+func TestTablebase_Adjudicate(t *testing.T) {
+	tb := newTablebase(t)
+	game := newGame(t, "k7/8/8/8/8/2K5/8/7R w - - 0 1")
+
+	if verdict, err := tb.Adjudicate(game); err != nil || verdict != WhiteWins {
+		t.Fatalf("Unexpected verdict %d: %v", verdict, err)
+	}
+
+	// Rook and king shuffle till the fifty-move rule draws the game
+	squares := []string{"h1", "h2", "a8", "b8", "h2", "h1", "b8", "a8"}
+	for i := 0; game.HalfmoveClock() < 100; i += 2 {
+		from, _ := core.ParseSquare(squares[i%len(squares)])
+		to, _ := core.ParseSquare(squares[i%len(squares)+1])
+		if err := game.PlayLenient(core.Move{Source: core.Cell{Position: from}, Target: core.Cell{Position: to}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if res, err := tb.Probe(game); err != nil || res.WDL != CursedWin {
+		t.Fatalf("Unexpected result %v: %v", res, err)
+	}
+
+	if verdict, err := tb.Adjudicate(game); err != nil || verdict != Drawn {
+		t.Fatalf("Unexpected verdict %d: %v", verdict, err)
+	}
+
+	// Clock of the start position counts too
+	if verdict, err := tb.Adjudicate(newGame(t, "k7/8/8/8/8/2K5/8/7R w - - 100 80")); err != nil || verdict != Drawn {
+		t.Fatalf("Unexpected verdict %d: %v", verdict, err)
+	}
+
+	tests := map[string]Verdict{
+		"k7/1R6/8/8/8/2K5/8/8 b - - 0 1": Drawn,
+		"k7/8/1R6/2K5/8/8/8/8 b - - 0 1": WhiteWins,
+		"K7/8/8/8/8/2k5/8/7r b - - 0 1":  BlackWins,
+		// Missing table
+		"k7/8/8/8/8/2K5/8/7Q w - - 0 1":  Undecided,
+		"r3k3/8/8/8/8/2K5/8/8 w q - 0 1": Undecided,
+		// Stalemate and checkmate
+		"k7/1R6/1K6/8/8/8/8/8 b - - 0 1": Drawn,
+		"k6R/8/1K6/8/8/8/8/8 b - - 0 1":  WhiteWins,
+	}
+
+	for fen, expected := range tests {
+		if verdict, err := tb.Adjudicate(newGame(t, fen)); err != nil || verdict != expected {
+			t.Fatalf("%s: unexpected verdict %d: %v", fen, verdict, err)
+		}
+	}
+}
+
+func TestTablebase_Errors(t *testing.T) {
+	tb := newTablebase(t)
+
+	if _, err := tb.ProbeWDL(newGame(t, "k7/8/8/8/8/2K5/8/7Q w - - 0 1")); !errors.Is(err, ErrMissingTable) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if _, err := tb.ProbeWDL(newGame(t, "r3k3/8/8/8/8/2K5/8/8 w q - 0 1")); !errors.Is(err, ErrCastling) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KNvK.rtbw"), []byte("not a table"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tb.ProbeWDL(newGame(t, "k7/8/8/8/8/2K5/8/7N w - - 0 1")); err == nil || !strings.Contains(err.Error(), "invalid magic") {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "KXvK.rtbw"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); err == nil {
+		t.Fatal("Expected error for an invalid table name")
+	}
+}
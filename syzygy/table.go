@@ -0,0 +1,531 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type tableType int
+
+const (
+	wdlTable tableType = iota
+	dtzTable
+)
+
+var (
+	extensions = map[tableType]string{wdlTable: ".rtbw", dtzTable: ".rtbz"}
+	magics     = map[tableType][4]byte{wdlTable: {0x71, 0xe8, 0x23, 0x5d}, dtzTable: {0xd7, 0x66, 0x0c, 0xa5}}
+)
+
+// Flags of the pairs data
+const (
+	flagSTM         = 1
+	flagMapped      = 2
+	flagWinPlies    = 4
+	flagLossPlies   = 8
+	flagWide        = 16
+	flagSingleValue = 128
+)
+
+// Piece codes of the tables, black pieces have the 4th bit set
+const (
+	codePawn  = 1
+	codeKing  = 6
+	codeBlack = 8
+)
+
+// Order of the pieces in a table name
+const pieceLetters = "KQRBNP"
+
+// Compressed values of a table part, see decompress
+type pairsData struct {
+	flags       byte
+	pieces      [maxPieces]int
+	groupLen    [maxPieces + 1]int
+	groupIdx    [maxPieces + 1]uint64
+	sizeofBlock uint64
+	span        uint64
+	minSymLen   int
+	lowestSym   []byte
+	base64      []uint64
+	symlen      []int
+	btree       []byte
+	sparseIndex []byte
+	blockLength []byte
+	blocksNum   uint64
+	data        []byte
+	// Offsets of the DTZ values in the map by the WDL score
+	mapIdx [4]int
+}
+
+// Table is a WDL or DTZ file, loaded on the first probe
+type table struct {
+	typ  tableType
+	path string
+	// Material with the first side as white and with the first side as black
+	key, key2       string
+	pieceCount      int
+	hasPawns        bool
+	hasUniquePieces bool
+	// Pawns of the leading side and of the other one
+	pawnCount [2]int
+
+	once  sync.Once
+	err   error
+	items [2][4]pairsData
+	// DTZ values map
+	dtzMap []byte
+}
+
+// Creates a table by its name like "KRPvKR"
+func newTable(typ tableType, path, name string) (*table, error) {
+	white, black, ok := strings.Cut(name, "v")
+	if !ok || !validSide(white) || !validSide(black) || len(white)+len(black) > maxPieces {
+		return nil, fmt.Errorf("syzygy: invalid table name: %q", name)
+	}
+
+	t := &table{
+		typ:        typ,
+		path:       path,
+		key:        white + "v" + black,
+		key2:       black + "v" + white,
+		pieceCount: len(white) + len(black),
+		hasPawns:   strings.Contains(name, "P"),
+	}
+
+	for _, side := range []string{white, black} {
+		for _, letter := range pieceLetters[1:] {
+			if strings.Count(side, string(letter)) == 1 {
+				t.hasUniquePieces = true
+			}
+		}
+	}
+
+	// Side with fewer pawns leads, it's compressed better
+	whitePawns, blackPawns := strings.Count(white, "P"), strings.Count(black, "P")
+	if blackPawns == 0 || (whitePawns > 0 && blackPawns >= whitePawns) {
+		t.pawnCount = [2]int{whitePawns, blackPawns}
+	} else {
+		t.pawnCount = [2]int{blackPawns, whitePawns}
+	}
+
+	return t, nil
+}
+
+// Checks pieces of a side are a king followed by the other pieces in order
+func validSide(side string) bool {
+	if !strings.HasPrefix(side, "K") {
+		return false
+	}
+
+	last := 0
+	for _, letter := range side[1:] {
+		i := strings.IndexRune(pieceLetters, letter)
+		if i < 1 || i < last {
+			return false
+		}
+		last = i
+	}
+	return true
+}
+
+// Returns the table part by the side to move and the file of the leading pawn,
+// DTZ tables store only one side
+func (t *table) get(stm, f int) *pairsData {
+	if !t.hasPawns {
+		f = 0
+	}
+	if t.typ == dtzTable {
+		stm = 0
+	}
+	return &t.items[stm%2][f]
+}
+
+func (t *table) load() error {
+	t.once.Do(func() {
+		data, err := os.ReadFile(t.path)
+		if err != nil {
+			t.err = err
+			return
+		}
+
+		if err := t.parse(data); err != nil {
+			t.err = fmt.Errorf("syzygy: %s: %w", t.path, err)
+		}
+	})
+	return t.err
+}
+
+// Reads the headers of the table, the values stay compressed.
+// Data which is out of bounds makes the parsing panic, it's recovered as a corruption
+func (t *table) parse(data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("corrupted table")
+		}
+	}()
+
+	if len(data) < 5 || [4]byte(data[:4]) != magics[t.typ] {
+		return fmt.Errorf("invalid magic")
+	}
+
+	const (
+		split    = 1
+		hasPawns = 2
+	)
+	if (data[4]&hasPawns != 0) != t.hasPawns || (t.typ == wdlTable && (data[4]&split != 0) != (t.key != t.key2)) {
+		return fmt.Errorf("table doesn't match its name")
+	}
+
+	sides := 1
+	if t.typ == wdlTable && t.key != t.key2 {
+		sides = 2
+	}
+
+	maxFile := 0
+	if t.hasPawns {
+		maxFile = 3
+	}
+	pp := t.hasPawns && t.pawnCount[1] > 0
+
+	pos := 5
+	for f := 0; f <= maxFile; f++ {
+		order := [2][2]int{{int(data[pos] & 0xf), 0xf}, {int(data[pos] >> 4), 0xf}}
+		if pp {
+			order[0][1], order[1][1] = int(data[pos+1]&0xf), int(data[pos+1]>>4)
+			pos++
+		}
+		pos++
+
+		for k := range t.pieceCount {
+			for i := range sides {
+				pieces := int(data[pos])
+				if i == 0 {
+					pieces &= 0xf
+				} else {
+					pieces >>= 4
+				}
+				t.get(i, f).pieces[k] = pieces
+			}
+			pos++
+		}
+
+		for i := range sides {
+			t.setGroups(t.get(i, f), order[i], f)
+		}
+	}
+
+	// Word alignment
+	pos += pos & 1
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			if pos, err = setSizes(t.get(i, f), data, pos); err != nil {
+				return err
+			}
+		}
+	}
+
+	if t.typ == dtzTable {
+		pos = t.setDTZMap(data, pos, maxFile)
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			d := t.get(i, f)
+			size := len(d.sparseIndex) * 6
+			d.sparseIndex = data[pos : pos+size]
+			pos += size
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			d := t.get(i, f)
+			size := len(d.blockLength) * 2
+			d.blockLength = data[pos : pos+size]
+			pos += size
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			// 64 byte alignment
+			pos = (pos + 0x3f) &^ 0x3f
+			d := t.get(i, f)
+			size := int(d.blocksNum * d.sizeofBlock)
+			d.data = data[pos : pos+size]
+			pos += size
+		}
+	}
+
+	return nil
+}
+
+// Sets the groups of pieces that are encoded together and their index multipliers
+func (t *table) setGroups(d *pairsData, order [2]int, f int) {
+	n := 0
+	firstLen := 2
+	if t.hasPawns {
+		firstLen = 0
+	} else if t.hasUniquePieces {
+		firstLen = 3
+	}
+
+	d.groupLen[n] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0
+
+	// Groups are encoded as g1 * N(g2) * N(g3) + g2 * N(g3) + g3,
+	// where N(g) is number of placements of the group.
+	// The leading group is encoded at order[0] and the remaining pawns at order[1]
+	pp := t.hasPawns && t.pawnCount[1] > 0
+	next := 1
+	freeSquares := 64 - d.groupLen[0]
+	if pp {
+		next = 2
+		freeSquares -= d.groupLen[1]
+	}
+
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch {
+		case k == order[0]:
+			d.groupIdx[0] = idx
+			switch {
+			case t.hasPawns:
+				idx *= leadPawnsSize[d.groupLen[0]][f]
+			case t.hasUniquePieces:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case k == order[1]:
+			d.groupIdx[1] = idx
+			idx *= binomial[d.groupLen[1]][48-d.groupLen[0]]
+		default:
+			d.groupIdx[next] = idx
+			idx *= binomial[d.groupLen[next]][freeSquares]
+			freeSquares -= d.groupLen[next]
+			next++
+		}
+	}
+	d.groupIdx[n] = idx
+}
+
+// Reads the Huffman code and the pairs tree
+func setSizes(d *pairsData, data []byte, pos int) (int, error) {
+	d.flags = data[pos]
+	pos++
+
+	if d.flags&flagSingleValue != 0 {
+		// The single value is stored in place of the minimal symbol length
+		d.minSymLen = int(data[pos])
+		return pos + 1, nil
+	}
+
+	n := slices.Index(d.groupLen[:], 0)
+	tbSize := d.groupIdx[n]
+
+	d.sizeofBlock = 1 << data[pos]
+	d.span = 1 << data[pos+1]
+	sparseIndexSize := (tbSize + d.span - 1) / d.span
+	padding := uint64(data[pos+2])
+	d.blocksNum = uint64(binary.LittleEndian.Uint32(data[pos+3:]))
+	blockLengthSize := d.blocksNum + padding
+	maxSymLen := int(data[pos+7])
+	d.minSymLen = int(data[pos+8])
+	pos += 9
+
+	if maxSymLen < d.minSymLen || maxSymLen > 64 {
+		return 0, fmt.Errorf("invalid symbol length")
+	}
+
+	d.lowestSym = data[pos:]
+	d.base64 = make([]uint64, maxSymLen-d.minSymLen+1)
+
+	// Canonical Huffman code, longer codes have lower values.
+	// base64[i] is the lowest code of length i right-padded to 64 bits
+	for i := len(d.base64) - 2; i >= 0; i-- {
+		d.base64[i] = (d.base64[i+1] + uint64(d.lowestSymAt(i)) - uint64(d.lowestSymAt(i+1))) / 2
+	}
+	for i := range d.base64 {
+		d.base64[i] <<= 64 - i - d.minSymLen
+	}
+	pos += len(d.base64) * 2
+
+	symbols := int(binary.LittleEndian.Uint16(data[pos:]))
+	pos += 2
+	d.btree = data[pos : pos+symbols*3]
+	d.symlen = make([]int, symbols)
+
+	// Symbols are pairs of other symbols, symlen is the number of values a symbol expands to minus one
+	visited := make([]bool, symbols)
+	for sym := range symbols {
+		if !visited[sym] {
+			d.symlen[sym] = d.setSymlen(sym, visited)
+		}
+	}
+
+	// Sizes are kept in the slices till the data is reached
+	d.sparseIndex = make([]byte, sparseIndexSize)
+	d.blockLength = make([]byte, blockLengthSize)
+
+	return pos + symbols*3 + symbols&1, nil
+}
+
+func (d *pairsData) setSymlen(sym int, visited []bool) int {
+	visited[sym] = true
+	right := d.right(sym)
+	if right == 0xfff {
+		return 0
+	}
+
+	left := d.left(sym)
+	if !visited[left] {
+		d.symlen[left] = d.setSymlen(left, visited)
+	}
+	if !visited[right] {
+		d.symlen[right] = d.setSymlen(right, visited)
+	}
+
+	return d.symlen[left] + d.symlen[right] + 1
+}
+
+// Reads offsets of the DTZ values maps
+func (t *table) setDTZMap(data []byte, pos, maxFile int) int {
+	t.dtzMap = data[pos:]
+	start := pos
+
+	for f := 0; f <= maxFile; f++ {
+		d := t.get(0, f)
+		if d.flags&flagMapped == 0 {
+			continue
+		}
+
+		if d.flags&flagWide != 0 {
+			// Word alignment, a table may mix the maps
+			pos += pos & 1
+			for i := range 4 {
+				d.mapIdx[i] = (pos-start)/2 + 1
+				pos += 2*int(binary.LittleEndian.Uint16(data[pos:])) + 2
+			}
+		} else {
+			for i := range 4 {
+				d.mapIdx[i] = pos - start + 1
+				pos += int(data[pos]) + 1
+			}
+		}
+	}
+
+	return pos + pos&1
+}
+
+func (d *pairsData) lowestSymAt(i int) uint16 {
+	return binary.LittleEndian.Uint16(d.lowestSym[2*i:])
+}
+
+// Left symbol of the pair, or the value if the symbol is a leaf
+func (d *pairsData) left(sym int) int {
+	lr := d.btree[3*sym:]
+	return int(lr[1]&0xf)<<8 | int(lr[0])
+}
+
+func (d *pairsData) right(sym int) int {
+	lr := d.btree[3*sym:]
+	return int(lr[2])<<4 | int(lr[1]>>4)
+}
+
+// Returns the value stored at the index
+func (d *pairsData) decompress(idx uint64) int {
+	if d.flags&flagSingleValue != 0 {
+		return d.minSymLen
+	}
+
+	// Sparse index points at the block and the offset of every span middle
+	k := idx / d.span
+	block := int(binary.LittleEndian.Uint32(d.sparseIndex[6*k:]))
+	offset := int(binary.LittleEndian.Uint16(d.sparseIndex[6*k+4:]))
+	offset += int(idx%d.span) - int(d.span/2)
+
+	// Every block holds blockLength + 1 values
+	for offset < 0 {
+		block--
+		offset += d.blockLengthAt(block) + 1
+	}
+	for offset > d.blockLengthAt(block) {
+		offset -= d.blockLengthAt(block) + 1
+		block++
+	}
+
+	ptr := d.data[uint64(block)*d.sizeofBlock:]
+	buf64 := readBits(ptr, 8)
+	ptr = ptr[min(8, len(ptr)):]
+	buf64Size := 64
+
+	var sym int
+	for {
+		n := 0
+		for buf64 < d.base64[n] {
+			n++
+		}
+
+		sym = int((buf64-d.base64[n])>>(64-n-d.minSymLen)) + int(d.lowestSymAt(n))
+		if offset < d.symlen[sym]+1 {
+			break
+		}
+
+		offset -= d.symlen[sym] + 1
+		n += d.minSymLen
+		buf64 <<= n
+		buf64Size -= n
+
+		if buf64Size <= 32 {
+			buf64Size += 32
+			buf64 |= readBits(ptr, 4) << (64 - buf64Size)
+			ptr = ptr[min(4, len(ptr)):]
+		}
+	}
+
+	// Pairs expand into adjacent values
+	for d.symlen[sym] != 0 {
+		left := d.left(sym)
+		if offset < d.symlen[left]+1 {
+			sym = left
+		} else {
+			offset -= d.symlen[left] + 1
+			sym = d.right(sym)
+		}
+	}
+
+	return d.left(sym)
+}
+
+func (d *pairsData) blockLengthAt(block int) int {
+	return int(binary.LittleEndian.Uint16(d.blockLength[2*block:]))
+}
+
+// Reads n big endian bytes, the last block may end before them
+func readBits(data []byte, n int) uint64 {
+	var res uint64
+	for i := range n {
+		res <<= 8
+		if i < len(data) {
+			res |= uint64(data[i])
+		}
+	}
+	return res
+}
@@ -0,0 +1,428 @@
+// Package tactics finds tactical motifs of positions: forks, pins, skewers,
+// discovered attacks, double checks, overloaded defenders, back-rank weaknesses and hanging pieces
+package tactics
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+type Motif int
+
+const (
+	// Piece attacks two pieces or more, attackers are the forking piece
+	// and targets are the attacked pieces
+	Fork Motif = iota
+	// Slider attacks a piece which can't move without exposing a more valuable piece behind it,
+	// attackers are the slider and targets are the pinned piece and the piece behind it
+	Pin
+	// Slider attacks a valuable piece which exposes a piece behind it when it moves,
+	// attackers are the slider and targets are the attacked piece and the piece behind it
+	Skewer
+	// Last move opened a line of a slider to a piece, attackers are the slider
+	// and the moved piece and targets are the attacked piece
+	DiscoveredAttack
+	// King is checked by two pieces, attackers are the checking pieces and targets are the king
+	DoubleCheck
+	// Piece is the only defender of two attacked pieces or more, attackers are the pieces
+	// attacking them and targets are the defender followed by the defended pieces
+	OverloadedDefender
+	// King on its back rank can't step off it, attackers are the rooks and the queens
+	// of the opponent, targets are the king and squares are the blocked squares in front of it
+	BackRankWeakness
+	// Piece can be taken with a gain by the exchange on its square,
+	// attackers are the pieces winning material by taking it and targets are the piece
+	HangingPiece
+)
+
+func (m Motif) String() string {
+	switch m {
+	case Fork:
+		return "fork"
+	case Pin:
+		return "pin"
+	case Skewer:
+		return "skewer"
+	case DiscoveredAttack:
+		return "discovered attack"
+	case DoubleCheck:
+		return "double check"
+	case OverloadedDefender:
+		return "overloaded defender"
+	case BackRankWeakness:
+		return "back-rank weakness"
+	case HangingPiece:
+		return "hanging piece"
+	}
+	return fmt.Sprintf("Motif(%d)", int(m))
+}
+
+// Tactic is a motif found in a position
+type Tactic struct {
+	Motif Motif
+	// Side which profits from the motif
+	Side      core.Side
+	Attackers []core.Cell
+	Targets   []core.Cell
+	Squares   []core.Position
+}
+
+// Values of the pieces in pawns, the king is worth more than the rest of the pieces
+var values = map[core.Figure]int{
+	core.Pawn:   1,
+	core.Knight: 3,
+	core.Bishop: 3,
+	core.Rook:   5,
+	core.Queen:  9,
+	core.King:   100,
+}
+
+// Find returns the motifs of both sides in the position of the game sorted by the motifs,
+// discovered attacks are the ones opened by the last move
+func Find(game *core.Game) []Tactic {
+	f := &finder{game: game}
+
+	var res []Tactic
+	res = append(res, f.forks()...)
+	res = append(res, f.lines()...)
+	res = append(res, f.discoveredAttacks()...)
+	res = append(res, f.doubleChecks()...)
+	res = append(res, f.overloadedDefenders()...)
+	res = append(res, f.backRanks()...)
+	res = append(res, f.hangingPieces()...)
+
+	slices.SortStableFunc(res, func(a, b Tactic) int { return int(a.Motif) - int(b.Motif) })
+	return res
+}
+
+// Returns the motifs of the kind found in the position
+func FindMotif(game *core.Game, motif Motif) []Tactic {
+	var res []Tactic
+	for _, t := range Find(game) {
+		if t.Motif == motif {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+type finder struct {
+	game *core.Game
+}
+
+func (f *finder) cell(pos core.Position) core.Cell {
+	return core.Cell{Piece: f.game.Board[pos.Row()][pos.Col()], Position: pos}
+}
+
+// Returns pieces of the side in the board order
+func (f *finder) pieces(side core.Side) []core.Cell {
+	var res []core.Cell
+	for row := range f.game.Board {
+		for col, pic := range f.game.Board[row] {
+			if pic != core.Empty && pic.Side() == side {
+				res = append(res, core.Cell{Piece: pic, Position: position(row, col)})
+			}
+		}
+	}
+	return res
+}
+
+func (f *finder) cells(positions []core.Position) []core.Cell {
+	var res []core.Cell
+	for _, pos := range positions {
+		res = append(res, f.cell(pos))
+	}
+	return res
+}
+
+func (f *finder) defended(c core.Cell) bool {
+	return len(f.game.Attackers(c.Position, c.Side())) > 0
+}
+
+// Returns pieces which win material by taking the piece, see core.Game.SEE
+func (f *finder) hanging(c core.Cell) ([]core.Position, bool) {
+	if c.Figure() == core.King {
+		return nil, false
+	}
+
+	var res []core.Position
+	for _, pos := range f.game.Attackers(c.Position, opponent(c.Side())) {
+		if f.game.SEE(core.Move{Source: f.cell(pos), Target: core.Cell{Position: c.Position}}) > 0 {
+			res = append(res, pos)
+		}
+	}
+	return res, len(res) > 0
+}
+
+// Checks the attacker gains by attacking the target: the king is checked,
+// the target is more valuable or is undefended
+func (f *finder) threatens(attacker, target core.Cell) bool {
+	return target.Figure() == core.King || values[target.Figure()] > values[attacker.Figure()] || !f.defended(target)
+}
+
+func (f *finder) forks() []Tactic {
+	var res []Tactic
+	for _, side := range []core.Side{core.White, core.Black} {
+		targets := f.pieces(opponent(side))
+		for _, forker := range f.pieces(side) {
+			var forked []core.Cell
+			for _, target := range targets {
+				if target.Figure() != core.Pawn && f.attacks(forker, target.Position) && f.threatens(forker, target) {
+					forked = append(forked, target)
+				}
+			}
+
+			if len(forked) > 1 {
+				res = append(res, Tactic{Motif: Fork, Side: side, Attackers: []core.Cell{forker}, Targets: forked})
+			}
+		}
+	}
+	return res
+}
+
+func (f *finder) attacks(attacker core.Cell, pos core.Position) bool {
+	return slices.Contains(f.game.Attackers(pos, attacker.Side()), attacker.Position)
+}
+
+// Returns pins and skewers
+func (f *finder) lines() []Tactic {
+	var res []Tactic
+	for _, side := range []core.Side{core.White, core.Black} {
+		for _, slider := range f.pieces(side) {
+			switch slider.Figure() {
+			case core.Bishop, core.Rook, core.Queen:
+			default:
+				continue
+			}
+
+			for _, dir := range core.PicDirs[slider.Figure()] {
+				ray := f.ray(slider.Position, dir, 2)
+				if len(ray) < 2 || ray[0].Side() == side || ray[1].Side() == side {
+					continue
+				}
+
+				front, behind := ray[0], ray[1]
+				switch {
+				case values[behind.Figure()] > values[front.Figure()] && f.threatens(slider, behind):
+					res = append(res, Tactic{Motif: Pin, Side: side, Attackers: []core.Cell{slider}, Targets: ray})
+				case values[front.Figure()] > values[behind.Figure()] && behind.Figure() != core.Pawn &&
+					f.threatens(slider, front) && f.threatens(slider, behind):
+					res = append(res, Tactic{Motif: Skewer, Side: side, Attackers: []core.Cell{slider}, Targets: ray})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Returns at most n pieces met from the position in the direction
+func (f *finder) ray(from core.Position, dir [2]int, n int) []core.Cell {
+	var res []core.Cell
+	for col, row := from.Col()+dir[0], from.Row()+dir[1]; valid(col, row) && len(res) < n; col, row = col+dir[0], row+dir[1] {
+		if f.game.Board[row][col] != core.Empty {
+			res = append(res, f.cell(position(row, col)))
+		}
+	}
+	return res
+}
+
+func (f *finder) discoveredAttacks() []Tactic {
+	moves := f.game.Moves()
+	if len(moves) == 0 {
+		return nil
+	}
+
+	last := moves[len(moves)-1]
+	if last.Action == core.KingCastling || last.Action == core.QueenCastling {
+		return nil
+	}
+
+	side := opponent(f.game.Turn())
+	moved := f.cell(last.Target.Position)
+	var res []Tactic
+	for _, slider := range f.pieces(side) {
+		dir, ok := direction(slider, last.Source.Position)
+		if !ok || !f.attacks(slider, last.Source.Position) {
+			continue
+		}
+
+		// Line goes on through the vacated square
+		ray := f.ray(last.Source.Position, dir, 1)
+		if len(ray) == 1 && ray[0].Side() != side && f.threatens(slider, ray[0]) {
+			res = append(res, Tactic{Motif: DiscoveredAttack, Side: side, Attackers: []core.Cell{slider, moved}, Targets: ray})
+		}
+	}
+	return res
+}
+
+// Returns direction of the slider to the position if it moves along the line
+func direction(slider core.Cell, pos core.Position) ([2]int, bool) {
+	dc, dr := pos.Col()-slider.Col(), pos.Row()-slider.Row()
+	if dc == 0 && dr == 0 {
+		return [2]int{}, false
+	}
+
+	dir := [2]int{sign(dc), sign(dr)}
+	if dc != 0 && dr != 0 && abs(dc) != abs(dr) {
+		return dir, false
+	}
+
+	switch slider.Figure() {
+	case core.Bishop, core.Rook, core.Queen:
+		return dir, slices.Contains(core.PicDirs[slider.Figure()], dir)
+	}
+	return dir, false
+}
+
+func (f *finder) king(side core.Side) (core.Cell, bool) {
+	for _, c := range f.pieces(side) {
+		if c.Figure() == core.King {
+			return c, true
+		}
+	}
+	return core.Cell{}, false
+}
+
+func (f *finder) doubleChecks() []Tactic {
+	side := f.game.Turn()
+	king, ok := f.king(side)
+	if !ok {
+		return nil
+	}
+
+	checkers := f.game.Attackers(king.Position, opponent(side))
+	if len(checkers) < 2 {
+		return nil
+	}
+	return []Tactic{{Motif: DoubleCheck, Side: opponent(side), Attackers: f.cells(checkers), Targets: []core.Cell{king}}}
+}
+
+func (f *finder) overloadedDefenders() []Tactic {
+	var res []Tactic
+	for _, side := range []core.Side{core.White, core.Black} {
+		pieces := f.pieces(side)
+		for _, defender := range pieces {
+			targets := []core.Cell{defender}
+			var attackers []core.Position
+
+			for _, c := range pieces {
+				if c.Figure() == core.King {
+					continue
+				}
+				defenders := f.game.Attackers(c.Position, side)
+				if len(defenders) != 1 || defenders[0] != defender.Position {
+					continue
+				}
+
+				// Piece is safe only while the defender stays
+				attacking := f.game.Attackers(c.Position, opponent(side))
+				if len(attacking) == 0 || slices.ContainsFunc(attacking, func(pos core.Position) bool {
+					return values[f.cell(pos).Figure()] < values[c.Figure()]
+				}) {
+					continue
+				}
+
+				targets = append(targets, c)
+				for _, pos := range attacking {
+					if !slices.Contains(attackers, pos) {
+						attackers = append(attackers, pos)
+					}
+				}
+			}
+
+			if len(targets) > 2 {
+				res = append(res, Tactic{Motif: OverloadedDefender, Side: opponent(side), Attackers: f.cells(attackers), Targets: targets})
+			}
+		}
+	}
+	return res
+}
+
+func (f *finder) backRanks() []Tactic {
+	var res []Tactic
+	for _, side := range []core.Side{core.White, core.Black} {
+		king, ok := f.king(side)
+		if !ok || king.Row() != core.PromotionRows[opponent(side)] {
+			continue
+		}
+
+		var heavy []core.Cell
+		for _, c := range f.pieces(opponent(side)) {
+			if c.Figure() == core.Rook || c.Figure() == core.Queen {
+				heavy = append(heavy, c)
+			}
+		}
+		if len(heavy) == 0 {
+			continue
+		}
+
+		// Squares in front of the king are blocked by its pieces or attacked
+		var front []core.Position
+		escapes := false
+		for col := king.Col() - 1; col <= king.Col()+1; col++ {
+			row := king.Row() + core.AdvDirs[side]
+			if !valid(col, row) {
+				continue
+			}
+
+			pos := position(row, col)
+			front = append(front, pos)
+			pic := f.game.Board[row][col]
+			if (pic == core.Empty || pic.Side() != side) && len(f.game.Attackers(pos, opponent(side))) == 0 {
+				escapes = true
+			}
+		}
+
+		if !escapes {
+			res = append(res, Tactic{Motif: BackRankWeakness, Side: opponent(side), Attackers: heavy, Targets: []core.Cell{king}, Squares: front})
+		}
+	}
+	return res
+}
+
+func (f *finder) hangingPieces() []Tactic {
+	var res []Tactic
+	for _, side := range []core.Side{core.White, core.Black} {
+		for _, c := range f.pieces(side) {
+			if attackers, ok := f.hanging(c); ok {
+				res = append(res, Tactic{Motif: HangingPiece, Side: opponent(side), Attackers: f.cells(attackers), Targets: []core.Cell{c}})
+			}
+		}
+	}
+	return res
+}
+
+func position(row, col int) core.Position {
+	pos, _ := core.NewPosition(row, col)
+	return pos
+}
+
+func valid(col, row int) bool {
+	return col >= 0 && col < 8 && row >= 0 && row < 8
+}
+
+func opponent(side core.Side) core.Side {
+	if side == core.White {
+		return core.Black
+	}
+	return core.White
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
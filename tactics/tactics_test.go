@@ -0,0 +1,103 @@
+package tactics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zzvanq/shahio/core"
+)
+
+func newGame(t *testing.T, fen string, sans ...string) core.Game {
+	t.Helper()
+
+	s, err := core.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := s.Game()
+	for _, san := range sans {
+		move, err := game.ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return game
+}
+
+// Returns the tactic like "w [c7] [e8 a8]" or "b [a1] [g8] [f7 g7 h7]"
+func describe(tactic Tactic) string {
+	squares := func(cells []core.Cell) string {
+		var res []string
+		for _, c := range cells {
+			res = append(res, c.Position.String())
+		}
+		return "[" + strings.Join(res, " ") + "]"
+	}
+
+	res := fmt.Sprintf("%c %s %s", tactic.Side, squares(tactic.Attackers), squares(tactic.Targets))
+	if len(tactic.Squares) > 0 {
+		res += " " + fmt.Sprint(tactic.Squares)
+	}
+	return res
+}
+
+func TestFindMotif(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		moves    []string
+		motif    Motif
+		expected []string
+	}{
+		{"knight fork", "q3k3/2N5/8/8/8/8/8/4K3 b - - 0 1", nil, Fork, []string{"w [c7] [a8 e8]"}},
+		{"defended pieces aren't forked", "7k/1pN2p2/b3b3/8/8/8/8/4K3 b - - 0 1", nil, Fork, nil},
+		{"absolute pin", "4k3/8/2n5/1B6/8/8/8/4K3 w - - 0 1", nil, Pin, []string{"w [b5] [c6 e8]"}},
+		{"relative pin", "6k1/3q2pp/2n1b3/1B6/8/8/5PPP/4R1K1 w - - 0 1", nil, Pin, []string{"w [b5] [c6 d7]"}},
+		{"skewer", "7r/8/5k2/8/8/2B5/8/4K3 w - - 0 1", nil, Skewer, []string{"w [c3] [f6 h8]"}},
+		{"no skewer of a pawn", "8/6p1/5k2/8/8/2B5/8/4K3 w - - 0 1", nil, Skewer, nil},
+		{"discovered attack", "4q1k1/8/8/8/8/4N3/8/4R1K1 w - - 0 1", []string{"Ng4"}, DiscoveredAttack, []string{"w [e1 g4] [e8]"}},
+		{"discovered check", "4k3/8/8/8/4N3/8/8/4R1K1 w - - 0 1", []string{"Nf6+"}, DiscoveredAttack, []string{"w [e1 f6] [e8]"}},
+		{"double check", "4k3/8/8/8/4N3/8/8/4R1K1 w - - 0 1", []string{"Nf6+"}, DoubleCheck, []string{"w [e1 f6] [e8]"}},
+		{"single check", "4k3/8/8/8/4N3/8/8/4R1K1 w - - 0 1", []string{"Nc5+"}, DoubleCheck, nil},
+		{"overloaded queen", "6k1/3q2pp/2n1b3/1B6/8/8/5PPP/4R1K1 w - - 0 1", nil, OverloadedDefender, []string{"w [b5 e1] [d7 c6 e6]"}},
+		{"back rank", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", nil, BackRankWeakness, []string{"w [a1] [g8] [f7 g7 h7]"}},
+		{"luft", "6k1/5pp1/7p/8/8/8/8/R5K1 w - - 0 1", nil, BackRankWeakness, nil},
+		{"attacked luft", "6k1/5pp1/7p/8/8/8/8/RB4K1 w - - 0 1", nil, BackRankWeakness, []string{"w [a1] [g8] [f7 g7 h7]"}},
+		{"hanging pieces", "4k3/8/8/3r4/8/8/3Q4/4K3 w - - 0 1", nil, HangingPiece, []string{"b [d5] [d2]", "w [d2] [d5]"}},
+		{"defended piece", "4k3/4p3/3r4/8/8/8/3R4/4K3 w - - 0 1", nil, HangingPiece, nil},
+		{"piece defended by fewer pieces", "3rk3/8/8/3n4/8/8/3R4/3QK3 w - - 0 1", nil, HangingPiece, []string{"w [d2] [d5]"}},
+	}
+
+	for _, test := range tests {
+		game := newGame(t, test.fen, test.moves...)
+
+		var got []string
+		for _, tactic := range FindMotif(&game, test.motif) {
+			if tactic.Motif != test.motif {
+				t.Fatalf("%s: unexpected motif %v", test.name, tactic.Motif)
+			}
+			got = append(got, describe(tactic))
+		}
+		if !slices.Equal(got, test.expected) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	game := newGame(t, "q3k3/2N5/8/8/8/8/8/4K3 b - - 0 1")
+
+	var got []string
+	for _, tactic := range Find(&game) {
+		got = append(got, tactic.Motif.String()+" "+describe(tactic))
+	}
+	expected := []string{"fork w [c7] [a8 e8]", "hanging piece w [c7] [a8]"}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}